@@ -19,6 +19,7 @@ package cmd
 
 import (
 	"errors"
+	"log"
 	"strings"
 
 	"github.com/norbjd/pasuman/pkg/add"
@@ -37,6 +38,20 @@ var (
 	addCmdSite        string
 	addCmdID          string
 	addCmdPassword    string
+	addCmdPolicy      string
+	addCmdGenerate    string
+	addCmdCopy        bool
+
+	addCmdFields          []string
+	addCmdSensitiveFields []string
+
+	addCmdOTP   string
+	addCmdOTPQR string
+
+	addCmdType     string
+	addCmdNote     string
+	addCmdNoteFile string
+	addCmdAttach   []string
 )
 
 var (
@@ -57,6 +72,49 @@ func addCmdInit() {
 	addCmd.Flags().StringVar(&addCmdSite, "site", "", "Site")
 	addCmd.Flags().StringVar(&addCmdID, "id", "", "ID")
 	addCmd.Flags().StringVar(&addCmdPassword, "password", "", "Password")
+	addCmd.Flags().StringVar(&addCmdPolicy, "policy", "",
+		policyUsage+" to bind the entry to (used by --generate and --rotate)")
+	addCmd.Flags().StringVar(&addCmdGenerate, "generate", "",
+		"Generate the password, complying with this password policy (default: --policy if set, or the policy "+
+			"named default of the config file if any)")
+	addCmd.Flags().Lookup("generate").NoOptDefVal = defaultPolicy
+	addCmd.MarkFlagsMutuallyExclusive("password", "generate")
+	addCmd.Flags().BoolVar(&addCmdCopy, "copy", false, copyUsage)
+	addCmd.Flags().StringArrayVar(&addCmdFields, "field", nil, fieldUsage)
+	addCmd.Flags().StringArrayVar(&addCmdSensitiveFields, "sensitive-field", nil, sensitiveFieldUsage)
+	addCmd.Flags().StringVar(&addCmdOTP, "otp", "", otpUsage)
+	addCmd.Flags().StringVar(&addCmdOTPQR, "otp-qr", "", otpQRUsage)
+	addCmd.MarkFlagsMutuallyExclusive("otp", "otp-qr")
+	addCmd.Flags().StringVar(&addCmdType, "type", "login", typeUsage)
+	addCmd.Flags().StringVar(&addCmdNote, "note", "", noteUsage)
+	addCmd.Flags().StringVar(&addCmdNoteFile, "note-file", "", noteFileUsage)
+	addCmd.MarkFlagsMutuallyExclusive("note", "note-file")
+	addCmd.Flags().StringArrayVar(&addCmdAttach, "attach", nil, attachUsage)
+
+	if err := addCmd.RegisterFlagCompletionFunc("type", typeCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := addCmd.RegisterFlagCompletionFunc("policy", policyCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := addCmd.RegisterFlagCompletionFunc("generate", policyCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := addCmd.RegisterFlagCompletionFunc("field", addFieldCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := addCmd.RegisterFlagCompletionFunc("sensitive-field", addFieldCompletion); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// addFieldCompletion - complete names of custom fields of other entries, as the entry does not exist yet.
+func addFieldCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return fieldDefinitionCompletion(cmd, nil, toComplete)
 }
 
 // nolint: funlen, gocognit
@@ -80,28 +138,59 @@ func addCmdRunE(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	cmdPrintf(addCmd, "Enter current master password: ")
+	if err := checkPolicy(addCmdPolicy); err != nil {
+		return err
+	}
+
+	if err := checkGeneratePolicy(addCmdGenerate, addCmdPolicy, addCmdCopy); err != nil {
+		return err
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
 
-	masterPassword, err := util.ReadPassword()
+	fields, err := parseFields(cmd, addCmdFields, addCmdSensitiveFields)
 	if err != nil {
 		return err
 	}
 
-	correct, err := masterpassword.IsCorrect(masterPassword)
+	otpKey, err := readOTPKey(addCmdOTP, addCmdOTPQR)
 	if err != nil {
 		return err
 	}
 
-	if !correct {
-		cmdPrintln(addCmd, "✘")
+	entryType, err := parseType(addCmdType)
+	if err != nil {
+		return err
+	}
 
-		return util.ErrMasterPasswordIncorrect
+	note, err := readNote(addCmdNote, addCmdNoteFile)
+	if err != nil {
+		return err
 	}
 
-	cmdPrintln(addCmd, "✔")
+	attachments, err := readAttachments(addCmdAttach)
+	if err != nil {
+		return err
+	}
+
+	if entryType == data.TypeNote && note == "" && len(attachments) == 0 {
+		return errEmptyNote
+	}
+
+	if addCmdGenerate != "" {
+		addCmdPassword, addCmdPolicy, err = generatePassword(cmd, addCmdGenerate, addCmdPolicy)
+		if err != nil {
+			return err
+		}
+	}
 
-	interactive := addCmdDescription == "" && len(addCmdTags) == 0 && addCmdSite == "" &&
-		addCmdID == "" && addCmdPassword == ""
+	// entries of type note have no ID nor password to ask for
+	interactive := entryType == data.TypeLogin && addCmdDescription == "" && len(addCmdTags) == 0 &&
+		addCmdSite == "" && addCmdID == "" && addCmdPassword == "" && len(fields) == 0 && otpKey == "" &&
+		note == "" && len(attachments) == 0
 
 	// nolint: nestif
 	if interactive {
@@ -177,11 +266,17 @@ func addCmdRunE(cmd *cobra.Command, args []string) error {
 		masterPassword,
 		data.Entry{
 			UniqueID:    uniqueID,
+			Type:        entryType,
 			Description: addCmdDescription,
 			Tags:        addCmdTags,
 			Site:        addCmdSite,
 			ID:          addCmdID,
 			Password:    addCmdPassword,
+			Policy:      addCmdPolicy,
+			Fields:      fields,
+			OTP:         otpKey,
+			Note:        note,
+			Attachments: attachments,
 		},
 	)
 	if err != nil {
@@ -190,5 +285,5 @@ func addCmdRunE(cmd *cobra.Command, args []string) error {
 
 	cmdPrintf(addCmd, "New entry: %s\n", uniqueID)
 
-	return nil
+	return copyGeneratedPassword(cmd, addCmdPassword, addCmdCopy)
 }
@@ -19,10 +19,18 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/generate"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -74,3 +82,147 @@ func TestAdd(t *testing.T) {
 		pasumantest.Teardown(t, RootCmd)
 	}
 }
+
+func TestAddInteractiveFromPipedStdin(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	passwordFile := filepath.Join(tempDir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte(pasumantest.TestMasterPassword+"\n"), 0o600))
+
+	// the password of the entry is read from stdin too
+	t.Setenv("PASUMAN_MASTER_PASSWORD", "")
+
+	util.SetStdin(strings.NewReader("id1\r\nA desc\ntag1,tag2\nhttps://mysupersite.pasuman\nmyId\np4$$w0rd!"))
+	defer util.SetStdin(os.Stdin)
+
+	out, err := pasumantest.ExecuteCommand(RootCmd, "add", "--password-file", passwordFile)
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"Enter unique id (leave empty to generate a random one): "+
+		"Enter description: "+
+		"Enter tags (comma-separated): "+
+		"Enter site: "+
+		"Enter id: "+
+		"Enter password: ✔\n"+
+		"New entry: id1\n", out)
+
+	pasumantest.Teardown(t, RootCmd)
+
+	entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
+	require.NoError(t, err)
+	require.Equal(t, data.Entry{
+		UniqueID:    "id1",
+		Description: "A desc",
+		Tags:        []string{"tag1", "tag2"},
+		Site:        "https://mysupersite.pasuman",
+		ID:          "myId",
+		Password:    "p4$$w0rd!",
+	}, entry)
+}
+
+func TestAddGenerate(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	// the profile is unlocked by every command
+	defer pasumantest.FastKDF()()
+
+	length := uint(12)
+
+	pasumantest.UpdateConfig(t, func(conf *config.Config) {
+		conf.Policies = map[string]generate.Rules{
+			"pin":  {Length: &length, Classes: []string{generate.ClassDigit}},
+			"wifi": {Passphrase: true},
+		}
+	})
+
+	tests := []struct {
+		args       []string
+		pattern    string
+		entropy    string
+		wantPolicy string
+		copied     bool
+		noOutTTY   bool
+		wantErr    error
+	}{
+		{
+			args:    []string{"add", "id1", "--id=myId", "--generate"},
+			pattern: `^.{128}$`,
+			entropy: "839.0",
+		},
+		{
+			args:       []string{"add", "id2", "--id=myId", "--generate=pin"},
+			pattern:    `^[0-9]{12}$`,
+			entropy:    "39.9",
+			wantPolicy: "pin",
+		},
+		{
+			args:       []string{"add", "id3", "--id=myId", "--generate", "--policy=wifi"},
+			pattern:    `^[a-z-]+(-[a-z-]+){5}$`,
+			entropy:    "77.5",
+			wantPolicy: "wifi",
+		},
+		{
+			args:       []string{"add", "id4", "--id=myId", "--generate=pin", "--policy=pin", "--copy"},
+			pattern:    `^[0-9]{12}$`,
+			entropy:    "39.9",
+			wantPolicy: "pin",
+			copied:     true,
+		},
+		{
+			args:    []string{"add", "id5", "--id=myId", "--generate=bank"},
+			wantErr: config.ErrUnknownPolicy,
+		},
+		{
+			args:    []string{"add", "id5", "--id=myId", "--generate=pin", "--policy=wifi"},
+			wantErr: errPolicyConflict,
+		},
+		{
+			args:    []string{"add", "id5", "--id=myId", "--password=p4$$w0rd!", "--copy"},
+			wantErr: errCopyFlag,
+		},
+		{
+			args:     []string{"add", "id5", "--id=myId", "--generate=pin", "--copy"},
+			noOutTTY: true,
+			wantErr:  errCopyToTerminal,
+		},
+	}
+
+	util.SetStdin(strings.NewReader("\n"))
+	defer util.SetStdin(os.Stdin)
+
+	defer func() { copyStdoutIsTerminal = func() bool { return util.IsTerminal(os.Stdout) } }()
+
+	for _, tt := range tests {
+		copyStdoutIsTerminal = func() bool { return !tt.noOutTTY }
+
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+
+			entry, err := get.Sensitive(pasumantest.TestMasterPassword, tt.args[1])
+			require.NoError(t, err)
+			require.Regexp(t, regexp.MustCompile(tt.pattern), entry.Password, tt.args)
+			require.Equal(t, tt.wantPolicy, entry.Policy, tt.args)
+
+			// the generated password is never printed
+			require.NotContains(t, out, entry.Password, tt.args)
+
+			wantOutput := "" +
+				"Enter current master password: ✔\n" +
+				"Password generated, entropy: " + tt.entropy + " bits\n" +
+				"New entry: " + tt.args[1] + "\n"
+			if tt.copied {
+				wantOutput += clipboardNote + "\n\n" +
+					"Password copied to clipboard*, press enter to clear clipboard (cleared after 45s)"
+			}
+
+			require.Equal(t, wantOutput, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
@@ -0,0 +1,105 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/spf13/cobra"
+)
+
+const (
+	agentCmdDefaultIdleTimeout = 15 * time.Minute
+
+	// agentCmdParentCheckInterval - how often the agent checks whether the process that started it is still running.
+	agentCmdParentCheckInterval = 5 * time.Second
+)
+
+var agentCmd *cobra.Command
+
+var agentCmdIdleTimeout time.Duration
+
+func agentCmdInit() {
+	agentCmd = &cobra.Command{
+		Use:   "agent",
+		Short: "Run the agent, keeping unlocked profiles in memory",
+		Long: "Run the agent, keeping unlocked profiles in memory.\n" +
+			"Once a profile is unlocked with `pasuman unlock`, other commands get its keys from the agent " +
+			"instead of asking the master password. Keys are kept in locked memory (never written to swap), " +
+			"and forgotten after the idle timeout, on `pasuman lock`, or when the agent stops.\n" +
+			"The agent stops when the session ends: when it receives SIGHUP or SIGTERM, " +
+			"or when the process that started it (e.g. the login shell) exits.",
+		Args: cobra.NoArgs,
+		RunE: agentCmdRunE,
+	}
+
+	agentCmd.Flags().DurationVar(&agentCmdIdleTimeout, "idle-timeout", agentCmdDefaultIdleTimeout,
+		"Forget keys after this duration without any command using them (0 to keep them until `pasuman lock`)")
+}
+
+func agentCmdRunE(cmd *cobra.Command, args []string) error {
+	listener, err := agent.Listen()
+	if err != nil {
+		return err
+	}
+
+	server := &agent.Server{IdleTimeout: agentCmdIdleTimeout}
+
+	go stopAgentOnSessionEnd(listener)
+
+	cmdStderrPrintf(cmd, "Agent listening on %s\n", agent.SocketPath())
+
+	err = server.Serve(listener)
+
+	server.LockAll()
+
+	cmdStderrPrintln(cmd, "Agent stopped: all profiles are locked")
+
+	return err
+}
+
+// stopAgentOnSessionEnd - close listener (and so stop the agent) when the session ends.
+func stopAgentOnSessionEnd(listener net.Listener) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	parentPID := os.Getppid()
+
+	ticker := time.NewTicker(agentCmdParentCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-signals:
+			listener.Close()
+
+			return
+		case <-ticker.C:
+			if os.Getppid() != parentPID {
+				listener.Close()
+
+				return
+			}
+		}
+	}
+}
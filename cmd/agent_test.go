@@ -0,0 +1,163 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/encrypt"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{
+		UniqueID: "id1",
+		ID:       "myId",
+		Password: "p4$$w0rd!",
+	})
+	require.NoError(t, err)
+
+	out, err := pasumantest.ExecuteCommand(RootCmd, "unlock")
+	require.ErrorIs(t, err, agent.ErrNotRunning)
+	require.Equal(t, ""+
+		"Enter current master password: ✔\n"+
+		"Error: agent is not running: start it with `pasuman agent`\n", out)
+
+	pasumantest.Teardown(t, RootCmd)
+
+	listener, err := agent.Listen()
+	require.NoError(t, err)
+
+	server := &agent.Server{}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	defer listener.Close()
+
+	tests := []struct {
+		args   []string
+		output string
+	}{
+		{
+			args: []string{"get", "id1", "--password"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"p4$$w0rd!\n",
+		},
+		{
+			args: []string{"unlock"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Profile is unlocked\n",
+		},
+		{
+			args:   []string{"get", "id1", "--password"},
+			output: "p4$$w0rd!\n",
+		},
+		{
+			args:   []string{"convert", "sealed"},
+			output: "Profile is now sealed\n",
+		},
+		{
+			// unique IDs of a sealed profile can be completed while it is unlocked
+			args: []string{"__complete", "get", ""},
+			output: "" +
+				"id1\n" +
+				":4\n" +
+				"Completion ended with directive: ShellCompDirectiveNoFileComp\n",
+		},
+		{
+			args:   []string{"lock"},
+			output: "Profile is locked\n",
+		},
+		{
+			args: []string{"get", "id1", "--password"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"p4$$w0rd!\n",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		require.NoError(t, err, tt.args)
+		require.Equal(t, tt.output, out, tt.args)
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
+
+func TestAgentUpgradesLegacyValues(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	defer pasumantest.FastKDF()()
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{UniqueID: "id1", ID: "myId", Password: "p4$$w0rd!"})
+	require.NoError(t, err)
+
+	// as encrypted by older versions of pasuman, with the master password
+	var d data.Data
+
+	_, err = d.Open(config.PasumanDataFile, pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+
+	d.Entries[0].Password, err = encrypt.Encrypt(pasumantest.TestMasterPassword, "p4$$w0rd!")
+	require.NoError(t, err)
+	require.NoError(t, d.ToFile(config.PasumanDataFile))
+	require.True(t, d.NeedsEncryptionUpgrade())
+
+	listener, err := agent.Listen()
+	require.NoError(t, err)
+
+	server := &agent.Server{}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	defer listener.Close()
+
+	out, err := pasumantest.ExecuteCommand(RootCmd, "unlock")
+	require.NoError(t, err)
+	require.Equal(t, "Enter current master password: ✔\nProfile is unlocked\n", out)
+
+	pasumantest.Teardown(t, RootCmd)
+
+	// the agent does not know the master password: the password has been re-encrypted with the data key
+	out, err = pasumantest.ExecuteCommand(RootCmd, "get", "id1", "--password")
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!\n", out)
+
+	pasumantest.Teardown(t, RootCmd)
+
+	require.NoError(t, d.FromFile(config.PasumanDataFile))
+	require.False(t, d.NeedsEncryptionUpgrade())
+}
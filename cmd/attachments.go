@@ -0,0 +1,241 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/list"
+	"github.com/spf13/cobra"
+)
+
+// outFileMode - mode of files attachments are written to: as profile files, they are only readable by their owner.
+const outFileMode = os.FileMode(0o600)
+
+var (
+	errInvalidType         = errors.New("invalid entry type: must be login or note")
+	errEmptyNote           = errors.New("entries of type note must have a note or an attachment")
+	errInvalidNote         = errors.New("notes must be text")
+	errDuplicateAttachment = errors.New("attachment given twice")
+	errOutRequired         = errors.New("--out is required to get an attachment")
+)
+
+// typeUsage, noteUsage, noteFileUsage, attachUsage - usage of flags giving the type, the note and attachments
+// of an entry.
+const (
+	typeUsage     = "Type of the entry: login (ID and password), or note (secure note and attachments)"
+	noteUsage     = "Secure note, encrypted as the password"
+	noteFileUsage = "Text file to read the secure note from (for multi-line notes)"
+	attachUsage   = "File to attach to the entry, encrypted as the password, named after its base name " +
+		"(can be repeated)"
+)
+
+// parseType - type of the entry given by --type ("login" is stored as the default type).
+func parseType(name string) (string, error) {
+	switch name {
+	case "", "login":
+		return data.TypeLogin, nil
+	case data.TypeNote:
+		return data.TypeNote, nil
+	default:
+		return "", fmt.Errorf("%w: %s", errInvalidType, name)
+	}
+}
+
+// typeCompletion - complete types of entries.
+func typeCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return []string{"login", data.TypeNote}, cobra.ShellCompDirectiveNoFileComp
+}
+
+// readNote - note given by --note, or read from the file given by --note-file.
+func readNote(note, noteFile string) (string, error) {
+	if noteFile != "" {
+		info, err := os.Stat(noteFile)
+		if err != nil {
+			return "", err
+		}
+
+		if info.Size() > data.MaxNoteSize {
+			return "", fmt.Errorf("%w: note is larger than %d bytes, attach it instead", data.ErrTooLarge,
+				data.MaxNoteSize)
+		}
+
+		content, err := os.ReadFile(noteFile)
+		if err != nil {
+			return "", err
+		}
+
+		note = string(content)
+	}
+
+	if len(note) > data.MaxNoteSize {
+		return "", fmt.Errorf("%w: note is larger than %d bytes, attach it instead", data.ErrTooLarge,
+			data.MaxNoteSize)
+	}
+
+	// NUL is the padding separator of encrypted values
+	if strings.ContainsRune(note, 0) {
+		return "", fmt.Errorf("%w: attach binary files instead", errInvalidNote)
+	}
+
+	return note, nil
+}
+
+// readAttachments - attachments given by --attach, read from files.
+func readAttachments(files []string) ([]data.Attachment, error) {
+	attachments := make([]data.Attachment, 0, len(files))
+	names := map[string]bool{}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+
+		if names[name] {
+			return nil, fmt.Errorf("%w: %s", errDuplicateAttachment, name)
+		}
+
+		names[name] = true
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.Size() > data.MaxAttachmentSize {
+			return nil, fmt.Errorf("%w: %s is larger than %d bytes", data.ErrTooLarge, file, data.MaxAttachmentSize)
+		}
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		attachments = append(attachments, data.Attachment{Name: name, Size: len(content), Content: content})
+	}
+
+	if len(attachments) == 0 {
+		return nil, nil
+	}
+
+	return attachments, nil
+}
+
+// jsonAttachment - attachment in JSON outputs: its content is only written by `get --attachment`.
+type jsonAttachment struct {
+	Name string `json:"name"`
+	Size int    `json:"size"`
+}
+
+// jsonAttachments - attachments in JSON outputs.
+func jsonAttachments(attachments []data.Attachment) []jsonAttachment {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	result := make([]jsonAttachment, len(attachments))
+
+	for idx, attachment := range attachments {
+		result[idx] = jsonAttachment{Name: attachment.Name, Size: attachment.Size}
+	}
+
+	return result
+}
+
+// attachmentsColumn - attachments in tables, as names followed by their size, separated by commas.
+func attachmentsColumn(attachments []data.Attachment) string {
+	columns := make([]string, len(attachments))
+
+	for idx, attachment := range attachments {
+		columns[idx] = fmt.Sprintf("%s (%d bytes)", attachment.Name, attachment.Size)
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// getAttachment - write attachment name of the entry uniqueID to out. out must not exist: it is created
+// readable only by its owner.
+func getAttachment(cmd *cobra.Command, uniqueID, name, out string) error {
+	if out == "" {
+		return errOutRequired
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	content, err := get.Attachment(masterPassword, uniqueID, name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, outFileMode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	cmdPrintf(cmd, "Attachment %s written to %s\n", name, out)
+
+	return nil
+}
+
+// attachmentNameCompletion - complete names of attachments of the entry given as argument.
+func attachmentNameCompletion(cmd *cobra.Command, args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	entries, err := list.List(rootCmdProfile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	var names []string
+
+	for _, entry := range entries {
+		if entry.UniqueID != args[0] {
+			continue
+		}
+
+		for _, attachment := range entry.Attachments {
+			names = append(names, attachment.Name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
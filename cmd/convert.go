@@ -0,0 +1,78 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/norbjd/pasuman/pkg/convert"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/spf13/cobra"
+)
+
+const (
+	convertCmdSealed   = "sealed"
+	convertCmdUnsealed = "unsealed"
+)
+
+var errInvalidConversion = errors.New("invalid conversion: must be one of " +
+	convertCmdSealed + ", " + convertCmdUnsealed)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <" + convertCmdSealed + "|" + convertCmdUnsealed + ">",
+	Short: "Convert profile to sealed or unsealed",
+	Long: "Convert profile to sealed or unsealed.\n" +
+		"In a sealed profile, all data (including unique IDs, descriptions, tags and sites) is encrypted, " +
+		"so the master password is needed to list or search entries.",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{convertCmdSealed, convertCmdUnsealed},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sealed bool
+
+		switch args[0] {
+		case convertCmdSealed:
+			sealed = true
+		case convertCmdUnsealed:
+			sealed = false
+		default:
+			return errInvalidConversion
+		}
+
+		masterPasswordSet, err := masterpassword.IsSet()
+		if err != nil {
+			return err
+		}
+
+		if !masterPasswordSet {
+			return errNoMasterPasswordSet
+		}
+
+		masterPassword, err := askMasterPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		if err := convert.Convert(masterPassword, sealed); err != nil {
+			return err
+		}
+
+		cmdPrintf(cmd, "Profile is now %s\n", args[0])
+
+		return nil
+	},
+}
@@ -0,0 +1,115 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/convert"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{
+		UniqueID:    "id1",
+		Description: "A desc",
+		Tags:        []string{"tag1", "tag2"},
+		Site:        "https://mysupersite.pasuman",
+		ID:          "myId",
+		Password:    "p4$$w0rd!",
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		args    []string
+		output  string
+		wantErr error
+	}{
+		{
+			args:    []string{"convert", "whatever"},
+			wantErr: errInvalidConversion,
+		},
+		{
+			args:    []string{"convert", "unsealed"},
+			wantErr: convert.ErrAlreadyUnsealed,
+		},
+		{
+			args: []string{"convert", "sealed"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Profile is now sealed\n",
+		},
+		{
+			args:    []string{"convert", "sealed"},
+			wantErr: convert.ErrAlreadySealed,
+		},
+		{
+			args: []string{"list"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Unique ID\tDescription\tTags\t\tSite\t\t\t\t\n" +
+				"---------\t-----------\t----\t\t----\t\t\t\t\n" +
+				"id1\t\tA desc\t\ttag1,tag2\thttps://mysupersite.pasuman\t\n",
+		},
+		{
+			// master password is asked only once
+			args: []string{"get", "id1", "--password"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"p4$$w0rd!\n",
+		},
+		{
+			// completion cannot ask master password
+			args:   []string{"__complete", "get", ""},
+			output: ":4\nCompletion ended with directive: ShellCompDirectiveNoFileComp\n",
+		},
+		{
+			args: []string{"convert", "unsealed"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Profile is now unsealed\n",
+		},
+		{
+			args: []string{"list"},
+			output: "" +
+				"Unique ID\tDescription\tTags\t\tSite\t\t\t\t\n" +
+				"---------\t-----------\t----\t\t----\t\t\t\t\n" +
+				"id1\t\tA desc\t\ttag1,tag2\thttps://mysupersite.pasuman\t\n",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, tt.output, out)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
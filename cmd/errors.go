@@ -21,4 +21,7 @@ import (
 	"errors"
 )
 
-var errNoMasterPasswordSet = errors.New("no master password set: run `pasuman master-password`")
+var (
+	errNoMasterPasswordSet = errors.New("no master password set: run `pasuman master-password`")
+	errEmptyPassword       = errors.New("must not be empty")
+)
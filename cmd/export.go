@@ -0,0 +1,400 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/norbjd/pasuman/pkg/archive"
+	"github.com/norbjd/pasuman/pkg/audit"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/csvmap"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/export"
+	"github.com/norbjd/pasuman/pkg/listprofiles"
+	"github.com/norbjd/pasuman/pkg/lock"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var exportCmd *cobra.Command
+
+var (
+	exportCmdFormat         string
+	exportCmdMap            []string
+	exportCmdEncrypted      bool
+	exportCmdAllProfiles    bool
+	exportCmdIncludeSecrets bool
+	exportCmdForce          bool
+)
+
+var (
+	errMapOnlyForCSV            = errors.New("--map can only be used with the csv format")
+	errExportFormatRequired     = errors.New("--format or --encrypted is required")
+	errAllProfilesOnlyEncrypted = errors.New("--all-profiles can only be used with --encrypted")
+	errPassphrasesMismatch      = errors.New("passphrases mismatch")
+	errSecretsToTerminal        = errors.New("refusing to write IDs and passwords to a terminal: " +
+		"give a file, or use --force")
+)
+
+func exportCmdInit() {
+	exportCmd = &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export entries",
+		Long: "Export entries to file (created only readable and writable by its owner), or to the standard " +
+			"output if no file is given.\n" +
+			"Supported formats:\n" +
+			"  - " + string(export.JSON) + ": list of entries\n" +
+			"  - " + string(export.YAML) + ": list of entries\n" +
+			"  - " + string(export.CSV) + ": CSV file with a header; use --map to choose columns (by default, one " +
+			"column per field: " + strings.Join(csvMapNames(csvmap.Default), ", ") + ")\n" +
+			"IDs, passwords, keys of one-time passwords, notes and values of sensitive custom fields are left out, " +
+			"e.g. for inventory reports, " +
+			"unless --include-secrets is given: " +
+			"they are then exported in plaintext, so keep the exported file safe, and delete it once used. " +
+			"Such exports are written to the audit log of the profile, and are refused to a terminal " +
+			"(unless --force is given).\n" +
+			"With --encrypted, an archive of the profile (or of all profiles) is exported instead, encrypted with " +
+			"a new passphrase: use it as a backup, restored with `pasuman restore`. It does not depend on master " +
+			"passwords, nor on the format of profile files. Attachments are only exported in archives (other formats " +
+			"only list their names and sizes).",
+		Args: cobra.MaximumNArgs(1),
+		RunE: exportCmdRunE,
+	}
+
+	exportCmd.Flags().StringVar(&exportCmdFormat, "format", "",
+		"Format of the file: "+strings.Join(exportFormats(), ", "))
+	exportCmd.Flags().StringSliceVar(&exportCmdMap, "map", nil, csvMapUsage)
+	exportCmd.Flags().BoolVar(&exportCmdIncludeSecrets, "include-secrets", false,
+		"Export IDs, passwords, keys of one-time passwords, notes and values of sensitive custom fields, in plaintext")
+	exportCmd.Flags().BoolVar(&exportCmdForce, "force", false,
+		"With --include-secrets, allow writing to a terminal")
+
+	exportCmd.Flags().BoolVar(&exportCmdEncrypted, "encrypted", false,
+		"Export an archive of the profile, encrypted with a new passphrase (see `pasuman restore`)")
+	exportCmd.Flags().BoolVar(&exportCmdAllProfiles, "all-profiles", false,
+		"With --encrypted, export all profiles in the archive")
+	exportCmd.MarkFlagsMutuallyExclusive("encrypted", "format")
+	exportCmd.MarkFlagsMutuallyExclusive("encrypted", "map")
+	exportCmd.MarkFlagsMutuallyExclusive("encrypted", "include-secrets")
+
+	if err := exportCmd.RegisterFlagCompletionFunc("format", exportFormatCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := exportCmd.RegisterFlagCompletionFunc("map", csvMapCompletion); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func exportCmdRunE(cmd *cobra.Command, args []string) error {
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	file := ""
+	if len(args) == 1 {
+		file = args[0]
+	}
+
+	if exportCmdEncrypted {
+		return exportTo(cmd, file, func(w io.Writer) (string, error) {
+			return exportArchive(cmd, w)
+		})
+	}
+
+	if exportCmdAllProfiles {
+		return errAllProfilesOnlyEncrypted
+	}
+
+	if exportCmdFormat == "" {
+		return errExportFormatRequired
+	}
+
+	format := export.Format(exportCmdFormat)
+
+	if !export.IsValidFormat(format) {
+		return fmt.Errorf("%w: %s", export.ErrInvalidFormat, exportCmdFormat)
+	}
+
+	if format != export.CSV && len(exportCmdMap) > 0 {
+		return errMapOnlyForCSV
+	}
+
+	mapping, err := csvMapping(exportCmdMap)
+	if err != nil {
+		return err
+	}
+
+	if exportCmdIncludeSecrets {
+		if file == "" && util.IsTerminal(cmd.OutOrStdout()) && !exportCmdForce {
+			return errSecretsToTerminal
+		}
+
+		cmdStderrPrintln(cmd, "WARNING: IDs and passwords are exported in plaintext!")
+		cmdStderrPrintln(cmd, "WARNING: keep the export safe, and delete it once used.")
+	}
+
+	return exportTo(cmd, file, func(w io.Writer) (string, error) {
+		return exportEntries(cmd, w, format, mapping, file)
+	})
+}
+
+// exportTo - write the export to file (a new file, only readable and writable by its owner), or to the standard
+// output if file is empty, and then print what has been exported, as returned by write (e.g. "3 entries").
+func exportTo(cmd *cobra.Command, file string, write func(w io.Writer) (string, error)) error {
+	if file == "" {
+		_, err := write(cmd.OutOrStdout())
+
+		return err
+	}
+
+	// an existing file is never overwritten, as it may not be an export
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	exported, err := write(f)
+	if err != nil {
+		_ = os.Remove(file)
+
+		return err
+	}
+
+	cmdStderrPrintf(cmd, "Exported %s to %s\n", exported, file)
+
+	return nil
+}
+
+// exportEntries - write entries to w in format, with secrets only if --include-secrets is given (the export is
+// then logged in the audit log of the profile, with its destination file, or the standard output if empty).
+func exportEntries(cmd *cobra.Command, w io.Writer, format export.Format, mapping csvmap.Mapping,
+	file string,
+) (string, error) {
+	includeSecrets := exportCmdIncludeSecrets && (format != export.CSV || mapping.Sensitive())
+	masterPassword := ""
+
+	if includeSecrets {
+		var err error
+
+		if masterPassword, err = askMasterPassword(cmd); err != nil {
+			return "", err
+		}
+	}
+
+	entries, err := export.Entries(masterPassword, includeSecrets)
+	if err != nil {
+		return "", err
+	}
+
+	if err := export.Write(w, format, entries, includeSecrets, mapping); err != nil {
+		return "", err
+	}
+
+	if includeSecrets {
+		destination := file
+		if destination == "" {
+			destination = "standard output"
+		}
+
+		event := fmt.Sprintf("export of %d entries with secrets, as %s, to %s", len(entries), format, destination)
+
+		if err := audit.Log(config.PasumanDataFile, event); err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%d entries", len(entries)), nil
+}
+
+// exportArchive - write an archive of the profile (or of all profiles, asking the master password of each
+// of them, unless it is unlocked in the agent), encrypted with a new passphrase.
+func exportArchive(cmd *cobra.Command, w io.Writer) (string, error) {
+	names := []string{rootCmdProfile}
+
+	if exportCmdAllProfiles {
+		var err error
+
+		if names, err = listprofiles.ListProfiles(); err != nil {
+			return "", err
+		}
+	}
+
+	profiles := make([]archive.Profile, 0, len(names))
+	entries := 0
+
+	for _, name := range names {
+		profile, err := exportArchiveProfile(cmd, name)
+		if errors.Is(err, errNoMasterPasswordSet) {
+			cmdStderrPrintf(cmd, "Skipped profile %s: no master password set\n", name)
+
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("profile %s: %w", name, err)
+		}
+
+		profiles = append(profiles, profile)
+		entries += len(profile.Entries)
+	}
+
+	passphrase, err := askNewPassword(cmd, "export passphrase", errPassphrasesMismatch)
+	if err != nil {
+		return "", err
+	}
+
+	if err := archive.Write(w, passphrase, profiles); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%d profiles (%d entries)", len(profiles), entries), nil
+}
+
+func exportArchiveProfile(cmd *cobra.Command, name string) (archive.Profile, error) {
+	if name == rootCmdProfile {
+		masterPassword, err := askMasterPassword(cmd)
+		if err != nil {
+			return archive.Profile{}, err
+		}
+
+		return archive.ReadProfile(name, config.PasumanDataFile, masterPassword)
+	}
+
+	dataFile := config.GetDataFile(config.GetConfig(), name)
+
+	// other profiles are not locked by the root command
+	l, err := lock.Acquire(dataFile+".lock", lock.Shared, rootCmdWait)
+	if err != nil {
+		return archive.Profile{}, err
+	}
+
+	defer func() { _ = l.Release() }()
+
+	var d data.Data
+
+	if err := d.FromFile(dataFile); err != nil && !errors.Is(err, data.ErrSealed) {
+		return archive.Profile{}, err
+	}
+
+	if d.MasterPassword == "" {
+		return archive.Profile{}, errNoMasterPasswordSet
+	}
+
+	masterPassword, err := otherProfileMasterPassword(cmd, name, dataFile, &d)
+	if err != nil {
+		return archive.Profile{}, err
+	}
+
+	return archive.ReadProfile(name, dataFile, masterPassword)
+}
+
+// otherProfileMasterPassword - master password of another profile than the one of the command, read from
+// dataFile into d: from the agent if the profile is unlocked, from the source given by `--password-*` flags
+// (the same for all profiles), or asked.
+func otherProfileMasterPassword(cmd *cobra.Command, name, dataFile string, d *data.Data) (string, error) {
+	if wrappedDataKey, dataKey, err := agent.Get(dataFile); err == nil && wrappedDataKey == d.DataKey {
+		return data.RememberKeys(wrappedDataKey, dataKey)
+	}
+
+	if passwordSource().IsSet() {
+		return readPasswordSource()
+	}
+
+	cmdStderrPrintf(cmd, "Enter current master password of profile %s: ", name)
+
+	masterPassword, err := util.ReadPassword()
+	if err != nil {
+		return "", err
+	}
+
+	// keys are kept, so the master password is checked only once
+	if _, err := d.Unlock(masterPassword); err != nil {
+		cmdStderrPrintln(cmd, "✘")
+
+		return "", err
+	}
+
+	cmdStderrPrintln(cmd, "✔")
+
+	return masterPassword, nil
+}
+
+// csvMapUsage - usage of the --map flag of import and export commands.
+var csvMapUsage = "Columns of CSV files, as a preset (" + strings.Join(csvmap.PresetNames(), ", ") + ") or as " +
+	"comma-separated column=field (fields: " + strings.Join(csvMapFields(), ", ") + ", " +
+	"or nothing to ignore a column)"
+
+// csvMapping - mapping of columns of CSV files given by --map, or the default one.
+func csvMapping(definitions []string) (csvmap.Mapping, error) {
+	if len(definitions) == 0 {
+		return csvmap.Default, nil
+	}
+
+	return csvmap.ParseMapping(definitions)
+}
+
+// csvMapFields - names of fields columns can be mapped to.
+func csvMapFields() []string {
+	fields := make([]string, len(csvmap.Fields))
+	for idx, field := range csvmap.Fields {
+		fields[idx] = string(field)
+	}
+
+	return fields
+}
+
+func csvMapNames(mapping csvmap.Mapping) []string {
+	names := make([]string, len(mapping))
+	for idx, column := range mapping {
+		names[idx] = column.Name
+	}
+
+	return names
+}
+
+func exportFormats() []string {
+	names := make([]string, len(export.Formats))
+	for idx, format := range export.Formats {
+		names[idx] = string(format)
+	}
+
+	return names
+}
+
+func exportFormatCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return exportFormats(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func csvMapCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return csvmap.PresetNames(), cobra.ShellCompDirectiveNoFileComp
+}
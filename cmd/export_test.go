@@ -0,0 +1,203 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/audit"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/csvmap"
+	"github.com/norbjd/pasuman/pkg/export"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportCSV(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	chromeFile := filepath.Join("..", "pkg", "csvmap", "testdata", "chrome.csv")
+	exportFile := filepath.Join(tempDir, "export.csv")
+
+	tests := []struct {
+		args    []string
+		output  string
+		wantErr error
+	}{
+		{
+			args:    []string{"import", "--format=keepass-xml", "--map=chrome", chromeFile},
+			wantErr: errMapOnlyForCSV,
+		},
+		{
+			args:    []string{"import", "--format=csv", "--map=unknown", chromeFile},
+			wantErr: csvmap.ErrInvalidMapping,
+		},
+		{
+			args: []string{"import", "--format=csv", "--map=chrome", chromeFile},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Imported 2 entries (0 renamed, 0 overwritten), skipped 0\n",
+		},
+		{
+			args:    []string{"export", "--format=xml"},
+			wantErr: export.ErrInvalidFormat,
+		},
+		{
+			args:    []string{"export", "--format=json", "--map=chrome"},
+			wantErr: errMapOnlyForCSV,
+		},
+		{
+			// IDs and passwords are left out without --include-secrets
+			args: []string{"export", "--format=csv"},
+			output: "" +
+				"unique_id,description,tags,site\n" +
+				"forum.pasuman,,,https://forum.pasuman/login\n" +
+				"mail,\"Personal mailbox\nSecond line\",,https://mail.pasuman\n",
+		},
+		{
+			args: []string{"export", "--format=csv", "--include-secrets"},
+			output: "" +
+				"WARNING: IDs and passwords are exported in plaintext!\n" +
+				"WARNING: keep the export safe, and delete it once used.\n" +
+				"Enter current master password: ✔\n" +
+				"unique_id,description,tags,site,id,password\n" +
+				"forum.pasuman,,,https://forum.pasuman/login,myId,t0ps3cr3t!\n" +
+				"mail,\"Personal mailbox\nSecond line\",,https://mail.pasuman,me@mail.pasuman,\"p4$$,\"\"w0rd\"\"\"\n",
+		},
+		{
+			// the master password is not needed when IDs and passwords are not mapped
+			args: []string{"export", "--format=csv", "--map=Name=unique_id,Address=site,Comment=", "--include-secrets"},
+			output: "" +
+				"WARNING: IDs and passwords are exported in plaintext!\n" +
+				"WARNING: keep the export safe, and delete it once used.\n" +
+				"Name,Address,Comment\n" +
+				"forum.pasuman,https://forum.pasuman/login,\n" +
+				"mail,https://mail.pasuman,\n",
+		},
+		{
+			args: []string{"export", "--format=csv", "--map=firefox", "--include-secrets", exportFile},
+			output: "" +
+				"WARNING: IDs and passwords are exported in plaintext!\n" +
+				"WARNING: keep the export safe, and delete it once used.\n" +
+				"Enter current master password: ✔\n" +
+				"Exported 2 entries to " + exportFile + "\n",
+		},
+		{
+			args:    []string{"export", "--format=csv", exportFile},
+			wantErr: os.ErrExist,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	info, err := os.Stat(exportFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	content, err := os.ReadFile(exportFile)
+	require.NoError(t, err)
+	require.Equal(t, ""+
+		"url,username,password\n"+
+		"https://forum.pasuman/login,myId,t0ps3cr3t!\n"+
+		"https://mail.pasuman,me@mail.pasuman,\"p4$$,\"\"w0rd\"\"\"\n", string(content))
+}
+
+func TestExportJSONYAML(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	chromeFile := filepath.Join("..", "pkg", "csvmap", "testdata", "chrome.csv")
+
+	_, err := pasumantest.ExecuteCommand(RootCmd, "import", "--format=csv", "--map=chrome", chromeFile)
+	require.NoError(t, err)
+	pasumantest.Teardown(t, RootCmd)
+
+	tests := []struct {
+		args   []string
+		output string
+	}{
+		{
+			args: []string{"export", "--format=json"},
+			output: `[
+  {
+    "unique_id": "forum.pasuman",
+    "description": "",
+    "tags": [],
+    "site": "https://forum.pasuman/login"
+  },
+  {
+    "unique_id": "mail",
+    "description": "Personal mailbox\nSecond line",
+    "tags": [],
+    "site": "https://mail.pasuman"
+  }
+]
+`,
+		},
+		{
+			args: []string{"export", "--format=yaml", "--include-secrets"},
+			output: `WARNING: IDs and passwords are exported in plaintext!
+WARNING: keep the export safe, and delete it once used.
+Enter current master password: ✔
+- unique_id: forum.pasuman
+  description: ""
+  tags: []
+  site: https://forum.pasuman/login
+  id: myId
+  password: t0ps3cr3t!
+- unique_id: mail
+  description: |-
+    Personal mailbox
+    Second line
+  tags: []
+  site: https://mail.pasuman
+  id: me@mail.pasuman
+  password: p4$$,"w0rd"
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		require.NoError(t, err, tt.args)
+		require.Equal(t, tt.output, out, tt.args)
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	// only exports with secrets are logged
+	log, err := os.ReadFile(audit.File(config.PasumanDataFile))
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(log), "\n"))
+	require.Contains(t, string(log), "export of 2 entries with secrets, as yaml, to standard output")
+}
@@ -0,0 +1,198 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/list"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// hiddenValue - shown instead of values of sensitive custom fields, when they are not decrypted.
+const hiddenValue = "*****"
+
+var (
+	errInvalidField   = errors.New("custom fields must be given as name=value")
+	errDuplicateField = errors.New("custom field given twice")
+)
+
+// fieldUsage - usage of flags giving custom fields.
+const (
+	fieldUsage = "Custom field, as name=value (can be repeated)"
+
+	sensitiveFieldUsage = "Sensitive custom field, encrypted as the password, as name=value, or name to be asked " +
+		"for the value (can be repeated)"
+)
+
+// jsonField - custom field in JSON outputs. Values of sensitive fields are only output once decrypted.
+type jsonField struct {
+	Name      string  `json:"name"`
+	Value     *string `json:"value,omitempty"`
+	Sensitive bool    `json:"sensitive,omitempty"`
+}
+
+// parseFields - custom fields given as name=value by --field, and by --sensitive-field for sensitive ones
+// (the value of a sensitive field given by its name only is asked, so it does not appear in the shell history).
+func parseFields(cmd *cobra.Command, fields, sensitiveFields []string) ([]data.Field, error) {
+	parsed := make([]data.Field, 0, len(fields)+len(sensitiveFields))
+	names := map[string]bool{}
+
+	for idx, definition := range append(append([]string{}, fields...), sensitiveFields...) {
+		sensitive := idx >= len(fields)
+
+		name, value, found := strings.Cut(definition, "=")
+		name = strings.TrimSpace(name)
+
+		if name == "" || (!found && !sensitive) {
+			return nil, fmt.Errorf("%w: %q", errInvalidField, definition)
+		}
+
+		if names[name] {
+			return nil, fmt.Errorf("%w: %s", errDuplicateField, name)
+		}
+
+		names[name] = true
+
+		if !found {
+			cmdPrintf(cmd, "Enter %s: ", name)
+
+			var err error
+
+			if value, err = util.ReadPassword(); err != nil {
+				return nil, err
+			}
+
+			cmdPrintln(cmd, "✔")
+		}
+
+		parsed = append(parsed, data.Field{Name: name, Value: value, Sensitive: sensitive})
+	}
+
+	if len(parsed) == 0 {
+		return nil, nil
+	}
+
+	return parsed, nil
+}
+
+// jsonFields - custom fields in JSON outputs, without values of sensitive fields unless withSensitive is true.
+func jsonFields(fields []data.Field, withSensitive bool) []jsonField {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	result := make([]jsonField, len(fields))
+
+	for idx := range fields {
+		result[idx] = jsonField{Name: fields[idx].Name, Sensitive: fields[idx].Sensitive}
+
+		if withSensitive || !fields[idx].Sensitive {
+			result[idx].Value = &fields[idx].Value
+		}
+	}
+
+	return result
+}
+
+// fieldsColumn - custom fields in tables, as name=value separated by commas, with values of sensitive fields
+// hidden unless withSensitive is true.
+func fieldsColumn(fields []data.Field, withSensitive bool) string {
+	columns := make([]string, len(fields))
+
+	for idx, field := range fields {
+		value := field.Value
+		if field.Sensitive && !withSensitive {
+			value = hiddenValue
+		}
+
+		columns[idx] = field.Name + "=" + value
+	}
+
+	return strings.Join(columns, ", ")
+}
+
+// fieldNames - names of custom fields of the entry uniqueID, or of all entries if uniqueID is empty, sorted.
+func fieldNames(uniqueID string) ([]string, error) {
+	entries, err := list.List(rootCmdProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := map[string]bool{}
+
+	for _, entry := range entries {
+		if uniqueID != "" && entry.UniqueID != uniqueID {
+			continue
+		}
+
+		for _, field := range entry.Fields {
+			unique[field.Name] = true
+		}
+	}
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// fieldNameCompletion - complete names of custom fields of the entry given as argument.
+func fieldNameCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	names, err := fieldNames(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// fieldDefinitionCompletion - complete names of custom fields followed by "=", from the entry given as argument
+// if any, or from all entries (e.g. to reuse the same names).
+func fieldDefinitionCompletion(cmd *cobra.Command, args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	uniqueID := ""
+	if len(args) > 0 {
+		uniqueID = args[0]
+	}
+
+	names, err := fieldNames(uniqueID)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	for idx := range names {
+		names[idx] += "="
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
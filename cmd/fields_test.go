@@ -0,0 +1,174 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomFields(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	tests := []struct {
+		args    []string
+		output  string
+		wantErr error
+	}{
+		{
+			args:    []string{"add", "bank", "--password=p4$$w0rd!", "--field=Account number"},
+			wantErr: errInvalidField,
+		},
+		{
+			args:    []string{"add", "bank", "--password=p4$$w0rd!", "--field=PIN=1", "--sensitive-field=PIN=2"},
+			wantErr: errDuplicateField,
+		},
+		{
+			// the value of a sensitive field given by its name only is asked
+			args: []string{
+				"add", "bank", "--password=p4$$w0rd!", "--field=Account number=FR76-1234",
+				"--sensitive-field=PIN=4321", "--sensitive-field=Recovery codes",
+			},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Enter Recovery codes: ✔\n" +
+				"New entry: bank\n",
+		},
+		{
+			args: []string{"get", "bank", "--output=json"},
+			output: `{
+  "unique_id": "bank",
+  "description": "",
+  "tags": null,
+  "site": "",
+  "fields": [
+    {
+      "name": "Account number",
+      "value": "FR76-1234"
+    },
+    {
+      "name": "PIN",
+      "sensitive": true
+    },
+    {
+      "name": "Recovery codes",
+      "sensitive": true
+    }
+  ]
+}
+`,
+		},
+		{
+			// the master password is not needed for fields that are not sensitive
+			args:   []string{"get", "bank", "--field=Account number"},
+			output: "FR76-1234\n",
+		},
+		{
+			args: []string{"get", "bank", "--field=PIN"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"4321\n",
+		},
+		{
+			args:    []string{"get", "bank", "--field=pin"},
+			wantErr: data.ErrFieldNotFound,
+		},
+		{
+			args: []string{"search", "fr76", "--output=json"},
+			output: `[
+  {
+    "unique_id": "bank",
+    "description": "",
+    "tags": null,
+    "site": "",
+    "fields": [
+      {
+        "name": "Account number",
+        "value": "FR76-1234"
+      },
+      {
+        "name": "PIN",
+        "sensitive": true
+      },
+      {
+        "name": "Recovery codes",
+        "sensitive": true
+      }
+    ]
+  }
+]
+`,
+		},
+		{
+			// values of sensitive fields are not searched
+			args:   []string{"search", "4321", "--output=json"},
+			output: "[]\n",
+		},
+		{
+			args:    []string{"update", "bank", "--remove-field=Unknown"},
+			wantErr: data.ErrFieldNotFound,
+		},
+		{
+			args: []string{"update", "bank", "--remove-field=Recovery codes", "--field=PIN=0000"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Updated: bank\n",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	// sensitive fields stay sensitive
+	entry, err := get.Sensitive(pasumantest.TestMasterPassword, "bank")
+	require.NoError(t, err)
+	require.Equal(t, []data.Field{
+		{Name: "Account number", Value: "FR76-1234"},
+		{Name: "PIN", Value: "0000", Sensitive: true},
+	}, entry.Fields)
+
+	// names of custom fields are completed: of the entry, or of all entries when adding one
+	out, err := pasumantest.ExecuteCommand(RootCmd, "__complete", "get", "bank", "--field", "")
+	require.NoError(t, err)
+	require.Equal(t, "Account number\nPIN\n:4\nCompletion ended with directive: ShellCompDirectiveNoFileComp\n", out)
+
+	pasumantest.Teardown(t, RootCmd)
+
+	out, err = pasumantest.ExecuteCommand(RootCmd, "__complete", "add", "other", "--field", "")
+	require.NoError(t, err)
+	require.Equal(t, "Account number=\nPIN=\n:6\n"+
+		"Completion ended with directive: ShellCompDirectiveNoSpace, ShellCompDirectiveNoFileComp\n", out)
+
+	pasumantest.Teardown(t, RootCmd)
+}
@@ -18,30 +18,308 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/generate"
+	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/spf13/cobra"
 )
 
-const passwordDefaultLength = 128
+const (
+	passwordDefaultLength      = generate.DefaultLength
+	passphraseDefaultWords     = generate.DefaultWords
+	passphraseDefaultSeparator = generate.DefaultSeparator
+)
+
+const (
+	policyUsage = "Name of a password policy of the config file"
+	copyUsage   = "Copy the generated password to clipboard (it is never printed), until enter is pressed"
+
+	// defaultPolicy - policy of `--generate` and `--rotate` without a value: the policy of the entry if any,
+	// or the policy named "default" of the config file, or the rules of `pasuman generate` without flags.
+	defaultPolicy = "default"
+)
+
+var (
+	errPassphraseFlags = errors.New("--words, --separator, --capitalize, --append-digit and --wordlist " +
+		"require --passphrase")
+	errPasswordFlags = errors.New("--length, --classes, --min-lower, --min-upper, --min-digit, --min-symbol, " +
+		"--include, --exclude and --no-ambiguous cannot be used with --passphrase")
+	errPolicyFlags = errors.New("--policy cannot be used with other flags")
+
+	errPolicyConflict = errors.New("--policy must be the policy of the generated password, if both are set")
+	errCopyFlag       = errors.New("--copy requires a generated password")
+	errCopyToTerminal = errors.New("--copy requires stdout to be a terminal: " +
+		"it is copied to clipboard with an escape sequence written to stdout")
+)
+
+var (
+	// copyStdoutIsTerminal - whether stdout is a terminal, replaced in tests
+	copyStdoutIsTerminal = func() bool { return util.IsTerminal(os.Stdout) }
+	// copyClearTimeout - delay after which the clipboard is cleared if enter is not pressed
+	copyClearTimeout = 45 * time.Second
+)
+
+var (
+	passphraseFlags = []string{"words", "separator", "capitalize", "append-digit", "wordlist"}
+	passwordFlags   = []string{
+		"length", "classes", "min-lower", "min-upper", "min-digit", "min-symbol", "include", "exclude", "no-ambiguous",
+	}
+)
 
-var generateCmdLength uint
+var (
+	generateCmdLength      uint
+	generateCmdClasses     []string
+	generateCmdMinCounts   = map[string]*uint{}
+	generateCmdInclude     string
+	generateCmdExclude     string
+	generateCmdNoAmbiguous bool
+	generateCmdPassphrase  bool
+	generateCmdWords       uint
+	generateCmdSeparator   string
+	generateCmdCapitalize  bool
+	generateCmdAppendDigit bool
+	generateCmdWordlist    string
+	generateCmdPolicy      string
+)
 
 func generateCmdInit() {
 	generateCmd.Flags().UintVar(&generateCmdLength, "length", passwordDefaultLength,
 		"Length wanted for the generated password")
+	generateCmd.Flags().StringSliceVar(&generateCmdClasses, "classes", nil,
+		fmt.Sprintf("Classes of characters of the password, among %s (default all)",
+			strings.Join(generate.Classes, ", ")))
+
+	for _, class := range generate.Classes {
+		generateCmdMinCounts[class] = generateCmd.Flags().Uint("min-"+class, 0,
+			fmt.Sprintf("Minimum number of characters of class %s in the password", class))
+	}
+
+	generateCmd.Flags().StringVar(&generateCmdInclude, "include", "",
+		"Characters allowed in the password, in addition to its classes (e.g. '!@#$' with --classes lower,upper,digit)")
+	generateCmd.Flags().StringVar(&generateCmdExclude, "exclude", "", "Characters never used in the password")
+	generateCmd.Flags().BoolVar(&generateCmdNoAmbiguous, "no-ambiguous", false,
+		"Do not use characters easily mistaken for one another in the password (0, O, 1, l, I and |)")
+	generateCmd.Flags().BoolVar(&generateCmdPassphrase, "passphrase", false,
+		"Generate a passphrase (random words) instead of a password")
+	generateCmd.Flags().UintVar(&generateCmdWords, "words", passphraseDefaultWords, "Number of words of the passphrase")
+	generateCmd.Flags().StringVar(&generateCmdSeparator, "separator", passphraseDefaultSeparator,
+		"Separator between words of the passphrase (if empty, the entropy printed is overstated when different "+
+			"words make the same passphrase, e.g. sun flower and sunflower)")
+	generateCmd.Flags().BoolVar(&generateCmdCapitalize, "capitalize", false,
+		"Capitalize words of the passphrase (words of the wordlist only differing by case are merged)")
+	generateCmd.Flags().BoolVar(&generateCmdAppendDigit, "append-digit", false,
+		"Append a random digit to the passphrase")
+	generateCmd.Flags().StringVar(&generateCmdWordlist, "wordlist", "",
+		"File to pick words of the passphrase from, one word per line (default: the EFF large wordlist)")
+
+	generateCmd.Flags().StringVar(&generateCmdPolicy, "policy", "", policyUsage)
+
+	if err := generateCmd.RegisterFlagCompletionFunc("classes", classesCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := generateCmd.RegisterFlagCompletionFunc("policy", policyCompletion); err != nil {
+		log.Fatal(err)
+	}
 }
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
-	Short: "Generate a random password",
+	Short: "Generate a random password or passphrase",
+	Long: "Generate a random password, or a passphrase with --passphrase (words picked from the EFF large " +
+		"wordlist, or from --wordlist). Its entropy is printed on stderr, so only the password is printed on stdout.\n" +
+		"Passwords are picked uniformly among all passwords that comply with the rules given by flags, e.g. " +
+		"`--length 20 --classes lower,upper,digit --include '!@#$' --min-upper 1 --min-digit 1 --min-symbol 1`, " +
+		"or by a password policy of the config file, with --policy.",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		generated, err := generate.Generate(generateCmdLength)
+		rules, err := generateCmdRules(cmd)
+		if err != nil {
+			return err
+		}
+
+		generated, entropy, err := rules.Generate()
 		if err != nil {
 			return err
 		}
 
 		cmdPrintln(cmd, generated)
+		cmdStderrPrintf(cmd, "Entropy: %.1f bits\n", entropy)
 
 		return nil
 	},
 }
+
+// generateCmdRules - rules of the password to generate, from the policy or the flags of `pasuman generate`.
+func generateCmdRules(cmd *cobra.Command) (generate.Rules, error) {
+	changed := func(flags []string) bool {
+		for _, flag := range flags {
+			if cmd.Flags().Changed(flag) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if generateCmdPolicy != "" {
+		if generateCmdPassphrase || changed(passwordFlags) || changed(passphraseFlags) {
+			return generate.Rules{}, errPolicyFlags
+		}
+
+		return config.GetConfig().Policy(generateCmdPolicy)
+	}
+
+	if generateCmdPassphrase {
+		if changed(passwordFlags) {
+			return generate.Rules{}, errPasswordFlags
+		}
+
+		return generate.Rules{
+			Passphrase:  true,
+			Words:       &generateCmdWords,
+			Separator:   &generateCmdSeparator,
+			Capitalize:  generateCmdCapitalize,
+			AppendDigit: generateCmdAppendDigit,
+			Wordlist:    generateCmdWordlist,
+		}, nil
+	}
+
+	if changed(passphraseFlags) {
+		return generate.Rules{}, errPassphraseFlags
+	}
+
+	rules := generate.Rules{
+		Length:      &generateCmdLength,
+		Classes:     generateCmdClasses,
+		MinCounts:   map[string]uint{},
+		Include:     generateCmdInclude,
+		Exclude:     generateCmdExclude,
+		NoAmbiguous: generateCmdNoAmbiguous,
+	}
+
+	for class, minCount := range generateCmdMinCounts {
+		if *minCount > 0 {
+			rules.MinCounts[class] = *minCount
+		}
+	}
+
+	return rules, nil
+}
+
+func classesCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return generate.Classes, cobra.ShellCompDirectiveNoFileComp
+}
+
+// generatePassword - a password generated in-process, complying with the password policy name (see defaultPolicy,
+// entryPolicy being the policy of the entry), and the policy the entry must be bound to ("" for none).
+func generatePassword(cmd *cobra.Command, name, entryPolicy string) (string, string, error) {
+	bound := name
+
+	if name == defaultPolicy {
+		bound = entryPolicy
+
+		if entryPolicy != "" {
+			name = entryPolicy
+		}
+	}
+
+	rules, err := config.GetConfig().Policy(name)
+	if name == defaultPolicy && errors.Is(err, config.ErrUnknownPolicy) {
+		rules, err = generate.Rules{}, nil
+	}
+
+	if err != nil {
+		return "", "", err
+	}
+
+	password, entropy, err := rules.Generate()
+	if err != nil {
+		return "", "", err
+	}
+
+	cmdStderrPrintf(cmd, "Password generated, entropy: %.1f bits\n", entropy)
+
+	return password, bound, nil
+}
+
+// copyGeneratedPassword - copy a password generated by `generatePassword` to clipboard, if asked to.
+// The clipboard is cleared when enter is pressed, or after `copyClearTimeout`.
+func copyGeneratedPassword(cmd *cobra.Command, password string, toClipboard bool) error {
+	if !toClipboard {
+		return nil
+	}
+
+	return copyUntilEnter(cmd, "Password", password, copyClearTimeout)
+}
+
+// copyUntilEnter - copy value (what, e.g. "Password") to clipboard, and clear the clipboard when enter is pressed,
+// or after timeout. Stdout must be a terminal (see `errCopyToTerminal`).
+func copyUntilEnter(cmd *cobra.Command, what, value string, timeout time.Duration) error {
+	cmdPrintf(cmd, "%s\n\n", clipboardNote)
+	cmdPrintf(cmd, "%s copied to clipboard*, press enter to clear clipboard (cleared after %s)", what, timeout)
+
+	util.CopyToClipboard(value)
+
+	timer := time.AfterFunc(timeout, func() {
+		util.CopyToClipboard("")
+	})
+	defer timer.Stop()
+
+	if _, err := util.ReadLine(); err != nil {
+		util.CopyToClipboard("")
+
+		return err
+	}
+
+	util.CopyToClipboard("")
+
+	return nil
+}
+
+// checkGeneratePolicy - check the policy of a generated password (name, see defaultPolicy), against the one
+// the entry is bound to with --policy.
+func checkGeneratePolicy(name, policy string, toClipboard bool) error {
+	if name == "" {
+		if toClipboard {
+			return errCopyFlag
+		}
+
+		return nil
+	}
+
+	if toClipboard && !copyStdoutIsTerminal() {
+		return errCopyToTerminal
+	}
+
+	if name != defaultPolicy {
+		if policy != "" && policy != name {
+			return errPolicyConflict
+		}
+
+		return checkPolicy(name)
+	}
+
+	return nil
+}
+
+// checkPolicy - check that the password policy name is defined in the config file, if set.
+func checkPolicy(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	_, err := config.GetConfig().Policy(name)
+
+	return err
+}
+
+func policyCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return config.GetConfig().PolicyNames(), cobra.ShellCompDirectiveNoFileComp
+}
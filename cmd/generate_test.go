@@ -19,11 +19,15 @@ package cmd
 
 import (
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
 
 	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/generate"
 	"github.com/stretchr/testify/require"
 )
 
@@ -34,23 +38,27 @@ func TestGenerate(t *testing.T) {
 	tests := []struct {
 		args           []string
 		passwordLength int
+		entropy        string
 		wantErrString  string
 	}{
 		{
 			args:           []string{"generate"},
 			passwordLength: passwordDefaultLength,
+			entropy:        "839.0",
 		},
 		{
-			args:           []string{"generate", "--length=0"},
-			passwordLength: 0,
+			args:          []string{"generate", "--length=0"},
+			wantErrString: "invalid password policy: passwords must have one character at least",
 		},
 		{
 			args:           []string{"generate", "--length=1"},
 			passwordLength: 1,
+			entropy:        "6.6",
 		},
 		{
 			args:           []string{"generate", "--length=256"},
 			passwordLength: 256,
+			entropy:        "1678.0",
 		},
 		{
 			args: []string{"generate", "--length=-1"},
@@ -66,7 +74,12 @@ func TestGenerate(t *testing.T) {
 		} else {
 			require.NoError(t, err)
 
-			generatedPassword := strings.TrimSpace(out)
+			// the entropy is printed on stderr, after the password
+			lines := strings.Split(out, "\n")
+			require.Len(t, lines, 3)
+			require.Equal(t, "Entropy: "+tt.entropy+" bits", lines[1])
+
+			generatedPassword := lines[0]
 			require.Equal(t, tt.passwordLength, len(generatedPassword))
 
 			for _, r := range generatedPassword {
@@ -81,3 +94,214 @@ func TestGenerate(t *testing.T) {
 		pasumantest.Teardown(t, RootCmd)
 	}
 }
+
+func TestGeneratePassphrase(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	wordlist := filepath.Join(tempDir, "wordlist.txt")
+	require.NoError(t, os.WriteFile(wordlist, []byte("correct\nhorse\nbattery\nstaple\n"), 0o600))
+
+	invalidWordlist := filepath.Join(tempDir, "invalid.txt")
+	require.NoError(t, os.WriteFile(invalidWordlist, []byte("correct horse\nbattery staple\n"), 0o600))
+
+	tests := []struct {
+		args    []string
+		pattern string
+		entropy string
+		wantErr error
+	}{
+		{
+			args:    []string{"generate", "--passphrase"},
+			pattern: `^[a-z-]+(-[a-z-]+){5}$`,
+			entropy: "77.5",
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--words=3", "--separator=_", "--capitalize", "--append-digit"},
+			pattern: `^[A-Z][a-z-]+(_[A-Z][a-z-]+){2}[0-9]$`,
+			entropy: "42.1",
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--wordlist=" + wordlist, "--separator= "},
+			pattern: `^(correct|horse|battery|staple)( (correct|horse|battery|staple)){5}$`,
+			entropy: "12.0",
+		},
+		{
+			args:    []string{"generate", "--words=3"},
+			wantErr: errPassphraseFlags,
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--words=0"},
+			wantErr: generate.ErrInvalidPolicy,
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--wordlist=" + filepath.Join(tempDir, "missing.txt")},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--wordlist=" + invalidWordlist},
+			wantErr: generate.ErrInvalidWordlist,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+
+			lines := strings.Split(out, "\n")
+			require.Len(t, lines, 3, tt.args)
+			require.Regexp(t, regexp.MustCompile(tt.pattern), lines[0], tt.args)
+			require.Equal(t, "Entropy: "+tt.entropy+" bits", lines[1], tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
+
+func TestGeneratePolicy(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	tests := []struct {
+		args    []string
+		pattern string
+		// each of these sets of characters must appear in the password
+		requires []string
+		entropy  string
+		wantErr  error
+	}{
+		{
+			args:    []string{"generate", "--length=20", "--classes=lower,digit"},
+			pattern: `^[a-z0-9]{20}$`,
+			entropy: "103.4",
+		},
+		{
+			args: []string{
+				"generate", "--length=12", "--classes=lower,upper,digit", "--include=!@#$",
+				"--min-upper=1", "--min-digit=1", "--min-symbol=1",
+			},
+			pattern:  `^[a-zA-Z0-9!@#$]{12}$`,
+			requires: []string{"ABCDEFGHIJKLMNOPQRSTUVWXYZ", "0123456789", "!@#$"},
+		},
+		{
+			args:    []string{"generate", "--length=8", "--classes=digit", "--exclude=0123456"},
+			pattern: `^[789]{8}$`,
+			entropy: "12.7",
+		},
+		{
+			args:    []string{"generate", "--length=64", "--no-ambiguous"},
+			pattern: `^[^0O1lI|]{64}$`,
+		},
+		{
+			args:    []string{"generate", "--classes=emoji"},
+			wantErr: generate.ErrInvalidPolicy,
+		},
+		{
+			args:    []string{"generate", "--length=2", "--min-digit=3"},
+			wantErr: generate.ErrInvalidPolicy,
+		},
+		{
+			args:    []string{"generate", "--classes=lower", "--min-digit=1"},
+			wantErr: generate.ErrInvalidPolicy,
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--classes=lower"},
+			wantErr: errPasswordFlags,
+		},
+		{
+			args:    []string{"generate", "--passphrase", "--length=20"},
+			wantErr: errPasswordFlags,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+
+			lines := strings.Split(out, "\n")
+			require.Len(t, lines, 3, tt.args)
+			require.Regexp(t, regexp.MustCompile(tt.pattern), lines[0], tt.args)
+
+			for _, chars := range tt.requires {
+				require.True(t, strings.ContainsAny(lines[0], chars), tt.args)
+			}
+
+			if tt.entropy != "" {
+				require.Equal(t, "Entropy: "+tt.entropy+" bits", lines[1], tt.args)
+			}
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
+
+func TestGenerateNamedPolicy(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	length, words, separator := uint(8), uint(3), "_"
+
+	pasumantest.UpdateConfig(t, func(conf *config.Config) {
+		conf.Policies = map[string]generate.Rules{
+			"pin":     {Length: &length, Classes: []string{generate.ClassDigit}},
+			"wifi":    {Passphrase: true, Words: &words, Separator: &separator},
+			"invalid": {Passphrase: true, Length: &length},
+		}
+	})
+
+	tests := []struct {
+		args    []string
+		pattern string
+		entropy string
+		wantErr error
+	}{
+		{
+			args:    []string{"generate", "--policy=pin"},
+			pattern: `^[0-9]{8}$`,
+			entropy: "26.6",
+		},
+		{
+			args:    []string{"generate", "--policy=wifi"},
+			pattern: `^[a-z-]+(_[a-z-]+){2}$`,
+			entropy: "38.8",
+		},
+		{
+			args:    []string{"generate", "--policy=invalid"},
+			wantErr: generate.ErrInvalidPolicy,
+		},
+		{
+			args:    []string{"generate", "--policy=bank"},
+			wantErr: config.ErrUnknownPolicy,
+		},
+		{
+			args:    []string{"generate", "--policy=pin", "--length=4"},
+			wantErr: errPolicyFlags,
+		},
+		{
+			args:    []string{"generate", "--policy=wifi", "--passphrase"},
+			wantErr: errPolicyFlags,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+
+			lines := strings.Split(out, "\n")
+			require.Len(t, lines, 3, tt.args)
+			require.Regexp(t, regexp.MustCompile(tt.pattern), lines[0], tt.args)
+			require.Equal(t, "Entropy: "+tt.entropy+" bits", lines[1], tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
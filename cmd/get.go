@@ -23,6 +23,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/norbjd/pasuman/pkg/data"
 	"github.com/norbjd/pasuman/pkg/get"
 	"github.com/norbjd/pasuman/pkg/masterpassword"
 	"github.com/norbjd/pasuman/pkg/util"
@@ -35,6 +36,10 @@ var (
 	getCmdID                        bool
 	getCmdPassword                  bool
 	getCmdCopyIDPasswordToClipboard bool
+	getCmdField                     string
+	getCmdNote                      bool
+	getCmdAttachment                string
+	getCmdOut                       string
 	getCmdOutput                    = outputTable
 )
 
@@ -46,12 +51,27 @@ func getCmdInit() {
 	getCmd.Flags().BoolVar(&getCmdPassword, "password", false, "Get password")
 	getCmd.Flags().BoolVar(&getCmdCopyIDPasswordToClipboard, "copy-id-password-to-clipboard", false,
 		"Do not print id and password, just copy them to clipboard one after the other")
-	getCmd.MarkFlagsMutuallyExclusive("not-sensitive", "all", "id", "password", "copy-id-password-to-clipboard")
+	getCmd.Flags().StringVar(&getCmdField, "field", "",
+		"Get the value of a custom field (the master password is asked only if it is sensitive)")
+	getCmd.Flags().BoolVar(&getCmdNote, "note", false, "Get the secure note")
+	getCmd.Flags().StringVar(&getCmdAttachment, "attachment", "", "Get an attachment, written to the file given by --out")
+	getCmd.Flags().StringVar(&getCmdOut, "out", "",
+		"File to write the attachment to: it must not exist, and is created readable only by its owner")
+	getCmd.MarkFlagsMutuallyExclusive("not-sensitive", "all", "id", "password", "copy-id-password-to-clipboard",
+		"field", "note", "attachment")
 	getCmd.Flags().Var(&getCmdOutput, "output", fmt.Sprintf("Output format: %s", outputMessageHelp))
 
 	if err := getCmd.RegisterFlagCompletionFunc("output", outputCompletion); err != nil {
 		log.Fatal(err)
 	}
+
+	if err := getCmd.RegisterFlagCompletionFunc("field", fieldNameCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := getCmd.RegisterFlagCompletionFunc("attachment", attachmentNameCompletion); err != nil {
+		log.Fatal(err)
+	}
 }
 
 var getCmd = &cobra.Command{
@@ -71,11 +91,19 @@ var getCmd = &cobra.Command{
 
 		uniqueID := args[0]
 
+		if getCmdField != "" {
+			return getField(cmd, uniqueID, getCmdField)
+		}
+
+		if getCmdAttachment != "" {
+			return getAttachment(cmd, uniqueID, getCmdAttachment, getCmdOut)
+		}
+
 		if getCmdCopyIDPasswordToClipboard {
 			getCmdAll = true
 		}
 
-		if getCmdNotSensitive && !getCmdAll && !getCmdID && !getCmdPassword {
+		if getCmdNotSensitive && !getCmdAll && !getCmdID && !getCmdPassword && !getCmdNote {
 			entry, err := get.NotSensitive(uniqueID)
 			if err != nil {
 				return err
@@ -86,18 +114,41 @@ var getCmd = &cobra.Command{
 				headerColumns := []string{"Unique ID", "Description", "Tags", "Site"}
 				columns := []string{entry.UniqueID, entry.Description, strings.Join(entry.Tags, ","), entry.Site}
 
+				if entry.Policy != "" {
+					headerColumns = append(headerColumns, "Policy")
+					columns = append(columns, entry.Policy)
+				}
+
+				if len(entry.Fields) > 0 {
+					headerColumns = append(headerColumns, "Fields")
+					columns = append(columns, fieldsColumn(entry.Fields, false))
+				}
+
+				if len(entry.Attachments) > 0 {
+					headerColumns = append(headerColumns, "Attachments")
+					columns = append(columns, attachmentsColumn(entry.Attachments))
+				}
+
 				util.RenderTable(cmd.OutOrStdout(), headerColumns, [][]string{columns})
 			case outputJSON:
 				var jsonEntry struct {
-					UniqueID    string   `json:"unique_id"`
-					Description string   `json:"description"`
-					Tags        []string `json:"tags"`
-					Site        string   `json:"site"`
+					UniqueID    string           `json:"unique_id"`
+					Type        string           `json:"type,omitempty"`
+					Description string           `json:"description"`
+					Tags        []string         `json:"tags"`
+					Site        string           `json:"site"`
+					Policy      string           `json:"policy,omitempty"`
+					Fields      []jsonField      `json:"fields,omitempty"`
+					Attachments []jsonAttachment `json:"attachments,omitempty"`
 				}
 				jsonEntry.UniqueID = entry.UniqueID
+				jsonEntry.Type = entry.Type
 				jsonEntry.Description = entry.Description
 				jsonEntry.Tags = entry.Tags
 				jsonEntry.Site = entry.Site
+				jsonEntry.Policy = entry.Policy
+				jsonEntry.Fields = jsonFields(entry.Fields, false)
+				jsonEntry.Attachments = jsonAttachments(entry.Attachments)
 
 				result, err := json.MarshalIndent(jsonEntry, "", "  ")
 				if err != nil {
@@ -111,24 +162,11 @@ var getCmd = &cobra.Command{
 			return nil
 		}
 
-		cmdStderrPrintf(cmd, "Enter current master password: ")
-		masterPassword, err := util.ReadPassword()
-		if err != nil {
-			return err
-		}
-
-		correct, err := masterpassword.IsCorrect(masterPassword)
+		masterPassword, err := askMasterPassword(cmd)
 		if err != nil {
 			return err
 		}
 
-		if !correct {
-			cmdStderrPrintln(cmd, "✘")
-
-			return util.ErrMasterPasswordIncorrect
-		}
-		cmdStderrPrintln(cmd, "✔")
-
 		entry, err := get.Sensitive(masterPassword, uniqueID)
 		if err != nil {
 			return err
@@ -146,6 +184,12 @@ var getCmd = &cobra.Command{
 			return nil
 		}
 
+		if getCmdNote {
+			cmdPrintln(cmd, strings.TrimSuffix(entry.Note, "\n"))
+
+			return nil
+		}
+
 		var entryID string
 		var entryPassword string
 
@@ -166,22 +210,53 @@ var getCmd = &cobra.Command{
 					entry.ID, entry.Password,
 				}
 
+				// sensitive custom fields are not copied to clipboard, they are not shown either
+				if entry.Policy != "" {
+					headerColumns = append(headerColumns, "Policy")
+					columns = append(columns, entry.Policy)
+				}
+
+				if len(entry.Fields) > 0 {
+					headerColumns = append(headerColumns, "Fields")
+					columns = append(columns, fieldsColumn(entry.Fields, !getCmdCopyIDPasswordToClipboard))
+				}
+
+				if len(entry.Attachments) > 0 {
+					headerColumns = append(headerColumns, "Attachments")
+					columns = append(columns, attachmentsColumn(entry.Attachments))
+				}
+
 				util.RenderTable(cmd.OutOrStdout(), headerColumns, [][]string{columns})
 			case outputJSON:
 				var jsonEntry struct {
-					UniqueID    string   `json:"unique_id"`
-					Description string   `json:"description"`
-					Tags        []string `json:"tags"`
-					Site        string   `json:"site"`
-					ID          string   `json:"id"`
-					Password    string   `json:"password"`
+					UniqueID    string           `json:"unique_id"`
+					Type        string           `json:"type,omitempty"`
+					Description string           `json:"description"`
+					Tags        []string         `json:"tags"`
+					Site        string           `json:"site"`
+					Policy      string           `json:"policy,omitempty"`
+					ID          string           `json:"id"`
+					Password    string           `json:"password"`
+					Fields      []jsonField      `json:"fields,omitempty"`
+					OTP         string           `json:"otp,omitempty"`
+					Note        string           `json:"note,omitempty"`
+					Attachments []jsonAttachment `json:"attachments,omitempty"`
 				}
 				jsonEntry.UniqueID = entry.UniqueID
+				jsonEntry.Type = entry.Type
+				jsonEntry.Attachments = jsonAttachments(entry.Attachments)
 				jsonEntry.Description = entry.Description
 				jsonEntry.Tags = entry.Tags
 				jsonEntry.Site = entry.Site
+				jsonEntry.Policy = entry.Policy
 				jsonEntry.ID = entry.ID
 				jsonEntry.Password = entry.Password
+				jsonEntry.Fields = jsonFields(entry.Fields, !getCmdCopyIDPasswordToClipboard)
+
+				if !getCmdCopyIDPasswordToClipboard {
+					jsonEntry.OTP = entry.OTP
+					jsonEntry.Note = entry.Note
+				}
 
 				result, err := json.MarshalIndent(jsonEntry, "", "  ")
 				if err != nil {
@@ -194,8 +269,7 @@ var getCmd = &cobra.Command{
 		}
 
 		if getCmdCopyIDPasswordToClipboard {
-			cmdPrintln(cmd, "\n*pasuman uses ANSI OSC 52 to copy to clipboard, it may not work on some terminals\n"+
-				"(see https://github.com/ojroques/vim-oscyank/blob/main/README.md)\n")
+			cmdPrintln(cmd, "\n"+clipboardNote+"\n")
 		}
 
 		if getCmdCopyIDPasswordToClipboard {
@@ -223,3 +297,34 @@ var getCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// getField - print the value of custom field name of the entry uniqueID, asking the master password
+// only if the field is sensitive.
+func getField(cmd *cobra.Command, uniqueID, name string) error {
+	entry, err := get.NotSensitive(uniqueID)
+	if err != nil {
+		return err
+	}
+
+	field, ok := entry.Field(name)
+	if !ok {
+		return fmt.Errorf("%w: %s", data.ErrFieldNotFound, name)
+	}
+
+	if field.Sensitive {
+		masterPassword, err := askMasterPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		if entry, err = get.Sensitive(masterPassword, uniqueID); err != nil {
+			return err
+		}
+
+		field, _ = entry.Field(name)
+	}
+
+	cmdPrintln(cmd, field.Value)
+
+	return nil
+}
@@ -0,0 +1,126 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// historyTimeFormat - format of dates in the history table, in the local time zone.
+const historyTimeFormat = "2006-01-02 15:04:05"
+
+var historyCmd *cobra.Command
+
+var historyCmdOutput = outputTable
+
+func historyCmdInit() {
+	historyCmd = &cobra.Command{
+		Use:   "history <unique id>",
+		Short: "List previous versions of an entry",
+		Long: "List previous versions of an entry, kept each time it is updated: when each version has been " +
+			"replaced, and which fields have been changed then. Bring one back with " +
+			"`pasuman restore <unique id> --version <version>`.\n" +
+			"The number of versions kept for each entry (history_size, 10 by default) and their maximum age in " +
+			"days (history_max_age_days, unlimited by default) can be set in the config file.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: autocomplete,
+		RunE:              historyCmdRunE,
+	}
+
+	historyCmd.Flags().Var(&historyCmdOutput, "output", fmt.Sprintf("Output format: %s", outputMessageHelp))
+
+	if err := historyCmd.RegisterFlagCompletionFunc("output", outputCompletion); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// jsonVersion - version of an entry in JSON outputs. The current version has not been replaced.
+type jsonVersion struct {
+	Version    int      `json:"version"`
+	ReplacedAt string   `json:"replaced_at,omitempty"`
+	Changed    []string `json:"changed,omitempty"`
+	Current    bool     `json:"current,omitempty"`
+}
+
+func historyCmdRunE(cmd *cobra.Command, args []string) error {
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	entry, err := get.NotSensitive(args[0])
+	if err != nil {
+		return err
+	}
+
+	versions := make([]jsonVersion, 0, len(entry.History)+1)
+
+	for _, revision := range entry.History {
+		versions = append(versions, jsonVersion{
+			Version: revision.Version, ReplacedAt: revision.ReplacedAt, Changed: revision.Changed,
+		})
+	}
+
+	versions = append(versions, jsonVersion{Version: entry.Version(), Current: true})
+
+	switch historyCmdOutput {
+	case outputTable:
+		lines := make([][]string, len(versions))
+
+		for idx, version := range versions {
+			replacedAt := "(current)"
+
+			if !version.Current {
+				replacedAt = version.ReplacedAt
+
+				if t, err := time.Parse(time.RFC3339, version.ReplacedAt); err == nil {
+					replacedAt = t.Local().Format(historyTimeFormat)
+				}
+			}
+
+			lines[idx] = []string{strconv.Itoa(version.Version), replacedAt, strings.Join(version.Changed, ", ")}
+		}
+
+		util.RenderTable(cmd.OutOrStdout(), []string{"Version", "Replaced at", "Changed"}, lines)
+	case outputJSON:
+		result, err := json.MarshalIndent(versions, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		cmdPrintln(cmd, string(result))
+	default:
+		return errInvalidOutput
+	}
+
+	return nil
+}
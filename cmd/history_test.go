@@ -0,0 +1,141 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistory(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	for _, args := range [][]string{
+		{"add", "github", "--site=https://github.com", "--password=p4$$w0rd!"},
+		{"update", "github", "--password=n€wp4$$w0rd!"},
+		{"update", "github", "--site=https://github.com/login", "--password=t0ps3cr3t!"},
+	} {
+		_, err := pasumantest.ExecuteCommand(RootCmd, args...)
+		require.NoError(t, err, args)
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	entry, err := get.NotSensitive("github")
+	require.NoError(t, err)
+	require.Len(t, entry.History, 2)
+
+	// dates are shown in the local time zone
+	replacedAt := func(idx int) string {
+		date, err := time.Parse(time.RFC3339, entry.History[idx].ReplacedAt)
+		require.NoError(t, err)
+
+		return date.Local().Format(historyTimeFormat)
+	}
+
+	tests := []struct {
+		args    []string
+		output  string
+		wantErr error
+	}{
+		{
+			args: []string{"history", "github"},
+			output: "" +
+				"Version\tReplaced at\t\tChanged\t\t\n" +
+				"-------\t-----------\t\t-------\t\t\n" +
+				"1\t" + replacedAt(0) + "\tpassword\t\n" +
+				"2\t" + replacedAt(1) + "\tsite, password\t\n" +
+				"3\t(current)\t\t\t\t\n",
+		},
+		{
+			args: []string{"history", "github", "--output=json"},
+			output: "" +
+				"[\n" +
+				"  {\n" +
+				"    \"version\": 1,\n" +
+				"    \"replaced_at\": \"" + entry.History[0].ReplacedAt + "\",\n" +
+				"    \"changed\": [\n" +
+				"      \"password\"\n" +
+				"    ]\n" +
+				"  },\n" +
+				"  {\n" +
+				"    \"version\": 2,\n" +
+				"    \"replaced_at\": \"" + entry.History[1].ReplacedAt + "\",\n" +
+				"    \"changed\": [\n" +
+				"      \"site\",\n" +
+				"      \"password\"\n" +
+				"    ]\n" +
+				"  },\n" +
+				"  {\n" +
+				"    \"version\": 3,\n" +
+				"    \"current\": true\n" +
+				"  }\n" +
+				"]\n",
+		},
+		{
+			args:    []string{"history", "gitlab"},
+			wantErr: get.ErrNotFound,
+		},
+		{
+			args:    []string{"restore", "github", "--version=3"},
+			wantErr: data.ErrVersionNotFound,
+		},
+		{
+			args: []string{"restore", "github", "--version=1"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Restored version 1 of github (changed: site, password)\n",
+		},
+		{
+			args: []string{"get", "github", "--password"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"p4$$w0rd!\n",
+		},
+		{
+			args: []string{"restore", "github", "--version=1"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Version 1 of github is the same as the current version: nothing to restore\n",
+		},
+		{
+			args:   []string{"__complete", "restore", "github", "--version", ""},
+			output: "1\n2\n3\n:4\nCompletion ended with directive: ShellCompDirectiveNoFileComp\n",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
@@ -0,0 +1,195 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/csvmap"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/importer"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/spf13/cobra"
+)
+
+var importCmd *cobra.Command
+
+var (
+	importCmdFormat      string
+	importCmdOnCollision string
+	importCmdMap         []string
+)
+
+// importCmdPassDecrypter - decrypter of password store files (replaced in tests).
+var importCmdPassDecrypter importer.Decrypter = importer.GPG
+
+func importCmdInit() {
+	importCmd = &cobra.Command{
+		Use:   "import <file or directory>",
+		Short: "Import entries from another password manager",
+		Long: "Import entries from another password manager.\n" +
+			"Supported formats:\n" +
+			"  - " + string(importer.KeePassXML) + ": KeePass XML export (KDBX files are not supported, " +
+			"export the database as XML first); groups are imported as tags, Title as unique ID, " +
+			"URL as site, UserName as ID, Notes as description, and other strings are added to the " +
+			"description (protected ones are sensitive fields)\n" +
+			"  - " + string(importer.BitwardenJSON) + ": Bitwarden JSON export (unencrypted); folders and " +
+			"collections are imported as tags, other URIs and custom fields are added to the description\n" +
+			"  - " + string(importer.OnePassword1PUX) + ": 1Password 1PUX export; vaults and tags are imported as " +
+			"tags, other URLs, login fields and fields of sections are added to the description\n" +
+			"  - " + string(importer.CSV) + ": CSV file with a header; use --map to map its columns to fields " +
+			"(by default, columns named after fields: " + strings.Join(csvMapNames(csvmap.Default), ", ") +
+			"), other columns are ignored; entries without unique ID get the host of their site\n" +
+			"  - " + string(importer.Pass) + ": password store directory of pass (e.g. ~/.password-store), files " +
+			"are decrypted with gpg; directories are imported as tags, file names as unique IDs (paths if names are " +
+			"not unique), first lines as " +
+			"passwords, login: or user: lines as IDs, url: lines as sites, and other lines as description\n" +
+			"Sensitive fields that have no place in an entry (hidden fields, card codes, etc.) are imported as " +
+			"sensitive custom fields, and one-time password secrets as the one-time password key.\n" +
+			"All entries are imported at once: if anything fails, nothing is imported.",
+		Args: cobra.ExactArgs(1),
+		RunE: importCmdRunE,
+	}
+
+	importCmd.Flags().StringVar(&importCmdFormat, "format", "",
+		"Format of the file: "+strings.Join(importFormats(), ", "))
+	importCmd.Flags().StringVar(&importCmdOnCollision, "on-collision", string(importer.Skip),
+		"What to do when an entry with the same unique ID already exists: "+
+			strings.Join(importPolicies(), ", "))
+	importCmd.Flags().StringSliceVar(&importCmdMap, "map", nil, csvMapUsage)
+
+	if err := importCmd.MarkFlagRequired("format"); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := importCmd.RegisterFlagCompletionFunc("format", importFormatCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := importCmd.RegisterFlagCompletionFunc("on-collision", importPolicyCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := importCmd.RegisterFlagCompletionFunc("map", csvMapCompletion); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func importCmdRunE(cmd *cobra.Command, args []string) error {
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	entries, err := importCmdParse(importer.Format(importCmdFormat), args[0])
+	if err != nil {
+		return err
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	summary, err := importer.Import(masterPassword, entries, importer.Policy(importCmdOnCollision))
+	if err != nil {
+		return err
+	}
+
+	printImportSummary(cmd, summary)
+
+	return nil
+}
+
+// printImportSummary - print what happened to imported entries.
+func printImportSummary(cmd *cobra.Command, summary importer.Summary) {
+	for _, result := range summary {
+		switch {
+		case result.Skipped:
+			cmdPrintf(cmd, "Skipped (already exists): %s\n", result.UniqueID)
+		case result.Overwritten:
+			cmdPrintf(cmd, "Overwritten: %s\n", result.UniqueID)
+		case result.RenamedFrom != "":
+			cmdPrintf(cmd, "Renamed: %s → %s\n", result.RenamedFrom, result.UniqueID)
+		}
+	}
+
+	imported, renamed, overwritten, skipped := summary.Count()
+
+	cmdPrintf(cmd, "Imported %d entries (%d renamed, %d overwritten), skipped %d\n",
+		imported, renamed, overwritten, skipped)
+}
+
+func importCmdParse(format importer.Format, path string) ([]data.Entry, error) {
+	if len(importCmdMap) > 0 && format != importer.CSV {
+		return nil, errMapOnlyForCSV
+	}
+
+	if format == importer.Pass {
+		return importer.ParsePass(path, importCmdPassDecrypter)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if format == importer.CSV {
+		mapping, err := csvMapping(importCmdMap)
+		if err != nil {
+			return nil, err
+		}
+
+		return importer.ParseCSV(f, mapping)
+	}
+
+	return importer.Parse(format, f)
+}
+
+func importFormats() []string {
+	names := make([]string, len(importer.Formats))
+	for idx, format := range importer.Formats {
+		names[idx] = string(format)
+	}
+
+	return names
+}
+
+func importPolicies() []string {
+	names := make([]string, len(importer.Policies))
+	for idx, policy := range importer.Policies {
+		names[idx] = string(policy)
+	}
+
+	return names
+}
+
+func importFormatCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return importFormats(), cobra.ShellCompDirectiveNoFileComp
+}
+
+func importPolicyCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return importPolicies(), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,148 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/importer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImport(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	testdata := filepath.Join("..", "pkg", "importer", "testdata")
+	keePassFile := filepath.Join(testdata, "keepass.xml")
+
+	// files of the test password store are not encrypted
+	importCmdPassDecrypter = os.ReadFile
+	defer func() { importCmdPassDecrypter = importer.GPG }()
+
+	tests := []struct {
+		args    []string
+		output  string
+		wantErr error
+	}{
+		{
+			args:    []string{"import", "--format=unknown", keePassFile},
+			wantErr: importer.ErrInvalidFormat,
+		},
+		{
+			args:    []string{"import", "--format=keepass-xml", "--on-collision=unknown", keePassFile},
+			wantErr: importer.ErrInvalidPolicy,
+		},
+		{
+			args: []string{"import", "--format=keepass-xml", keePassFile},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Skipped (already exists): forum\n" +
+				"Imported 2 entries (0 renamed, 0 overwritten), skipped 1\n",
+		},
+		{
+			args: []string{"import", "--format=keepass-xml", "--on-collision=rename", keePassFile},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Renamed: mail → mail-2\n" +
+				"Renamed: forum → forum-2\n" +
+				"Renamed: forum → forum-3\n" +
+				"Imported 3 entries (3 renamed, 0 overwritten), skipped 0\n",
+		},
+		{
+			args: []string{"import", "--format=bitwarden-json", filepath.Join(testdata, "bitwarden.json")},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Skipped (already exists): mail\n" +
+				"Imported 4 entries (0 renamed, 0 overwritten), skipped 1\n",
+		},
+		{
+			args: []string{"import", "--format=1password-1pux", filepath.Join(testdata, "1password.1pux")},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Skipped (already exists): mail\n" +
+				"Skipped (already exists): card\n" +
+				"Skipped (already exists): wifi\n" +
+				"Imported 1 entries (0 renamed, 0 overwritten), skipped 3\n",
+		},
+		{
+			args: []string{"import", "--format=pass", "--on-collision=rename", filepath.Join(testdata, "password-store")},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Renamed: shop → shop-2\n" +
+				"Renamed: forum → forum-4\n" +
+				"Renamed: mail → mail-3\n" +
+				"Imported 3 entries (3 renamed, 0 overwritten), skipped 0\n",
+		},
+		{
+			args: []string{"search", "forum", "--output=json"},
+			output: `[
+  {
+    "unique_id": "forum",
+    "description": "",
+    "tags": [
+      "Internet"
+    ],
+    "site": "https://forum.pasuman"
+  },
+  {
+    "unique_id": "forum-2",
+    "description": "",
+    "tags": [
+      "Internet"
+    ],
+    "site": "https://forum.pasuman"
+  },
+  {
+    "unique_id": "forum-3",
+    "description": "",
+    "tags": [
+      "Internet",
+      "Shopping"
+    ],
+    "site": ""
+  },
+  {
+    "unique_id": "forum-4",
+    "description": "",
+    "tags": [
+      "Internet"
+    ],
+    "site": "https://forum.pasuman"
+  }
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr)
+		} else {
+			require.NoError(t, err)
+			require.Equal(t, tt.output, out)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
@@ -0,0 +1,55 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var lockCmdAll bool
+
+func lockCmdInit() {
+	lockCmd.Flags().BoolVar(&lockCmdAll, "all", false, "Lock all profiles")
+}
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Make the agent forget keys of the profile",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if lockCmdAll {
+			if err := agent.Lock(""); err != nil {
+				return err
+			}
+
+			cmdPrintln(cmd, "All profiles are locked")
+
+			return nil
+		}
+
+		if err := agent.Lock(config.PasumanDataFile); err != nil {
+			return err
+		}
+
+		cmdPrintln(cmd, "Profile is locked")
+
+		return nil
+	},
+}
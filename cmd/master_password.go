@@ -20,6 +20,8 @@ package cmd
 import (
 	"errors"
 
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/masterpassword"
 	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/spf13/cobra"
@@ -42,24 +44,10 @@ var masterPasswordCmd = &cobra.Command{
 		var currentMasterPassword string
 
 		if masterPasswordSet {
-			cmdStderrPrintf(cmd, "Enter current master password: ")
-			currentMasterPassword, err = util.ReadPassword()
+			currentMasterPassword, err = askMasterPassword(cmd)
 			if err != nil {
 				return err
 			}
-
-			correct, err := masterpassword.IsCorrect(currentMasterPassword)
-			if err != nil {
-				return err
-			}
-
-			if !correct {
-				cmdStderrPrintln(cmd, "✘")
-
-				return util.ErrMasterPasswordIncorrect
-			}
-
-			cmdStderrPrintln(cmd, "✔")
 		}
 
 		cmdPrintf(cmd, "Enter new master password: ")
@@ -105,6 +93,9 @@ var masterPasswordCmd = &cobra.Command{
 			return err
 		}
 
+		// keys kept by the agent were unlocked with the previous master password
+		_ = agent.Lock(config.PasumanDataFile)
+
 		cmdPrintln(cmd, "Master password has been set!")
 
 		return nil
@@ -0,0 +1,81 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/norbjd/pasuman/pkg/migrate"
+	"github.com/spf13/cobra"
+)
+
+var migrateCmd *cobra.Command
+
+var migrateCmdDryRun bool
+
+func migrateCmdInit() {
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Upgrade profile file to the latest format",
+		Long: "Upgrade profile file to the latest format.\n" +
+			"Profile files are also upgraded automatically on any modification; " +
+			"the previous version of the file is kept as backup.",
+		Args: cobra.NoArgs,
+		RunE: migrateCmdRunE,
+	}
+
+	migrateCmd.Flags().BoolVar(&migrateCmdDryRun, "dry-run", false, "Only show migrations that would be applied")
+}
+
+func migrateCmdRunE(cmd *cobra.Command, args []string) error {
+	result, err := migrate.Migrate("", true)
+	if err != nil {
+		return err
+	}
+
+	// master password is only needed to write migrations
+	if !migrateCmdDryRun && len(result.Migrations) != 0 {
+		masterPassword, err := askMasterPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		result, err = migrate.Migrate(masterPassword, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	cmdPrintf(cmd, "Profile file is at version %d, latest version is %d\n", result.FromVersion, result.ToVersion)
+
+	if len(result.Migrations) == 0 {
+		cmdPrintln(cmd, "Nothing to migrate")
+
+		return nil
+	}
+
+	for _, migration := range result.Migrations {
+		cmdPrintf(cmd, "  - version %d → %d: %s\n", migration.From, migration.From+1, migration.Description)
+	}
+
+	if migrateCmdDryRun {
+		cmdPrintln(cmd, "Dry run: nothing has been written")
+	} else {
+		cmdPrintf(cmd, "Profile file migrated to version %d\n", result.ToVersion)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	tests := []struct {
+		args   []string
+		output string
+	}{
+		{
+			args: []string{"migrate", "--dry-run"},
+			output: "" +
+				"Profile file is at version 0, latest version is 11\n" +
+				"  - version 0 → 1: add format version to the profile file\n" +
+				"  - version 1 → 2: record encryption algorithm and key derivation parameters in encrypted values\n" +
+				"  - version 2 → 3: encrypt entries with a data key, wrapped by the master password\n" +
+				"  - version 3 → 4: allow to seal entries (encrypt them as a whole)\n" +
+				"  - version 4 → 5: bind encrypted values to their entry, and authenticate the profile file\n" +
+				"  - version 5 → 6: add custom fields to entries\n" +
+				"  - version 6 → 7: add one-time password keys to entries\n" +
+				"  - version 7 → 8: add secure notes and attachments to entries\n" +
+				"  - version 8 → 9: keep previous versions of entries\n" +
+				"  - version 9 → 10: keep removed entries in a trash\n" +
+				"  - version 10 → 11: bind entries to password policies\n" +
+				"Dry run: nothing has been written\n",
+		},
+		{
+			args: []string{"migrate"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Profile file is at version 0, latest version is 11\n" +
+				"  - version 0 → 1: add format version to the profile file\n" +
+				"  - version 1 → 2: record encryption algorithm and key derivation parameters in encrypted values\n" +
+				"  - version 2 → 3: encrypt entries with a data key, wrapped by the master password\n" +
+				"  - version 3 → 4: allow to seal entries (encrypt them as a whole)\n" +
+				"  - version 4 → 5: bind encrypted values to their entry, and authenticate the profile file\n" +
+				"  - version 5 → 6: add custom fields to entries\n" +
+				"  - version 6 → 7: add one-time password keys to entries\n" +
+				"  - version 7 → 8: add secure notes and attachments to entries\n" +
+				"  - version 8 → 9: keep previous versions of entries\n" +
+				"  - version 9 → 10: keep removed entries in a trash\n" +
+				"  - version 10 → 11: bind entries to password policies\n" +
+				"Profile file migrated to version 11\n",
+		},
+		{
+			args: []string{"migrate"},
+			output: "" +
+				"Profile file is at version 11, latest version is 11\n" +
+				"Nothing to migrate\n",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		require.NoError(t, err)
+		require.Equal(t, tt.output, out)
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
@@ -0,0 +1,133 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/norbjd/pasuman/pkg/otp"
+	"github.com/spf13/cobra"
+)
+
+var otpCmd *cobra.Command
+
+var (
+	otpCmdCopy bool
+
+	// otpCmdNow - current time, replaced in tests
+	otpCmdNow = time.Now
+	// otpCmdQRDecoder - decoder of QR codes given by `--otp-qr` (of `add` and `update`), replaced in tests
+	otpCmdQRDecoder otp.QRDecoder = otp.ZBarImg
+)
+
+var errNoOTP = errors.New("no one-time password key for this entry: add one with `pasuman update <unique id> --otp`")
+
+// otpUsage, otpQRUsage - usage of flags giving the key of one-time passwords of an entry.
+const (
+	otpUsage   = "Key of one-time passwords (TOTP), as an otpauth:// URI or a base32 secret"
+	otpQRUsage = "Image file of a QR code of the key of one-time passwords (TOTP), decoded with zbarimg"
+)
+
+func otpCmdInit() {
+	otpCmd = &cobra.Command{
+		Use:   "otp <unique id>",
+		Short: "Get the current one-time password of an entry",
+		Long: "Get the current time-based one-time password (TOTP, RFC 6238) of an entry, and how long it is " +
+			"still valid. The key of one-time passwords is set with `--otp` or `--otp-qr` of `add` and `update`.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: autocomplete,
+		RunE:              otpCmdRunE,
+	}
+
+	otpCmd.Flags().BoolVar(&otpCmdCopy, "copy", false,
+		"Do not print the one-time password, copy it to clipboard until enter is pressed or it expires")
+}
+
+func otpCmdRunE(cmd *cobra.Command, args []string) error {
+	if otpCmdCopy && !copyStdoutIsTerminal() {
+		return errCopyToTerminal
+	}
+
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	entry, err := get.Sensitive(masterPassword, args[0])
+	if err != nil {
+		return err
+	}
+
+	if entry.OTP == "" {
+		return errNoOTP
+	}
+
+	key, err := otp.Parse(entry.OTP)
+	if err != nil {
+		return err
+	}
+
+	now := otpCmdNow()
+
+	code, err := key.Code(now)
+	if err != nil {
+		return err
+	}
+
+	remaining := key.Remaining(now)
+
+	if otpCmdCopy {
+		// the one-time password is of no use once expired
+		return copyUntilEnter(cmd, "One-time password", code, remaining)
+	}
+
+	cmdPrintf(cmd, "%s (valid for %s)\n", code, remaining)
+
+	return nil
+}
+
+// readOTPKey - key of one-time passwords given by `--otp`, or read from the QR code image given by `--otp-qr`,
+// checked so an invalid key is never stored.
+func readOTPKey(key, qrFile string) (string, error) {
+	if qrFile != "" {
+		return otp.ReadQR(qrFile, otpCmdQRDecoder)
+	}
+
+	if key == "" {
+		return "", nil
+	}
+
+	if _, err := otp.Parse(key); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(key), nil
+}
@@ -0,0 +1,135 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/otp"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTP(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	// test vectors of RFC 6238, at 59 seconds
+	otpCmdNow = func() time.Time { return time.Unix(59, 0) }
+	defer func() { otpCmdNow = time.Now }()
+
+	otpCmdQRDecoder = func(file string) (string, error) {
+		require.Equal(t, "qr.png", file)
+
+		return "otpauth://totp/Example:alice?algorithm=SHA256&digits=8&secret=" +
+			"GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA\n", nil
+	}
+	defer func() { otpCmdQRDecoder = otp.ZBarImg }()
+
+	tests := []struct {
+		args     []string
+		output   string
+		noOutTTY bool
+		wantErr  error
+	}{
+		{
+			args:    []string{"add", "github", "--password=p4$$w0rd!", "--otp=not base32!"},
+			wantErr: otp.ErrInvalidKey,
+		},
+		{
+			args: []string{
+				"add", "github", "--password=p4$$w0rd!",
+				"--otp=otpauth://totp/GitHub:alice?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&digits=8&issuer=GitHub",
+			},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"New entry: github\n",
+		},
+		{
+			args: []string{"otp", "github"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"94287082 (valid for 1s)\n",
+		},
+		{
+			args: []string{"add", "example", "--password=t0ps3cr3t!"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"New entry: example\n",
+		},
+		{
+			args:    []string{"otp", "example"},
+			wantErr: errNoOTP,
+		},
+		{
+			args: []string{"update", "example", "--otp-qr=qr.png"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Updated: example\n",
+		},
+		{
+			args: []string{"otp", "example", "--copy"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				clipboardNote + "\n\n" +
+				"One-time password copied to clipboard*, press enter to clear clipboard (cleared after 1s)",
+		},
+		{
+			args:     []string{"otp", "example", "--copy"},
+			noOutTTY: true,
+			wantErr:  errCopyToTerminal,
+		},
+		{
+			// the base32 secret alone uses default parameters: SHA1, 6 digits, 30 seconds
+			args: []string{"update", "example", "--otp=gezd gnbv gy3t qojq gezd gnbv gy3t qojq"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Updated: example\n",
+		},
+		{
+			args: []string{"otp", "example"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"287082 (valid for 1s)\n",
+		},
+	}
+
+	util.SetStdin(strings.NewReader("\n"))
+	defer util.SetStdin(os.Stdin)
+
+	defer func() { copyStdoutIsTerminal = func() bool { return util.IsTerminal(os.Stdout) } }()
+
+	for _, tt := range tests {
+		copyStdoutIsTerminal = func() bool { return !tt.noOutTTY }
+
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
@@ -22,13 +22,12 @@ import (
 
 	"github.com/norbjd/pasuman/pkg/masterpassword"
 	"github.com/norbjd/pasuman/pkg/remove"
-	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/spf13/cobra"
 )
 
 var removeCmd = &cobra.Command{
 	Use:               "remove <unique id>",
-	Short:             "Remove an entry",
+	Short:             "Remove an entry (move it to the trash)",
 	Args:              cobra.ExactArgs(1),
 	ValidArgsFunction: autocomplete,
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -43,29 +42,16 @@ var removeCmd = &cobra.Command{
 
 		uniqueID := strings.TrimSpace(args[0])
 
-		cmdStderrPrintf(cmd, "Enter current master password: ")
-		masterPassword, err := util.ReadPassword()
+		masterPassword, err := askMasterPassword(cmd)
 		if err != nil {
 			return err
 		}
 
-		correct, err := masterpassword.IsCorrect(masterPassword)
-		if err != nil {
-			return err
-		}
-
-		if !correct {
-			cmdStderrPrintln(cmd, "✘")
-
-			return util.ErrMasterPasswordIncorrect
-		}
-		cmdStderrPrintln(cmd, "✔")
-
-		if err := remove.Remove(uniqueID); err != nil {
+		if err := remove.Remove(masterPassword, uniqueID); err != nil {
 			return err
 		}
 
-		cmdPrintf(cmd, "Removed entry: %s\n", uniqueID)
+		cmdPrintf(cmd, "Removed entry: %s (restore it with `pasuman trash restore %s`)\n", uniqueID, uniqueID)
 
 		return nil
 	},
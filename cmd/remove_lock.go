@@ -19,28 +19,39 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"os"
 
-	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/lock"
 	"github.com/spf13/cobra"
 )
 
-var errNoLock = errors.New("no lock")
+var (
+	errNoLock    = errors.New("no lock")
+	errLockInUse = errors.New("lock is in use")
+)
 
 var removeLockCmd = &cobra.Command{
 	Use:   "remove-lock",
 	Short: "Remove lock",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		lockFile := config.PasumanDataFile + ".lock"
-
-		err := os.Remove(lockFile)
+		owner, err := lock.Remove(lockFile())
 		if os.IsNotExist(err) {
 			return errNoLock
 		}
+		if errors.Is(err, lock.ErrLocked) {
+			return fmt.Errorf("%w: %v: stop this process first", errLockInUse, err)
+		}
 		if err != nil {
 			return err
 		}
 
+		if owner != nil {
+			cmdPrintf(cmd, "Lock was held by %s\n", owner)
+		} else {
+			cmdPrintln(cmd, "Lock owner is unknown")
+		}
+
 		cmdPrintln(cmd, "Lock has been removed!")
 
 		return nil
@@ -59,7 +59,7 @@ func TestRemove(t *testing.T) {
 			},
 			output: "" +
 				"Enter current master password: ✔\n" +
-				"Removed entry: id1\n",
+				"Removed entry: id1 (restore it with `pasuman trash restore id1`)\n",
 		},
 		{
 			args:    []string{"remove", "id1"},
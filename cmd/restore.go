@@ -0,0 +1,274 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/archive"
+	"github.com/norbjd/pasuman/pkg/convert"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/importer"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/norbjd/pasuman/pkg/update"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var restoreCmd *cobra.Command
+
+var (
+	restoreCmdFrom        string
+	restoreCmdMerge       bool
+	restoreCmdOnCollision string
+	restoreCmdVersion     int
+)
+
+var (
+	errProfileExists            = errors.New("profile already exists")
+	errArchiveProfileRequired   = errors.New("archive contains several profiles")
+	errArchiveProfileNotFound   = errors.New("profile not found in archive")
+	errOnCollisionRequiresMerge = errors.New("--on-collision can only be used with --merge")
+)
+
+func restoreCmdInit() {
+	restoreCmd = &cobra.Command{
+		Use:   "restore <archive> | <unique id> --version <version>",
+		Short: "Restore a profile from an archive, or a previous version of an entry",
+		Long: "Restore a profile from an archive exported with `pasuman export --encrypted`, after checking " +
+			"that the archive has not been modified.\n" +
+			"The profile is restored into the profile given by --profile: if it does not exist yet, it is " +
+			"created with a new master password; otherwise, use --merge to add entries of the archive to it.\n" +
+			"With --version, restore a previous version of an entry instead (see `pasuman history`): the " +
+			"current version is kept in the history, so the restore can be undone too.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: restoreCmdCompletion,
+		RunE:              restoreCmdRunE,
+	}
+
+	restoreCmd.Flags().StringVar(&restoreCmdFrom, "from", "",
+		"Profile of the archive to restore, if it contains several profiles")
+	restoreCmd.Flags().BoolVar(&restoreCmdMerge, "merge", false,
+		"Merge entries of the archive into the profile, if it already exists")
+	restoreCmd.Flags().StringVar(&restoreCmdOnCollision, "on-collision", string(importer.Skip),
+		"With --merge, what to do when an entry with the same unique ID already exists: "+
+			strings.Join(importPolicies(), ", "))
+
+	restoreCmd.Flags().IntVar(&restoreCmdVersion, "version", 0,
+		"Restore this previous version of the entry given as argument, instead of an archive")
+	restoreCmd.MarkFlagsMutuallyExclusive("version", "from")
+	restoreCmd.MarkFlagsMutuallyExclusive("version", "merge")
+	restoreCmd.MarkFlagsMutuallyExclusive("version", "on-collision")
+
+	if err := restoreCmd.RegisterFlagCompletionFunc("on-collision", importPolicyCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := restoreCmd.RegisterFlagCompletionFunc("version", versionCompletion); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// restoreCmdCompletion - complete unique IDs of entries with --version, archives (files) otherwise.
+func restoreCmdCompletion(cmd *cobra.Command, args []string,
+	toComplete string,
+) ([]string, cobra.ShellCompDirective) {
+	if cmd.Flags().Changed("version") {
+		return autocomplete(cmd, args, toComplete)
+	}
+
+	return nil, cobra.ShellCompDirectiveDefault
+}
+
+// versionCompletion - complete previous versions of the entry given as argument.
+func versionCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	entry, err := get.NotSensitive(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	versions := make([]string, len(entry.History))
+	for idx, revision := range entry.History {
+		versions[idx] = strconv.Itoa(revision.Version)
+	}
+
+	return versions, cobra.ShellCompDirectiveNoFileComp
+}
+
+// restoreVersion - restore a previous version of the entry uniqueID.
+func restoreVersion(cmd *cobra.Command, uniqueID string, version int) error {
+	if _, err := get.NotSensitive(uniqueID); err != nil {
+		return err
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	changed, err := update.Restore(masterPassword, uniqueID, version)
+	if err != nil {
+		return err
+	}
+
+	if len(changed) == 0 {
+		cmdPrintf(cmd, "Version %d of %s is the same as the current version: nothing to restore\n", version, uniqueID)
+
+		return nil
+	}
+
+	cmdPrintf(cmd, "Restored version %d of %s (changed: %s)\n", version, uniqueID, strings.Join(changed, ", "))
+
+	return nil
+}
+
+func restoreCmdRunE(cmd *cobra.Command, args []string) error {
+	if cmd.Flags().Changed("version") {
+		masterPasswordSet, err := masterpassword.IsSet()
+		if err != nil {
+			return err
+		}
+
+		if !masterPasswordSet {
+			return errNoMasterPasswordSet
+		}
+
+		return restoreVersion(cmd, strings.TrimSpace(args[0]), restoreCmdVersion)
+	}
+
+	if cmd.Flags().Changed("on-collision") && !restoreCmdMerge {
+		return errOnCollisionRequiresMerge
+	}
+
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if masterPasswordSet && !restoreCmdMerge {
+		return fmt.Errorf("%w: use --merge to merge entries of the archive into profile %s, "+
+			"or --profile to restore into a new profile", errProfileExists, rootCmdProfile)
+	}
+
+	profile, err := restoreCmdReadArchive(cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	var masterPassword string
+
+	if masterPasswordSet {
+		if masterPassword, err = askMasterPassword(cmd); err != nil {
+			return err
+		}
+	} else {
+		if masterPassword, err = askNewPassword(cmd, "master password", errNewPasswordsMismatch); err != nil {
+			return err
+		}
+
+		if err := masterpassword.SetMasterPassword("", masterPassword); err != nil {
+			return err
+		}
+	}
+
+	summary, err := importer.Import(masterPassword, profile.Entries, importer.Policy(restoreCmdOnCollision))
+	if err != nil {
+		return err
+	}
+
+	printImportSummary(cmd, summary)
+
+	if err := importer.ImportTrash(masterPassword, profile.Trash); err != nil {
+		return err
+	}
+
+	if !masterPasswordSet && profile.Sealed {
+		if err := convert.Convert(masterPassword, true); err != nil {
+			return err
+		}
+	}
+
+	if masterPasswordSet {
+		cmdPrintf(cmd, "Profile %s of the archive has been merged into profile %s\n", profile.Name, rootCmdProfile)
+	} else {
+		cmdPrintf(cmd, "Profile %s of the archive has been restored into new profile %s\n",
+			profile.Name, rootCmdProfile)
+	}
+
+	return nil
+}
+
+// restoreCmdReadArchive - read the profile to restore from the archive, asking its passphrase.
+func restoreCmdReadArchive(cmd *cobra.Command, file string) (archive.Profile, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return archive.Profile{}, err
+	}
+	defer f.Close()
+
+	a, err := archive.Parse(f)
+	if err != nil {
+		return archive.Profile{}, err
+	}
+
+	cmdStderrPrintf(cmd, "Enter archive passphrase: ")
+
+	passphrase, err := util.ReadPassword()
+	if err != nil {
+		return archive.Profile{}, err
+	}
+
+	profiles, err := a.Profiles(passphrase)
+	if err != nil {
+		cmdStderrPrintln(cmd, "✘")
+
+		return archive.Profile{}, err
+	}
+
+	cmdStderrPrintln(cmd, "✔")
+
+	if restoreCmdFrom != "" {
+		profile, found := archive.Find(profiles, restoreCmdFrom)
+		if !found {
+			return archive.Profile{}, fmt.Errorf("%w: %s", errArchiveProfileNotFound, restoreCmdFrom)
+		}
+
+		return profile, nil
+	}
+
+	if len(profiles) != 1 {
+		names := make([]string, len(profiles))
+		for idx, profile := range profiles {
+			names[idx] = profile.Name
+		}
+
+		return archive.Profile{}, fmt.Errorf("%w (%s): choose one with --from", errArchiveProfileRequired,
+			strings.Join(names, ", "))
+	}
+
+	return profiles[0], nil
+}
@@ -0,0 +1,215 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/archive"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportEncryptedRestore(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	// profiles are unlocked by most commands
+	defer pasumantest.FastKDF()()
+
+	archiveFile := filepath.Join(tempDir, "archive.json")
+	invalidArchiveFile := filepath.Join(tempDir, "invalid.json")
+
+	require.NoError(t, os.WriteFile(invalidArchiveFile, []byte(`{"entries": []}`), 0o600))
+
+	tests := []struct {
+		args    []string
+		output  string
+		check   func()
+		wantErr error
+	}{
+		{
+			args:   []string{"add", "id1", "--id=myId", "--password=p4$$w0rd!"},
+			output: "Enter current master password: ✔\nNew entry: id1\n",
+		},
+		{
+			args: []string{"--profile=work", "master-password"},
+			output: "" +
+				"Enter new master password: ✔\n" +
+				"Enter new master password again: ✔\n" +
+				"Master password has been set!\n",
+		},
+		{
+			args:   []string{"--profile=work", "add", "id2", "--id=otherId", "--password=t0ps3cr3t!"},
+			output: "Enter current master password: ✔\nNew entry: id2\n",
+		},
+		{
+			args:   []string{"--profile=work", "update", "id2", "--password=n3wt0ps3cr3t!"},
+			output: "Enter current master password: ✔\nUpdated: id2\n",
+		},
+		{
+			args:   []string{"--profile=work", "add", "id3", "--id=thirdId", "--password=r3m0v3d!"},
+			output: "Enter current master password: ✔\nNew entry: id3\n",
+		},
+		{
+			args: []string{"--profile=work", "remove", "id3"},
+			output: "Enter current master password: ✔\n" +
+				"Removed entry: id3 (restore it with `pasuman trash restore id3`)\n",
+		},
+		{
+			args:   []string{"--profile=work", "convert", "sealed"},
+			output: "Enter current master password: ✔\nProfile is now sealed\n",
+		},
+		{
+			args:    []string{"export", "--all-profiles", "--format=csv"},
+			wantErr: errAllProfilesOnlyEncrypted,
+		},
+		{
+			args: []string{"export", "--encrypted", "--all-profiles", archiveFile},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Enter current master password of profile work: ✔\n" +
+				"Enter new export passphrase: ✔\n" +
+				"Enter new export passphrase again: ✔\n" +
+				"Exported 2 profiles (2 entries) to " + archiveFile + "\n",
+		},
+		{
+			args:    []string{"restore", archiveFile},
+			wantErr: errProfileExists,
+		},
+		{
+			args:    []string{"--profile=new", "restore", invalidArchiveFile},
+			wantErr: archive.ErrInvalidArchive,
+		},
+		{
+			args:    []string{"--profile=new", "restore", archiveFile},
+			wantErr: errArchiveProfileRequired,
+		},
+		{
+			args:    []string{"--profile=new", "restore", "--from=unknown", archiveFile},
+			wantErr: errArchiveProfileNotFound,
+		},
+		{
+			args: []string{"--profile=new", "restore", "--from=work", archiveFile},
+			output: "" +
+				"Enter archive passphrase: ✔\n" +
+				"Enter new master password: ✔\n" +
+				"Enter new master password again: ✔\n" +
+				"Imported 1 entries (0 renamed, 0 overwritten), skipped 0\n" +
+				"Profile work of the archive has been restored into new profile new\n",
+			check: func() {
+				t.Helper()
+
+				// entries are imported before the profile is sealed: they are not left in plaintext in its backup
+				backup, err := os.ReadFile(config.GetDataFile(config.GetConfig(), "new") + data.BackupSuffix)
+				require.NoError(t, err)
+				require.NotContains(t, string(backup), `"unique_id"`)
+			},
+		},
+		{
+			// previous versions and removed entries are restored too
+			args:   []string{"--profile=new", "restore", "id2", "--version=1"},
+			output: "Enter current master password: ✔\nRestored version 1 of id2 (changed: password)\n",
+		},
+		{
+			args:   []string{"--profile=new", "get", "id2", "--password"},
+			output: "Enter current master password: ✔\nt0ps3cr3t!\n",
+		},
+		{
+			args:   []string{"--profile=new", "trash", "restore", "id3"},
+			output: "Enter current master password: ✔\nRestored entry: id3\n",
+		},
+		{
+			args:   []string{"--profile=new", "get", "id3", "--password"},
+			output: "Enter current master password: ✔\nr3m0v3d!\n",
+		},
+		{
+			args:    []string{"--profile=new", "restore", "--from=default", "--on-collision=rename", archiveFile},
+			wantErr: errOnCollisionRequiresMerge,
+		},
+		{
+			args: []string{"--profile=new", "restore", "--from=default", "--merge", archiveFile},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Enter archive passphrase: ✔\n" +
+				"Imported 1 entries (0 renamed, 0 overwritten), skipped 0\n" +
+				"Profile default of the archive has been merged into profile new\n",
+		},
+		{
+			args: []string{"--profile=new", "restore", "--from=work", "--merge", "--on-collision=rename", archiveFile},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Enter archive passphrase: ✔\n" +
+				"Renamed: id2 → id2-2\n" +
+				"Imported 1 entries (1 renamed, 0 overwritten), skipped 0\n" +
+				"Profile work of the archive has been merged into profile new\n",
+		},
+		{
+			// the restored profile is sealed, as in the archive
+			args: []string{"--profile=new", "list", "--output=json"},
+			output: "Enter current master password: ✔\n" + `[
+  {
+    "unique_id": "id1",
+    "description": "",
+    "tags": null,
+    "site": ""
+  },
+  {
+    "unique_id": "id2",
+    "description": "",
+    "tags": null,
+    "site": ""
+  },
+  {
+    "unique_id": "id2-2",
+    "description": "",
+    "tags": null,
+    "site": ""
+  },
+  {
+    "unique_id": "id3",
+    "description": "",
+    "tags": null,
+    "site": ""
+  }
+]
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+
+			if tt.check != nil {
+				tt.check()
+			}
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
@@ -21,16 +21,34 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"os"
+	"time"
 
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/lock"
 	"github.com/spf13/cobra"
 )
 
 var ErrFileLocked = errors.New("file is locked")
 
-var rootCmdProfile string
+var (
+	rootCmdProfile         string
+	rootCmdWait            time.Duration
+	rootCmdPasswordFD      int
+	rootCmdPasswordFile    string
+	rootCmdPasswordCommand string
+)
+
+// rootCmdLock - lock acquired by the `PersistentPreRunE` of the root command.
+var rootCmdLock *lock.Lock
+
+// rootCmdMasterPassword - master password, once asked by `askMasterPassword`,
+// or the token standing for it if the profile is unlocked in the agent (see `data.RememberKeys`).
+var rootCmdMasterPassword string
+
+// rootCmdSourcePassword - password read from the source given by `--password-*` flags, as it can be read only once.
+var rootCmdSourcePassword string
 
 // nolint: gochecknoinits
 func init() {
@@ -44,23 +62,53 @@ func init() {
 	})
 
 	RootCmd.PersistentFlags().StringVar(&rootCmdProfile, "profile", constants.RootCmdDefaultProfile, "Profile")
+	RootCmd.PersistentFlags().DurationVar(&rootCmdWait, "wait", 0,
+		"Wait up to this duration (e.g. 30s) for another pasuman process to finish, instead of failing right away")
+	RootCmd.PersistentFlags().IntVar(&rootCmdPasswordFD, "password-fd", -1,
+		"Read master password from file descriptor `fd` (e.g. 3, with 3<file in the shell), instead of prompting for it")
+	RootCmd.PersistentFlags().StringVar(&rootCmdPasswordFile, "password-file", "",
+		"Read master password from this file, only readable by its owner, instead of prompting for it")
+	RootCmd.PersistentFlags().StringVar(&rootCmdPasswordCommand, "password-command", "",
+		"Read master password from the output of this shell command (e.g. a keyring helper), "+
+			"instead of prompting for it")
+	RootCmd.MarkFlagsMutuallyExclusive("password-fd", "password-file", "password-command")
 
 	addCmdInit()
 	RootCmd.AddCommand(addCmd)
+	agentCmdInit()
+	RootCmd.AddCommand(agentCmd)
+	RootCmd.AddCommand(convertCmd)
 	generateCmdInit()
 	RootCmd.AddCommand(generateCmd)
+	exportCmdInit()
+	RootCmd.AddCommand(exportCmd)
 	getCmdInit()
 	RootCmd.AddCommand(getCmd)
+	historyCmdInit()
+	RootCmd.AddCommand(historyCmd)
+	importCmdInit()
+	RootCmd.AddCommand(importCmd)
 	listCmdInit()
 	RootCmd.AddCommand(listCmd)
 	RootCmd.AddCommand(listProfilesCmd)
+	lockCmdInit()
+	RootCmd.AddCommand(lockCmd)
 	manCmdInit()
 	RootCmd.AddCommand(manCmd)
 	RootCmd.AddCommand(masterPasswordCmd)
+	migrateCmdInit()
+	RootCmd.AddCommand(migrateCmd)
+	otpCmdInit()
+	RootCmd.AddCommand(otpCmd)
 	RootCmd.AddCommand(removeCmd)
 	RootCmd.AddCommand(removeLockCmd)
+	restoreCmdInit()
+	RootCmd.AddCommand(restoreCmd)
 	searchCmdInit()
 	RootCmd.AddCommand(searchCmd)
+	trashCmdInit()
+	RootCmd.AddCommand(trashCmd)
+	RootCmd.AddCommand(unlockCmd)
 	updateCmdInit()
 	RootCmd.AddCommand(updateCmd)
 
@@ -73,26 +121,56 @@ var RootCmd = &cobra.Command{
 	Version: "1.0.0",
 	PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
 		cmd.SilenceUsage = true
+		// master password is asked again on each execution
+		rootCmdMasterPassword = ""
+		rootCmdSourcePassword = ""
+		data.ForgetKeys()
 
-		createDataFile := cmd == addCmd || cmd == masterPasswordCmd
+		createDataFile := cmd == addCmd || cmd == masterPasswordCmd || cmd == restoreCmd
 		config.Init(rootCmdProfile, createDataFile)
 
-		if cmd == removeLockCmd ||
-			cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+			// so unique IDs of a sealed profile can be completed
+			unlockFromAgent()
+
+			return nil
+		}
+
+		if cmd == removeLockCmd || cmd == agentCmd || cmd == lockCmd {
 			return nil
 		}
 
-		lockFile := config.PasumanDataFile + ".lock"
+		// read-only commands can run concurrently
+		lockMode := lock.Shared
+		if cmd == addCmd || cmd == updateCmd || cmd == removeCmd || cmd == masterPasswordCmd ||
+			cmd == migrateCmd || cmd == convertCmd || cmd == importCmd || cmd == restoreCmd ||
+			cmd == trashRestoreCmd || cmd == trashEmptyCmd || cmd == unlockCmd {
+			lockMode = lock.Exclusive
+		}
+
+		l, err := lock.Acquire(lockFile(), lockMode, rootCmdWait)
+		if errors.Is(err, lock.ErrLocked) {
+			return fmt.Errorf("%w: %v: use `--wait=<duration>` to wait for it to be released", ErrFileLocked, err)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if l.StaleOwner != nil {
+			cmdStderrPrintf(cmd, "WARNING: a previous pasuman process (%s) did not release its lock properly\n",
+				l.StaleOwner)
+		}
 
-		_, err := os.Stat(lockFile)
+		rootCmdLock = l
 
-		if errors.Is(err, os.ErrNotExist) {
-			if _, err := os.Create(lockFile); err != nil {
-				return err
-			}
-		} else {
-			return fmt.Errorf("%w: use `pasuman remove-lock` command to remove it "+
-				"(only if no other pasuman process is running!)", ErrFileLocked)
+		// the master password must be typed to change it, or to unlock the profile in the agent
+		if cmd != masterPasswordCmd && cmd != unlockCmd {
+			unlockFromAgent()
+		}
+
+		if readsEntries(cmd) {
+			return unseal(cmd)
 		}
 
 		return nil
@@ -107,17 +185,29 @@ var RootCmd = &cobra.Command{
 	},
 }
 
-// RemoveLock - removes the lock created by the `PersistentPreRunE` of the root command.
+// readsEntries - whether cmd reads entries, and so needs the master password first if the profile is sealed.
+func readsEntries(cmd *cobra.Command) bool {
+	return cmd == addCmd || cmd == exportCmd || cmd == getCmd || cmd == historyCmd || cmd == importCmd ||
+		cmd == listCmd || cmd == migrateCmd || cmd == otpCmd || cmd == removeCmd || cmd == restoreCmd ||
+		cmd == searchCmd || cmd == trashListCmd || cmd == trashRestoreCmd || cmd == trashEmptyCmd || cmd == updateCmd
+}
+
+func lockFile() string {
+	return config.PasumanDataFile + ".lock"
+}
+
+// RemoveLock - releases the lock acquired by the `PersistentPreRunE` of the root command.
 // We want to execute this even if the command fails (in the `RunE` part).
 // If there is an error during RunE, PersistentPostRunE is not run.
 // So as far as I know, we should also call `RemoveLock` manually in the `main.go`.
+// If the process is killed before, the lock is released anyway by the operating system.
 func RemoveLock() error {
-	lockFile := config.PasumanDataFile + ".lock"
-
-	err := os.Remove(lockFile)
-	if !errors.Is(err, os.ErrNotExist) && err != nil {
-		return err
+	if rootCmdLock == nil {
+		return nil
 	}
 
-	return nil
+	err := rootCmdLock.Release()
+	rootCmdLock = nil
+
+	return err
 }
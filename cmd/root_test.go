@@ -0,0 +1,103 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordSources(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{
+		UniqueID: "id1",
+		ID:       "myId",
+		Password: "p4$$w0rd!",
+	})
+	require.NoError(t, err)
+
+	passwordFile := filepath.Join(tempDir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte(pasumantest.TestMasterPassword+"\n"), 0o600))
+
+	insecurePasswordFile := filepath.Join(tempDir, "insecure-password")
+	require.NoError(t, os.WriteFile(insecurePasswordFile, []byte(pasumantest.TestMasterPassword), 0o644))
+
+	wrongPasswordFile := filepath.Join(tempDir, "wrong-password")
+	require.NoError(t, os.WriteFile(wrongPasswordFile, []byte("wrong"), 0o600))
+
+	tests := []struct {
+		args       []string
+		output     string
+		wantErr    error
+		wantErrMsg string
+	}{
+		{
+			// no prompt
+			args:   []string{"get", "id1", "--password", "--password-file", passwordFile},
+			output: "p4$$w0rd!\n",
+		},
+		{
+			args:   []string{"get", "id1", "--password", "--password-command", "echo " + pasumantest.TestMasterPassword},
+			output: "p4$$w0rd!\n",
+		},
+		{
+			args:       []string{"get", "id1", "--password", "--password-file", insecurePasswordFile},
+			wantErrMsg: "password file must only be readable and writable by its owner",
+		},
+		{
+			args:    []string{"get", "id1", "--password", "--password-file", wrongPasswordFile},
+			wantErr: util.ErrMasterPasswordIncorrect,
+		},
+		{
+			args:       []string{"get", "id1", "--password", "--password-command", "exit 1"},
+			wantErrMsg: "password command failed",
+		},
+		{
+			args: []string{
+				"get", "id1", "--password", "--password-file", passwordFile, "--password-command", "echo pass",
+			},
+			wantErrMsg: "none of the others can be",
+		},
+	}
+
+	for _, tt := range tests {
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+
+		switch {
+		case tt.wantErr != nil:
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		case tt.wantErrMsg != "":
+			require.ErrorContains(t, err, tt.wantErrMsg, tt.args)
+		default:
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
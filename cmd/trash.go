@@ -0,0 +1,244 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/norbjd/pasuman/pkg/trash"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trashCmd        *cobra.Command
+	trashListCmd    *cobra.Command
+	trashRestoreCmd *cobra.Command
+	trashEmptyCmd   *cobra.Command
+)
+
+var (
+	trashListCmdOutput = outputTable
+	trashRestoreCmdAs  string
+	trashEmptyCmdYes   bool
+)
+
+func trashCmdInit() {
+	trashCmd = &cobra.Command{
+		Use:   "trash",
+		Short: "List, restore or remove for good removed entries",
+		Long: "Removed entries are kept in the trash of the profile, until they are restored or the trash is " +
+			"emptied. Entries removed for longer than trash_retention_days (30 by default, 0 to keep them until " +
+			"the trash is emptied), set in the config file, are purged automatically.",
+	}
+
+	trashListCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List removed entries",
+		Args:  cobra.NoArgs,
+		RunE:  trashListCmdRunE,
+	}
+
+	trashListCmd.Flags().Var(&trashListCmdOutput, "output", fmt.Sprintf("Output format: %s", outputMessageHelp))
+
+	if err := trashListCmd.RegisterFlagCompletionFunc("output", outputCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	trashRestoreCmd = &cobra.Command{
+		Use:   "restore <unique id>",
+		Short: "Restore a removed entry",
+		Long: "Restore a removed entry, with its previous versions. If several entries with this unique ID " +
+			"have been removed, the last one removed is restored.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: trashCompletion,
+		RunE:              trashRestoreCmdRunE,
+	}
+
+	trashRestoreCmd.Flags().StringVar(&trashRestoreCmdAs, "as", "",
+		"Restore the entry with this unique ID, if an entry with its own unique ID has been added since")
+
+	trashEmptyCmd = &cobra.Command{
+		Use:   "empty",
+		Short: "Remove entries of the trash for good",
+		Args:  cobra.NoArgs,
+		RunE:  trashEmptyCmdRunE,
+	}
+
+	trashEmptyCmd.Flags().BoolVar(&trashEmptyCmdYes, "yes", false, "Do not ask for confirmation")
+
+	trashCmd.AddCommand(trashListCmd, trashRestoreCmd, trashEmptyCmd)
+}
+
+// jsonTrashedEntry - entry of the trash in JSON outputs.
+type jsonTrashedEntry struct {
+	UniqueID    string   `json:"unique_id"`
+	Type        string   `json:"type,omitempty"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Site        string   `json:"site"`
+	DeletedAt   string   `json:"deleted_at"`
+}
+
+func trashListCmdRunE(cmd *cobra.Command, args []string) error {
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	trashed, err := trash.List()
+	if err != nil {
+		return err
+	}
+
+	switch trashListCmdOutput {
+	case outputTable:
+		lines := make([][]string, len(trashed))
+
+		for idx, t := range trashed {
+			deletedAt := t.DeletedAt
+			if date, err := time.Parse(time.RFC3339, t.DeletedAt); err == nil {
+				deletedAt = date.Local().Format(historyTimeFormat)
+			}
+
+			lines[idx] = []string{
+				t.Entry.UniqueID, t.Entry.Description, strings.Join(t.Entry.Tags, ","), t.Entry.Site, deletedAt,
+			}
+		}
+
+		util.RenderTable(cmd.OutOrStdout(), []string{"Unique ID", "Description", "Tags", "Site", "Removed at"}, lines)
+	case outputJSON:
+		entries := make([]jsonTrashedEntry, len(trashed))
+
+		for idx, t := range trashed {
+			entries[idx] = jsonTrashedEntry{
+				UniqueID: t.Entry.UniqueID, Type: t.Entry.Type, Description: t.Entry.Description,
+				Tags: t.Entry.Tags, Site: t.Entry.Site, DeletedAt: t.DeletedAt,
+			}
+		}
+
+		result, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		cmdPrintln(cmd, string(result))
+	default:
+		return errInvalidOutput
+	}
+
+	return nil
+}
+
+func trashRestoreCmdRunE(cmd *cobra.Command, args []string) error {
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	uniqueID, err := trash.Restore(masterPassword, strings.TrimSpace(args[0]), strings.TrimSpace(trashRestoreCmdAs))
+	if errors.Is(err, trash.ErrAlreadyExists) {
+		return fmt.Errorf("%w: use `--as <unique id>` to restore it with another unique ID", err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	cmdPrintf(cmd, "Restored entry: %s\n", uniqueID)
+
+	return nil
+}
+
+func trashEmptyCmdRunE(cmd *cobra.Command, args []string) error {
+	masterPasswordSet, err := masterpassword.IsSet()
+	if err != nil {
+		return err
+	}
+
+	if !masterPasswordSet {
+		return errNoMasterPasswordSet
+	}
+
+	masterPassword, err := askMasterPassword(cmd)
+	if err != nil {
+		return err
+	}
+
+	if !trashEmptyCmdYes {
+		cmdPrintf(cmd, "Remove all entries of the trash for good? [y/N] ")
+
+		answer, err := util.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			cmdPrintln(cmd, "Trash not emptied")
+
+			return nil
+		}
+	}
+
+	count, err := trash.Empty(masterPassword)
+	if err != nil {
+		return err
+	}
+
+	cmdPrintf(cmd, "Trash emptied, entries removed for good: %d\n", count)
+
+	return nil
+}
+
+// trashCompletion - unique IDs of removed entries.
+func trashCompletion(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) != 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	trashed, err := trash.List()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	uniqueIDs := make([]string, len(trashed))
+	for idx := range trashed {
+		uniqueIDs[idx] = trashed[idx].Entry.UniqueID
+	}
+
+	return uniqueIDs, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,166 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/trash"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+// nolint: funlen
+func TestTrash(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	for _, args := range [][]string{
+		{"add", "github", "--description=Code", "--tags=dev", "--site=https://github.com", "--password=p4$$w0rd!"},
+		{"remove", "github"},
+		{"add", "github", "--password=n€wp4$$w0rd!"},
+	} {
+		_, err := pasumantest.ExecuteCommand(RootCmd, args...)
+		require.NoError(t, err, args)
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	trashed, err := trash.List()
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+
+	// dates are shown in the local time zone
+	date, err := time.Parse(time.RFC3339, trashed[0].DeletedAt)
+	require.NoError(t, err)
+
+	deletedAt := date.Local().Format(historyTimeFormat)
+
+	defer util.SetStdin(os.Stdin)
+
+	tests := []struct {
+		args    []string
+		stdin   io.Reader
+		output  string
+		wantErr error
+	}{
+		{
+			args: []string{"trash", "list"},
+			output: "" +
+				"Unique ID\tDescription\tTags\tSite\t\t\tRemoved at\t\t\n" +
+				"---------\t-----------\t----\t----\t\t\t----------\t\t\n" +
+				"github\t\tCode\t\tdev\thttps://github.com\t" + deletedAt + "\t\n",
+		},
+		{
+			args: []string{"trash", "list", "--output=json"},
+			output: "" +
+				"[\n" +
+				"  {\n" +
+				"    \"unique_id\": \"github\",\n" +
+				"    \"description\": \"Code\",\n" +
+				"    \"tags\": [\n" +
+				"      \"dev\"\n" +
+				"    ],\n" +
+				"    \"site\": \"https://github.com\",\n" +
+				"    \"deleted_at\": \"" + trashed[0].DeletedAt + "\"\n" +
+				"  }\n" +
+				"]\n",
+		},
+		{
+			args:   []string{"__complete", "trash", "restore", ""},
+			output: "github\n:4\nCompletion ended with directive: ShellCompDirectiveNoFileComp\n",
+		},
+		{
+			args:    []string{"trash", "restore", "gitlab"},
+			wantErr: data.ErrNotInTrash,
+		},
+		{
+			args:    []string{"trash", "restore", "github"},
+			wantErr: trash.ErrAlreadyExists,
+		},
+		{
+			args: []string{"trash", "restore", "github", "--as=old-github"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Restored entry: old-github\n",
+		},
+		{
+			args: []string{"get", "old-github", "--password"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"p4$$w0rd!\n",
+		},
+		{
+			args: []string{"remove", "github"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Removed entry: github (restore it with `pasuman trash restore github`)\n",
+		},
+		{
+			args:  []string{"trash", "empty"},
+			stdin: strings.NewReader("n\n"),
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Remove all entries of the trash for good? [y/N] Trash not emptied\n",
+		},
+		{
+			args:  []string{"trash", "empty"},
+			stdin: strings.NewReader("y\n"),
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Remove all entries of the trash for good? [y/N] Trash emptied, entries removed for good: 1\n",
+		},
+		{
+			args:    []string{"trash", "restore", "github"},
+			wantErr: data.ErrNotInTrash,
+		},
+		{
+			args: []string{"trash", "empty", "--yes"},
+			output: "" +
+				"Enter current master password: ✔\n" +
+				"Trash emptied, entries removed for good: 0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		if tt.stdin != nil {
+			util.SetStdin(tt.stdin)
+		}
+
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+			require.Equal(t, tt.output, out, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	_, err = get.NotSensitive("github")
+	require.ErrorIs(t, err, get.ErrNotFound)
+}
@@ -0,0 +1,88 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/spf13/cobra"
+)
+
+var errNoDataKey = errors.New("profile has no data key yet: run `pasuman migrate` first")
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the profile in the agent",
+	Long: "Unlock the profile in the agent (see `pasuman agent`), " +
+		"so other commands do not ask the master password anymore.",
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		masterPasswordSet, err := masterpassword.IsSet()
+		if err != nil {
+			return err
+		}
+
+		if !masterPasswordSet {
+			return errNoMasterPasswordSet
+		}
+
+		masterPassword, err := askMasterPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		var d data.Data
+
+		if err := d.FromFile(config.PasumanDataFile); err != nil && !errors.Is(err, data.ErrSealed) {
+			return err
+		}
+
+		// a data key generated now would not be in the profile file
+		if d.DataKey == "" {
+			return errNoDataKey
+		}
+
+		keys, err := d.Open(config.PasumanDataFile, masterPassword)
+		if err != nil {
+			return err
+		}
+
+		// the agent keeps only the data key: values still encrypted with the master password are re-encrypted
+		if d.NeedsEncryptionUpgrade() {
+			if err := d.UpgradeEncryption(keys); err != nil {
+				return err
+			}
+
+			if err := d.ToFile(config.PasumanDataFile); err != nil {
+				return err
+			}
+		}
+
+		if err := agent.Unlock(config.PasumanDataFile, d.DataKey, keys.DataKey); err != nil {
+			return err
+		}
+
+		cmdPrintln(cmd, "Profile is unlocked")
+
+		return nil
+	},
+}
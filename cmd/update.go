@@ -19,6 +19,7 @@ package cmd
 
 import (
 	"errors"
+	"log"
 	"strings"
 
 	"github.com/norbjd/pasuman/pkg/data"
@@ -36,6 +37,22 @@ var (
 	updateCmdSite        string
 	updateCmdID          string
 	updateCmdPassword    string
+	updateCmdPolicy      string
+	updateCmdNoPolicy    bool
+	updateCmdRotate      string
+	updateCmdCopy        bool
+
+	updateCmdFields          []string
+	updateCmdSensitiveFields []string
+	updateCmdRemoveFields    []string
+
+	updateCmdOTP   string
+	updateCmdOTPQR string
+
+	updateCmdNote              string
+	updateCmdNoteFile          string
+	updateCmdAttach            []string
+	updateCmdRemoveAttachments []string
 )
 
 func updateCmdInit() {
@@ -45,6 +62,58 @@ func updateCmdInit() {
 	updateCmd.Flags().StringVar(&updateCmdSite, "site", "", "Site")
 	updateCmd.Flags().StringVar(&updateCmdID, "id", "", "ID")
 	updateCmd.Flags().StringVar(&updateCmdPassword, "password", "", "Password")
+	updateCmd.Flags().StringVar(&updateCmdPolicy, "policy", "", policyUsage+" to bind the entry to (used by --rotate)")
+	updateCmd.Flags().BoolVar(&updateCmdNoPolicy, "no-policy", false, "Unbind the entry from its password policy")
+	updateCmd.MarkFlagsMutuallyExclusive("policy", "no-policy")
+	updateCmd.Flags().StringVar(&updateCmdRotate, "rotate", "",
+		"Replace the password with a generated one, complying with this password policy (default: --policy if set, "+
+			"or the policy of the entry, or the policy named default of the config file if any)")
+	updateCmd.Flags().Lookup("rotate").NoOptDefVal = defaultPolicy
+	updateCmd.MarkFlagsMutuallyExclusive("password", "rotate")
+	updateCmd.MarkFlagsMutuallyExclusive("no-policy", "rotate")
+	updateCmd.Flags().BoolVar(&updateCmdCopy, "copy", false, copyUsage)
+	updateCmd.Flags().StringArrayVar(&updateCmdFields, "field", nil,
+		fieldUsage+": replaces the custom field with the same name, if any (sensitive ones stay sensitive)")
+	updateCmd.Flags().StringArrayVar(&updateCmdSensitiveFields, "sensitive-field", nil,
+		sensitiveFieldUsage+": replaces the custom field with the same name, if any")
+	updateCmd.Flags().StringArrayVar(&updateCmdRemoveFields, "remove-field", nil,
+		"Name of a custom field to remove (can be repeated)")
+
+	updateCmd.Flags().StringVar(&updateCmdOTP, "otp", "", otpUsage)
+	updateCmd.Flags().StringVar(&updateCmdOTPQR, "otp-qr", "", otpQRUsage)
+	updateCmd.MarkFlagsMutuallyExclusive("otp", "otp-qr")
+
+	updateCmd.Flags().StringVar(&updateCmdNote, "note", "", noteUsage)
+	updateCmd.Flags().StringVar(&updateCmdNoteFile, "note-file", "", noteFileUsage)
+	updateCmd.MarkFlagsMutuallyExclusive("note", "note-file")
+	updateCmd.Flags().StringArrayVar(&updateCmdAttach, "attach", nil,
+		attachUsage+": replaces the attachment with the same name, if any")
+	updateCmd.Flags().StringArrayVar(&updateCmdRemoveAttachments, "remove-attachment", nil,
+		"Name of an attachment to remove (can be repeated)")
+
+	if err := updateCmd.RegisterFlagCompletionFunc("policy", policyCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := updateCmd.RegisterFlagCompletionFunc("rotate", policyCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := updateCmd.RegisterFlagCompletionFunc("field", fieldDefinitionCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := updateCmd.RegisterFlagCompletionFunc("sensitive-field", fieldDefinitionCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := updateCmd.RegisterFlagCompletionFunc("remove-field", fieldNameCompletion); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := updateCmd.RegisterFlagCompletionFunc("remove-attachment", attachmentNameCompletion); err != nil {
+		log.Fatal(err)
+	}
 }
 
 var updateCmd = &cobra.Command{
@@ -64,30 +133,64 @@ var updateCmd = &cobra.Command{
 
 		uniqueID := strings.TrimSpace(args[0])
 
-		if _, err := get.NotSensitive(uniqueID); errors.Is(err, get.ErrNotFound) {
+		entry, err := get.NotSensitive(uniqueID)
+		if errors.Is(err, get.ErrNotFound) {
 			return update.ErrNotFound
 		}
 
-		cmdStderrPrintf(cmd, "Enter current master password: ")
-		masterPassword, err := util.ReadPassword()
 		if err != nil {
 			return err
 		}
 
-		correct, err := masterpassword.IsCorrect(masterPassword)
+		if err := checkPolicy(updateCmdPolicy); err != nil {
+			return err
+		}
+
+		if err := checkGeneratePolicy(updateCmdRotate, updateCmdPolicy, updateCmdCopy); err != nil {
+			return err
+		}
+
+		masterPassword, err := askMasterPassword(cmd)
+		if err != nil {
+			return err
+		}
+
+		fields, err := parseFields(cmd, updateCmdFields, updateCmdSensitiveFields)
+		if err != nil {
+			return err
+		}
+
+		otpKey, err := readOTPKey(updateCmdOTP, updateCmdOTPQR)
+		if err != nil {
+			return err
+		}
+
+		note, err := readNote(updateCmdNote, updateCmdNoteFile)
 		if err != nil {
 			return err
 		}
 
-		if !correct {
-			cmdStderrPrintln(cmd, "✘")
+		attachments, err := readAttachments(updateCmdAttach)
+		if err != nil {
+			return err
+		}
 
-			return util.ErrMasterPasswordIncorrect
+		if updateCmdRotate != "" {
+			entryPolicy := entry.Policy
+			if updateCmdPolicy != "" {
+				entryPolicy = updateCmdPolicy
+			}
+
+			updateCmdPassword, updateCmdPolicy, err = generatePassword(cmd, updateCmdRotate, entryPolicy)
+			if err != nil {
+				return err
+			}
 		}
-		cmdStderrPrintln(cmd, "✔")
 
 		if updateCmdDescription == "" && len(updateCmdTags) == 0 && updateCmdSite == "" &&
-			updateCmdID == "" && updateCmdPassword == "" {
+			updateCmdID == "" && updateCmdPassword == "" && len(fields) == 0 && len(updateCmdRemoveFields) == 0 &&
+			otpKey == "" && note == "" && len(attachments) == 0 && len(updateCmdRemoveAttachments) == 0 &&
+			updateCmdPolicy == "" && !updateCmdNoPolicy {
 			cmdPrintln(cmd, "INFO: Leave field empty if you don't want to update it")
 
 			if updateCmdUniqueID == "" {
@@ -154,6 +257,14 @@ var updateCmd = &cobra.Command{
 				Site:        updateCmdSite,
 				ID:          updateCmdID,
 				Password:    updateCmdPassword,
+				Policy:      updateCmdPolicy,
+				Fields:      fields,
+				OTP:         otpKey,
+				Note:        note,
+				Attachments: attachments,
+			},
+			update.Removed{
+				Fields: updateCmdRemoveFields, Attachments: updateCmdRemoveAttachments, Policy: updateCmdNoPolicy,
 			},
 		); err != nil {
 			return err
@@ -164,6 +275,6 @@ var updateCmd = &cobra.Command{
 		}
 		cmdPrintf(cmd, "Updated: %s\n", uniqueID)
 
-		return nil
+		return copyGeneratedPassword(cmd, updateCmdPassword, updateCmdCopy)
 	},
 }
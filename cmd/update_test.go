@@ -19,14 +19,19 @@ package cmd
 
 import (
 	"os"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
 	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/constants"
 	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/generate"
 	"github.com/norbjd/pasuman/pkg/get"
 	"github.com/norbjd/pasuman/pkg/update"
+	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -64,6 +69,8 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
 				require.NoError(t, err)
+				// previous versions are tested by TestHistory
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "id1",
@@ -85,6 +92,7 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
 				require.NoError(t, err)
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "id1",
@@ -106,6 +114,7 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
 				require.NoError(t, err)
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "id1",
@@ -127,6 +136,7 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
 				require.NoError(t, err)
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "id1",
@@ -148,6 +158,7 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
 				require.NoError(t, err)
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "id1",
@@ -163,7 +174,7 @@ func TestUpdate(t *testing.T) {
 				"Updated: id1\n",
 		},
 		{
-			args: []string{"update", "id1", "--unique-id=newId1"},
+			args: []string{"update", "id1", "--unique-id=newId1", "--tags=tag2,tag3"},
 			check: func() {
 				t.Helper()
 
@@ -172,6 +183,7 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "newId1")
 				require.NoError(t, err)
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "newId1",
@@ -199,6 +211,7 @@ func TestUpdate(t *testing.T) {
 
 				entry, err := get.Sensitive(pasumantest.TestMasterPassword, "brandNewId1")
 				require.NoError(t, err)
+				entry.History = nil
 
 				require.Equal(t, data.Entry{
 					UniqueID:    "brandNewId1",
@@ -235,3 +248,185 @@ func TestUpdate(t *testing.T) {
 		pasumantest.Teardown(t, RootCmd)
 	}
 }
+
+func TestUpdatePolicy(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	// the profile is unlocked by every command
+	defer pasumantest.FastKDF()()
+
+	length := uint(20)
+
+	pasumantest.UpdateConfig(t, func(conf *config.Config) {
+		conf.Policies = map[string]generate.Rules{
+			"bank": {Length: &length},
+			"wifi": {Passphrase: true},
+		}
+	})
+
+	tests := []struct {
+		args       []string
+		wantPolicy string
+		wantErr    error
+	}{
+		{
+			args:    []string{"add", "id1", "--id=myId", "--password=p4$$w0rd!", "--policy=unknown"},
+			wantErr: config.ErrUnknownPolicy,
+		},
+		{
+			args:       []string{"add", "id1", "--id=myId", "--password=p4$$w0rd!", "--policy=bank"},
+			wantPolicy: "bank",
+		},
+		{
+			args:    []string{"update", "id1", "--policy=unknown"},
+			wantErr: config.ErrUnknownPolicy,
+		},
+		{
+			args:       []string{"update", "id1", "--policy=wifi"},
+			wantPolicy: "wifi",
+		},
+		{
+			args:       []string{"update", "id1", "--no-policy"},
+			wantPolicy: "",
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+
+			entry, err := get.NotSensitive("id1")
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPolicy, entry.Policy, tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+
+	require.NoError(t, update.Update(pasumantest.TestMasterPassword, "id1", data.Entry{Policy: "bank"}, update.Removed{}))
+
+	out, err := pasumantest.ExecuteCommand(RootCmd, "get", "id1", "--output=json")
+	require.NoError(t, err)
+	require.Contains(t, out, `"policy": "bank"`)
+
+	pasumantest.Teardown(t, RootCmd)
+}
+
+func TestUpdateRotate(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	// the profile is unlocked by every command
+	defer pasumantest.FastKDF()()
+
+	pinLength, defaultLength := uint(12), uint(16)
+
+	pasumantest.UpdateConfig(t, func(conf *config.Config) {
+		conf.Policies = map[string]generate.Rules{
+			"pin":  {Length: &pinLength, Classes: []string{generate.ClassDigit}},
+			"wifi": {Passphrase: true},
+		}
+	})
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{UniqueID: "id1", ID: "myId", Password: "p4$$w0rd!"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		args       []string
+		setup      func()
+		pattern    string
+		wantPolicy string
+		copied     bool
+		noOutTTY   bool
+		wantErr    error
+	}{
+		{
+			// neither the entry nor the config file have a policy: rules of `pasuman generate`
+			args:    []string{"update", "id1", "--rotate"},
+			pattern: `^.{128}$`,
+		},
+		{
+			args:       []string{"update", "id1", "--rotate=pin"},
+			pattern:    `^[0-9]{12}$`,
+			wantPolicy: "pin",
+		},
+		{
+			// the policy of the entry
+			args:       []string{"update", "id1", "--rotate", "--copy"},
+			pattern:    `^[0-9]{12}$`,
+			wantPolicy: "pin",
+			copied:     true,
+		},
+		{
+			args:       []string{"update", "id1", "--rotate", "--policy=wifi"},
+			pattern:    `^[a-z-]+(-[a-z-]+){5}$`,
+			wantPolicy: "wifi",
+		},
+		{
+			args: []string{"update", "id1", "--no-policy"},
+			setup: func() {
+				pasumantest.UpdateConfig(t, func(conf *config.Config) {
+					conf.Policies["default"] = generate.Rules{Length: &defaultLength}
+				})
+			},
+			pattern: `^[a-z-]+(-[a-z-]+){5}$`,
+		},
+		{
+			// the policy named default of the config file, the entry is not bound to it
+			args:    []string{"update", "id1", "--rotate"},
+			pattern: `^.{16}$`,
+		},
+		{
+			args:    []string{"update", "id1", "--rotate=bank"},
+			wantErr: config.ErrUnknownPolicy,
+		},
+		{
+			args:    []string{"update", "id1", "--rotate=pin", "--policy=wifi"},
+			wantErr: errPolicyConflict,
+		},
+		{
+			args:    []string{"update", "id1", "--description=A desc", "--copy"},
+			wantErr: errCopyFlag,
+		},
+		{
+			args:     []string{"update", "id1", "--rotate", "--copy"},
+			noOutTTY: true,
+			wantErr:  errCopyToTerminal,
+		},
+	}
+
+	util.SetStdin(strings.NewReader("\n"))
+	defer util.SetStdin(os.Stdin)
+
+	defer func() { copyStdoutIsTerminal = func() bool { return util.IsTerminal(os.Stdout) } }()
+
+	for _, tt := range tests {
+		if tt.setup != nil {
+			tt.setup()
+		}
+
+		copyStdoutIsTerminal = func() bool { return !tt.noOutTTY }
+
+		out, err := pasumantest.ExecuteCommand(RootCmd, tt.args...)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.args)
+		} else {
+			require.NoError(t, err, tt.args)
+
+			entry, err := get.Sensitive(pasumantest.TestMasterPassword, "id1")
+			require.NoError(t, err)
+			require.Regexp(t, regexp.MustCompile(tt.pattern), entry.Password, tt.args)
+			require.Equal(t, tt.wantPolicy, entry.Policy, tt.args)
+
+			// the generated password is never printed
+			require.NotContains(t, out, entry.Password, tt.args)
+			require.Equal(t, tt.copied, strings.Contains(out, "Password copied to clipboard*"), tt.args)
+		}
+
+		pasumantest.Teardown(t, RootCmd)
+	}
+}
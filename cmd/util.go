@@ -19,16 +19,24 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"github.com/norbjd/pasuman/pkg/agent"
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/data"
 	"github.com/norbjd/pasuman/pkg/list"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
 	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/spf13/cobra"
 )
 
+// clipboardNote - printed when something is copied to clipboard, marked with "*".
+const clipboardNote = "*pasuman uses ANSI OSC 52 to copy to clipboard, it may not work on some terminals\n" +
+	"(see https://github.com/ojroques/vim-oscyank/blob/main/README.md)"
+
 func cmdPrintf(cmd *cobra.Command, format string, a ...interface{}) {
 	fmt.Fprintf(cmd.OutOrStdout(), format, a...)
 }
@@ -45,6 +53,155 @@ func cmdStderrPrintln(cmd *cobra.Command, message string) {
 	cmdStderrPrintf(cmd, "%s\n", message)
 }
 
+// askMasterPassword - ask current master password (or read it from the source given by `--password-*` flags),
+// and check it. It is asked only once per execution, even if it is needed several times
+// (for example, to unseal the profile first).
+func askMasterPassword(cmd *cobra.Command) (string, error) {
+	if rootCmdMasterPassword != "" {
+		return rootCmdMasterPassword, nil
+	}
+
+	if passwordSource().IsSet() {
+		masterPassword, err := readPasswordSource()
+		if err != nil {
+			return "", err
+		}
+
+		correct, err := masterpassword.IsCorrect(masterPassword)
+		if err != nil {
+			return "", err
+		}
+
+		if !correct {
+			return "", util.ErrMasterPasswordIncorrect
+		}
+
+		rootCmdMasterPassword = masterPassword
+
+		return masterPassword, nil
+	}
+
+	cmdStderrPrintf(cmd, "Enter current master password: ")
+
+	masterPassword, err := util.ReadPassword()
+	if err != nil {
+		return "", err
+	}
+
+	correct, err := masterpassword.IsCorrect(masterPassword)
+	if err != nil {
+		return "", err
+	}
+
+	if !correct {
+		cmdStderrPrintln(cmd, "✘")
+
+		return "", util.ErrMasterPasswordIncorrect
+	}
+
+	cmdStderrPrintln(cmd, "✔")
+
+	rootCmdMasterPassword = masterPassword
+
+	return masterPassword, nil
+}
+
+func passwordSource() util.PasswordSource {
+	return util.PasswordSource{FD: rootCmdPasswordFD, File: rootCmdPasswordFile, Command: rootCmdPasswordCommand}
+}
+
+// readPasswordSource - read the password from the source given by `--password-*` flags, only once per execution.
+func readPasswordSource() (string, error) {
+	if rootCmdSourcePassword != "" {
+		return rootCmdSourcePassword, nil
+	}
+
+	password, err := passwordSource().Read()
+	if err != nil {
+		return "", err
+	}
+
+	rootCmdSourcePassword = password
+
+	return password, nil
+}
+
+// askNewPassword - ask a new password twice (what it is for, e.g. "export passphrase", is part of the prompt).
+func askNewPassword(cmd *cobra.Command, what string, errMismatch error) (string, error) {
+	cmdStderrPrintf(cmd, "Enter new %s: ", what)
+
+	password, err := util.ReadPassword()
+	if err != nil {
+		return "", err
+	}
+
+	if password == "" {
+		cmdStderrPrintln(cmd, "✘")
+
+		return "", fmt.Errorf("%s %w", what, errEmptyPassword)
+	}
+
+	cmdStderrPrintln(cmd, "✔")
+
+	cmdStderrPrintf(cmd, "Enter new %s again: ", what)
+
+	passwordAgain, err := util.ReadPassword()
+	if err != nil {
+		return "", err
+	}
+
+	if password != passwordAgain {
+		cmdStderrPrintln(cmd, "✘")
+
+		return "", errMismatch
+	}
+
+	cmdStderrPrintln(cmd, "✔")
+
+	return password, nil
+}
+
+// unlockFromAgent - get keys of the profile from the agent, if it is running and the profile is unlocked,
+// so the master password is not asked. Otherwise, it is asked as usual.
+func unlockFromAgent() {
+	wrappedDataKey, dataKey, err := agent.Get(config.PasumanDataFile)
+	if err != nil {
+		return
+	}
+
+	var d data.Data
+
+	if err := d.FromFile(config.PasumanDataFile); err != nil && !errors.Is(err, data.ErrSealed) {
+		return
+	}
+
+	// master password has been changed since the profile was unlocked
+	if d.DataKey != wrappedDataKey {
+		return
+	}
+
+	token, err := data.RememberKeys(wrappedDataKey, dataKey)
+	if err != nil {
+		return
+	}
+
+	rootCmdMasterPassword = token
+}
+
+// unseal - ask master password if the profile is sealed, so entries can be read by the command.
+func unseal(cmd *cobra.Command) error {
+	var d data.Data
+
+	// other errors are reported by the command itself
+	if err := d.FromFile(config.PasumanDataFile); !errors.Is(err, data.ErrSealed) {
+		return nil
+	}
+
+	_, err := askMasterPassword(cmd)
+
+	return err
+}
+
 func printEntries(w io.Writer, entries []data.Entry, output cmdOutput) error {
 	switch output {
 	case outputTable:
@@ -61,16 +218,22 @@ func printEntries(w io.Writer, entries []data.Entry, output cmdOutput) error {
 		util.RenderTable(w, headerColumns, lines)
 	case outputJSON:
 		type jsonEntry struct {
-			UniqueID    string   `json:"unique_id"`
-			Description string   `json:"description"`
-			Tags        []string `json:"tags"`
-			Site        string   `json:"site"`
+			UniqueID    string           `json:"unique_id"`
+			Type        string           `json:"type,omitempty"`
+			Description string           `json:"description"`
+			Tags        []string         `json:"tags"`
+			Site        string           `json:"site"`
+			Fields      []jsonField      `json:"fields,omitempty"`
+			Attachments []jsonAttachment `json:"attachments,omitempty"`
 		}
 
 		jsonEntries := make([]jsonEntry, len(entries))
 
 		for idx, e := range entries {
-			jsonEntries[idx] = jsonEntry{UniqueID: e.UniqueID, Description: e.Description, Tags: e.Tags, Site: e.Site}
+			jsonEntries[idx] = jsonEntry{
+				UniqueID: e.UniqueID, Type: e.Type, Description: e.Description, Tags: e.Tags, Site: e.Site,
+				Fields: jsonFields(e.Fields, false), Attachments: jsonAttachments(e.Attachments),
+			}
 		}
 
 		result, err := json.MarshalIndent(jsonEntries, "", "  ")
@@ -19,12 +19,14 @@ package pasumantest
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
-	"strings"
 	"testing"
 
 	"github.com/alexedwards/argon2id"
+	"github.com/norbjd/pasuman/pkg/agent"
 	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/encrypt"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/require"
@@ -59,12 +61,38 @@ func Init(t *testing.T, profile string) string {
 		[]byte(`{"data_directory": "`+dataDir+`"}`), defaultFileMode)
 	require.NoError(t, err)
 
+	// never use an agent running on the machine
+	err = os.Setenv(agent.SocketEnvVar, tempDir+string(os.PathSeparator)+"agent.sock")
+	require.NoError(t, err)
+
 	config.Init(profile, true)
 	require.NoError(t, InitProfile(profile))
 
 	return tempDir
 }
 
+// UpdateConfig - update the config file written by `Init` with update.
+func UpdateConfig(t *testing.T, update func(*config.Config)) {
+	t.Helper()
+
+	conf := config.GetConfig()
+	update(&conf)
+
+	configJSON, err := json.Marshal(conf)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(config.PasumanConfigFile, configJSON, defaultFileMode))
+}
+
+// FastKDF - derive keys with cheap parameters, for tests unlocking profiles many times.
+// Call the function returned to restore the default parameters.
+func FastKDF() func() {
+	defaultKDF := encrypt.DefaultKDF
+	encrypt.DefaultKDF = encrypt.KDF{Name: defaultKDF.Name, Time: 1, Memory: 64, Threads: 1}
+
+	return func() { encrypt.DefaultKDF = defaultKDF }
+}
+
 func InitProfile(profile string) error {
 	masterPasswordHash, err := argon2id.CreateHash(TestMasterPassword, argon2id.DefaultParams)
 	if err != nil {
@@ -93,37 +121,10 @@ func ExecuteCommand(c *cobra.Command, args ...string) (string, error) {
 
 // Teardown - reset flags to default value
 // and run all "PostRun" functions.
-// For some reason, `f.Value.Set(f.DefValue)` does not work
-// if the value is of type slice and default value is nil
-// because if so, f.DefValue is the string "[]".
 func Teardown(t *testing.T, c *cobra.Command) {
 	t.Helper()
 
-	c.Flags().VisitAll(func(f *pflag.Flag) {
-		var err error
-
-		if strings.HasSuffix(f.Value.Type(), "Slice") {
-			err = f.Value.Set("")
-		} else {
-			err = f.Value.Set(f.DefValue)
-		}
-
-		require.NoError(t, err)
-	})
-
-	for _, subCommand := range c.Commands() {
-		subCommand.Flags().VisitAll(func(f *pflag.Flag) {
-			var err error
-
-			if strings.HasSuffix(f.Value.Type(), "Slice") {
-				err = f.Value.Set("")
-			} else {
-				err = f.Value.Set(f.DefValue)
-			}
-
-			require.NoError(t, err)
-		})
-	}
+	resetFlags(t, c)
 
 	if c.PostRun != nil {
 		c.PostRun(c, nil)
@@ -143,3 +144,38 @@ func Teardown(t *testing.T, c *cobra.Command) {
 		require.NoError(t, err)
 	}
 }
+
+// resetFlags - reset flags of c and of its subcommands, recursively.
+func resetFlags(t *testing.T, c *cobra.Command) {
+	t.Helper()
+
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		resetFlag(t, f)
+	})
+
+	for _, subCommand := range c.Commands() {
+		resetFlags(t, subCommand)
+	}
+}
+
+// resetFlag - reset f to its default value.
+// For some reason, `f.Value.Set(f.DefValue)` does not work
+// if the value is of type slice and default value is nil
+// because if so, f.DefValue is the string "[]"; and `f.Value.Set("")` appends to
+// the previous value once the flag has been set.
+func resetFlag(t *testing.T, f *pflag.Flag) {
+	t.Helper()
+
+	var err error
+
+	if sliceValue, ok := f.Value.(pflag.SliceValue); ok {
+		err = sliceValue.Replace(nil)
+	} else {
+		err = f.Value.Set(f.DefValue)
+	}
+
+	require.NoError(t, err)
+
+	// otherwise, flags would still be considered set by the next command (e.g. in mutually exclusive groups)
+	f.Changed = false
+}
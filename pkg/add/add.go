@@ -18,36 +18,37 @@
 package add
 
 import (
+	"time"
+
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/data"
-	"github.com/norbjd/pasuman/pkg/encrypt"
 	"github.com/norbjd/pasuman/pkg/util"
 )
 
 func Add(masterPassword string, e data.Entry) (string, error) {
 	var d data.Data
 
-	if err := d.FromFile(config.PasumanDataFile); err != nil {
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
 		return "", err
 	}
 
-	var err error
-
 	if e.UniqueID == "" {
 		if e.UniqueID, err = util.NewUUIDV4(); err != nil {
 			return "", err
 		}
 	}
 
-	if e.ID, err = encrypt.Encrypt(masterPassword, e.ID); err != nil {
+	if err := d.UpgradeEncryption(keys); err != nil {
 		return "", err
 	}
 
-	if e.Password, err = encrypt.Encrypt(masterPassword, e.Password); err != nil {
+	if err := e.Encrypt(keys); err != nil {
 		return "", err
 	}
 
 	d.Entries = append(d.Entries, e)
+	d.PurgeTrash(config.GetConfig().TrashRetention(), time.Now())
 
 	return e.UniqueID, d.ToFile(config.PasumanDataFile)
 }
@@ -26,7 +26,6 @@ import (
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/constants"
 	"github.com/norbjd/pasuman/pkg/data"
-	"github.com/norbjd/pasuman/pkg/encrypt"
 	"github.com/norbjd/pasuman/pkg/util"
 	"github.com/stretchr/testify/require"
 )
@@ -89,15 +88,14 @@ func TestAdd(t *testing.T) {
 				err := d.FromFile(config.PasumanDataFile)
 				require.NoError(t, err)
 
+				keys, err := d.Unlock("pass")
+				require.NoError(t, err)
+
 				for _, entry := range d.Entries {
 					if entry.UniqueID == "new-unique-id" {
-						id, err := encrypt.Decrypt("pass", entry.ID)
-						require.NoError(t, err)
-						require.Equal(t, "my-id", id)
-
-						password, err := encrypt.Decrypt("pass", entry.Password)
-						require.NoError(t, err)
-						require.Equal(t, "p4$$w0rd!", password)
+						require.NoError(t, entry.Decrypt(keys))
+						require.Equal(t, "my-id", entry.ID)
+						require.Equal(t, "p4$$w0rd!", entry.Password)
 
 						return
 					}
@@ -0,0 +1,144 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// SocketEnvVar - environment variable overriding the path of the agent socket.
+const SocketEnvVar = "PASUMAN_AGENT_SOCKET"
+
+const (
+	commandGet    = "get"
+	commandUnlock = "unlock"
+	commandLock   = "lock"
+
+	dialTimeout    = 2 * time.Second
+	requestTimeout = 5 * time.Second
+)
+
+var (
+	ErrNotRunning     = errors.New("agent is not running: start it with `pasuman agent`")
+	ErrProfileLocked  = errors.New("profile is locked in the agent: run `pasuman unlock`")
+	ErrAlreadyRunning = errors.New("agent is already running")
+
+	errUnknownCommand = errors.New("unknown command")
+)
+
+// request - sent by pasuman commands to the agent, one per connection.
+type request struct {
+	Command        string `json:"command"`
+	DataFile       string `json:"data_file,omitempty"`
+	WrappedDataKey string `json:"wrapped_data_key,omitempty"`
+	DataKey        []byte `json:"data_key,omitempty"`
+}
+
+// response - sent by the agent. Error is empty on success.
+type response struct {
+	Error          string `json:"error,omitempty"`
+	WrappedDataKey string `json:"wrapped_data_key,omitempty"`
+	DataKey        []byte `json:"data_key,omitempty"`
+}
+
+// SocketPath - path of the agent socket: `$PASUMAN_AGENT_SOCKET` if set, otherwise a socket
+// in a directory only accessible by the user (in `$XDG_RUNTIME_DIR` if set, or in the temporary directory).
+func SocketPath() string {
+	if socketPath := os.Getenv(SocketEnvVar); socketPath != "" {
+		return socketPath
+	}
+
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "pasuman", "agent.sock")
+	}
+
+	return filepath.Join(os.TempDir(), "pasuman-"+strconv.Itoa(os.Getuid()), "agent.sock")
+}
+
+// Get - data key of the profile stored in dataFile, and the wrapped data key it has been unwrapped from
+// (see `data.Data.DataKey`). The master password is never kept by the agent.
+// Fails with ErrNotRunning if the agent is not running, and ErrProfileLocked if the profile has not been unlocked.
+func Get(dataFile string) (string, []byte, error) {
+	resp, err := call(request{Command: commandGet, DataFile: dataFile})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return resp.WrappedDataKey, resp.DataKey, nil
+}
+
+// Unlock - keep the data key of the profile stored in dataFile in the agent, unwrapped from wrappedDataKey.
+func Unlock(dataFile string, wrappedDataKey string, dataKey []byte) error {
+	_, err := call(request{
+		Command:        commandUnlock,
+		DataFile:       dataFile,
+		WrappedDataKey: wrappedDataKey,
+		DataKey:        dataKey,
+	})
+
+	return err
+}
+
+// Lock - make the agent forget keys of the profile stored in dataFile, or of all profiles if dataFile is empty.
+func Lock(dataFile string) error {
+	_, err := call(request{Command: commandLock, DataFile: dataFile})
+
+	return err
+}
+
+func call(req request) (response, error) {
+	conn, err := net.DialTimeout("unix", SocketPath(), dialTimeout)
+	if errors.Is(err, syscall.ENOENT) || errors.Is(err, syscall.ECONNREFUSED) {
+		return response{}, ErrNotRunning
+	}
+
+	if err != nil {
+		return response{}, err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(requestTimeout)); err != nil {
+		return response{}, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return response{}, err
+	}
+
+	var resp response
+
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return response{}, err
+	}
+
+	switch resp.Error {
+	case "":
+		return resp, nil
+	case ErrProfileLocked.Error():
+		return response{}, ErrProfileLocked
+	default:
+		return response{}, errors.New(resp.Error) // nolint: goerr113
+	}
+}
@@ -0,0 +1,134 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, idleTimeout time.Duration) *Server {
+	t.Helper()
+
+	// the socket directory is created by `Listen`
+	t.Setenv(SocketEnvVar, filepath.Join(t.TempDir(), "agent", "agent.sock"))
+
+	listener, err := Listen()
+	require.NoError(t, err)
+
+	server := &Server{IdleTimeout: idleTimeout}
+
+	go func() {
+		_ = server.Serve(listener)
+	}()
+
+	t.Cleanup(func() {
+		listener.Close()
+		server.LockAll()
+	})
+
+	return server
+}
+
+func TestAgent(t *testing.T) {
+	_ = startServer(t, 0)
+
+	info, err := os.Stat(SocketPath())
+	require.NoError(t, err)
+	require.Equal(t, socketMode, info.Mode().Perm())
+
+	_, err = Listen()
+	require.ErrorIs(t, err, ErrAlreadyRunning)
+
+	_, _, err = Get("default.json")
+	require.ErrorIs(t, err, ErrProfileLocked)
+
+	dataKey := []byte("01234567890123456789012345678901")
+	otherDataKey := []byte("abcdefghijabcdefghijabcdefghijab")
+
+	require.NoError(t, Unlock("default.json", "wrapped", dataKey))
+	require.NoError(t, Unlock("other.json", "otherwrapped", otherDataKey))
+
+	wrappedDataKey, got, err := Get("default.json")
+	require.NoError(t, err)
+	require.Equal(t, "wrapped", wrappedDataKey)
+	require.Equal(t, dataKey, got)
+
+	require.NoError(t, Lock("default.json"))
+
+	_, _, err = Get("default.json")
+	require.ErrorIs(t, err, ErrProfileLocked)
+
+	_, got, err = Get("other.json")
+	require.NoError(t, err)
+	require.Equal(t, otherDataKey, got)
+
+	require.NoError(t, Lock(""))
+
+	_, _, err = Get("other.json")
+	require.ErrorIs(t, err, ErrProfileLocked)
+}
+
+func TestAgentIdleTimeout(t *testing.T) {
+	_ = startServer(t, 200*time.Millisecond)
+
+	require.NoError(t, Unlock("default.json", "wrapped", []byte("01234567890123456789012345678901")))
+
+	// each request resets the idle timer
+	for i := 0; i < 3; i++ {
+		time.Sleep(100 * time.Millisecond)
+
+		_, _, err := Get("default.json")
+		require.NoError(t, err)
+	}
+
+	time.Sleep(400 * time.Millisecond)
+
+	_, _, err := Get("default.json")
+	require.ErrorIs(t, err, ErrProfileLocked)
+}
+
+func TestAgentNotRunning(t *testing.T) {
+	t.Setenv(SocketEnvVar, filepath.Join(t.TempDir(), "agent.sock"))
+
+	_, _, err := Get("default.json")
+	require.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestListenInsecureSocketDir(t *testing.T) {
+	socketDir := t.TempDir()
+	require.NoError(t, os.Chmod(socketDir, 0o755))
+
+	t.Setenv(SocketEnvVar, filepath.Join(socketDir, "agent.sock"))
+
+	_, err := Listen()
+	require.ErrorIs(t, err, errInsecureSocketDir)
+}
+
+func TestLockedAlloc(t *testing.T) {
+	buffer, err := lockedAlloc(64)
+	require.NoError(t, err)
+	require.Len(t, buffer, 64)
+
+	copy(buffer, "secret")
+	lockedFree(buffer)
+}
@@ -0,0 +1,74 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !windows
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// lockedAlloc - allocate size bytes outside of the Go heap, and lock them in memory,
+// so they are never written to swap. They must be freed with `lockedFree`.
+func lockedAlloc(size int) ([]byte, error) {
+	pageSize := os.Getpagesize()
+	length := (size/pageSize + 1) * pageSize
+
+	buffer, err := unix.Mmap(-1, 0, length, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANON)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unix.Mlock(buffer); err != nil {
+		_ = unix.Munmap(buffer)
+
+		return nil, fmt.Errorf("cannot lock memory: %w", err)
+	}
+
+	return buffer[:size], nil
+}
+
+// lockedFree - wipe and free a buffer allocated by `lockedAlloc`.
+func lockedFree(buffer []byte) {
+	buffer = buffer[:cap(buffer)]
+
+	for i := range buffer {
+		buffer[i] = 0
+	}
+
+	_ = unix.Munlock(buffer)
+	_ = unix.Munmap(buffer)
+}
+
+func checkSocketDir(socketDir string) error {
+	info, err := os.Stat(socketDir)
+	if err != nil {
+		return err
+	}
+
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || !info.IsDir() || int(stat.Uid) != os.Getuid() || info.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("%w: %s", errInsecureSocketDir, socketDir)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build windows
+
+package agent
+
+// lockedAlloc - memory is not locked on Windows: keys may be written to the page file.
+func lockedAlloc(size int) ([]byte, error) {
+	return make([]byte, size), nil
+}
+
+// lockedFree - wipe a buffer allocated by `lockedAlloc`.
+func lockedFree(buffer []byte) {
+	for i := range buffer {
+		buffer[i] = 0
+	}
+}
+
+// checkSocketDir - the socket directory is created in the user profile, not accessible by other users.
+func checkSocketDir(socketDir string) error {
+	return nil
+}
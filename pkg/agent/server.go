@@ -0,0 +1,217 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	dirMode    = os.FileMode(0o700)
+	socketMode = os.FileMode(0o600)
+)
+
+var errInsecureSocketDir = errors.New("agent socket directory must be only accessible by its owner")
+
+// secret - data key of a profile, stored in locked memory (see `lockedAlloc`).
+type secret struct {
+	wrappedDataKey string
+	buffer         []byte
+}
+
+// Server - the agent: keeps keys of unlocked profiles in memory, and forgets them after IdleTimeout
+// without any request (0 to keep them until `LockAll`).
+type Server struct {
+	IdleTimeout time.Duration
+
+	mu        sync.Mutex
+	secrets   map[string]secret
+	idleTimer *time.Timer
+}
+
+// Listen - listen on the agent socket (see `SocketPath`), creating its directory if needed.
+// A socket left by an agent that has not been stopped properly is replaced.
+func Listen() (net.Listener, error) {
+	socketPath := SocketPath()
+	socketDir := filepath.Dir(socketPath)
+
+	if err := os.MkdirAll(socketDir, dirMode); err != nil {
+		return nil, err
+	}
+
+	if err := checkSocketDir(socketDir); err != nil {
+		return nil, err
+	}
+
+	if conn, err := net.DialTimeout("unix", socketPath, dialTimeout); err == nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("%w on %s", ErrAlreadyRunning, socketPath)
+	}
+
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(socketPath, socketMode); err != nil {
+		listener.Close()
+
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// Serve - serve requests of pasuman commands on listener, until it is closed.
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// LockAll - forget keys of all profiles.
+func (s *Server) LockAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lock("")
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(requestTimeout)); err != nil {
+		return
+	}
+
+	resp, err := s.serve(conn)
+	if err != nil {
+		resp = response{Error: err.Error()}
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+func (s *Server) serve(conn net.Conn) (response, error) {
+	if err := checkPeer(conn); err != nil {
+		return response{}, err
+	}
+
+	var req request
+
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		return response{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.resetIdleTimer()
+
+	switch req.Command {
+	case commandGet:
+		return s.get(req.DataFile)
+	case commandUnlock:
+		return response{}, s.unlock(req)
+	case commandLock:
+		s.lock(req.DataFile)
+
+		return response{}, nil
+	default:
+		return response{}, fmt.Errorf("%w: %s", errUnknownCommand, req.Command)
+	}
+}
+
+func (s *Server) get(dataFile string) (response, error) {
+	sec, ok := s.secrets[dataFile]
+	if !ok {
+		return response{}, ErrProfileLocked
+	}
+
+	dataKey := make([]byte, len(sec.buffer))
+	copy(dataKey, sec.buffer)
+
+	return response{WrappedDataKey: sec.wrappedDataKey, DataKey: dataKey}, nil
+}
+
+func (s *Server) unlock(req request) error {
+	buffer, err := lockedAlloc(len(req.DataKey))
+	if err != nil {
+		return err
+	}
+
+	copy(buffer, req.DataKey)
+
+	s.lock(req.DataFile)
+
+	if s.secrets == nil {
+		s.secrets = map[string]secret{}
+	}
+
+	s.secrets[req.DataFile] = secret{
+		wrappedDataKey: req.WrappedDataKey,
+		buffer:         buffer,
+	}
+
+	return nil
+}
+
+// lock - forget keys of the profile stored in dataFile, or of all profiles if dataFile is empty.
+// Caller must hold s.mu.
+func (s *Server) lock(dataFile string) {
+	for file, sec := range s.secrets {
+		if dataFile == "" || file == dataFile {
+			lockedFree(sec.buffer)
+			delete(s.secrets, file)
+		}
+	}
+}
+
+// resetIdleTimer - caller must hold s.mu.
+func (s *Server) resetIdleTimer() {
+	if s.IdleTimeout <= 0 {
+		return
+	}
+
+	if s.idleTimer != nil {
+		s.idleTimer.Stop()
+	}
+
+	s.idleTimer = time.AfterFunc(s.IdleTimeout, s.LockAll)
+}
@@ -0,0 +1,184 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package archive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/encrypt"
+)
+
+const (
+	// format - identifies archives of pasuman.
+	format = "pasuman-archive"
+	// version - version of the format of archives: increment it if the format changes.
+	version = 1
+)
+
+var (
+	ErrInvalidArchive = errors.New("not a pasuman archive")
+	ErrCorrupted      = errors.New("cannot decrypt archive: wrong passphrase, or archive has been modified")
+	errUnsupported    = errors.New("unsupported archive version: upgrade pasuman")
+)
+
+// Profile - a profile, with its entries, their previous versions and the trash decrypted.
+type Profile struct {
+	Name    string              `json:"name"`
+	Sealed  bool                `json:"sealed"`
+	Entries []data.Entry        `json:"entries"`
+	Trash   []data.TrashedEntry `json:"trash,omitempty"`
+}
+
+// Archive - an archive file. Its content (profiles) is encrypted with a key derived from the passphrase of
+// the archive, and bound to the other fields, so they cannot be modified either.
+type Archive struct {
+	Format    string `json:"format"`
+	Version   int    `json:"version"`
+	CreatedAt string `json:"created_at"`
+	Content   string `json:"content"`
+}
+
+type content struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+func (a Archive) associatedData() string {
+	return fmt.Sprintf("%s/%d/%s", a.Format, a.Version, a.CreatedAt)
+}
+
+// ReadProfile - read profile name from dataFile, decrypting its entries with masterPassword.
+func ReadProfile(name, dataFile, masterPassword string) (Profile, error) {
+	var d data.Data
+
+	keys, err := d.Open(dataFile, masterPassword)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	for idx := range d.Entries {
+		if err := decryptEntry(&d.Entries[idx], dataFile, keys); err != nil {
+			return Profile{}, err
+		}
+	}
+
+	for idx := range d.Trash {
+		if err := decryptEntry(&d.Trash[idx].Entry, dataFile, keys); err != nil {
+			return Profile{}, err
+		}
+	}
+
+	sort.Slice(d.Entries, func(i, j int) bool {
+		return d.Entries[i].UniqueID < d.Entries[j].UniqueID
+	})
+
+	return Profile{Name: name, Sealed: d.Sealed, Entries: d.Entries, Trash: d.Trash}, nil
+}
+
+// decryptEntry - decrypt the entry and its previous versions, which are encrypted with the data key of the profile.
+// Archives are self-contained: attachments are read from their blobs.
+func decryptEntry(entry *data.Entry, dataFile string, keys encrypt.Keys) error {
+	if err := entry.Decrypt(keys); err != nil {
+		return err
+	}
+
+	if err := entry.LoadAttachments(dataFile, keys); err != nil {
+		return err
+	}
+
+	if entry.History == nil {
+		return nil
+	}
+
+	entry.History = append([]data.Revision(nil), entry.History...)
+
+	for idx := range entry.History {
+		if err := decryptEntry(&entry.History[idx].Entry, dataFile, keys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Write - write an archive of profiles to w, encrypted with passphrase. Archives are self-contained: they do not
+// depend on master passwords, nor on the format of profile files.
+func Write(w io.Writer, passphrase string, profiles []Profile) error {
+	plaintext, err := json.Marshal(content{Profiles: profiles})
+	if err != nil {
+		return err
+	}
+
+	a := Archive{Format: format, Version: version, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+
+	if a.Content, err = encrypt.EncryptWithPassword(passphrase, string(plaintext), a.associatedData()); err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(a)
+}
+
+// Parse - read an archive written by `Write`, without decrypting it yet.
+func Parse(r io.Reader) (Archive, error) {
+	var a Archive
+
+	if err := json.NewDecoder(r).Decode(&a); err != nil || a.Format != format {
+		return Archive{}, ErrInvalidArchive
+	}
+
+	if a.Version != version {
+		return Archive{}, fmt.Errorf("%w (version %d)", errUnsupported, a.Version)
+	}
+
+	return a, nil
+}
+
+// Profiles - decrypt profiles of the archive, checking that it has not been modified.
+func (a Archive) Profiles(passphrase string) ([]Profile, error) {
+	plaintext, err := encrypt.DecryptWithPassword(passphrase, a.Content, a.associatedData())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	var c content
+
+	if err := json.Unmarshal([]byte(plaintext), &c); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupted, err)
+	}
+
+	return c.Profiles, nil
+}
+
+// Find - profile name among profiles.
+func Find(profiles []Profile, name string) (Profile, bool) {
+	for _, profile := range profiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+
+	return Profile{}, false
+}
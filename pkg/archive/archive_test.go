@@ -0,0 +1,139 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package archive
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/convert"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/remove"
+	"github.com/norbjd/pasuman/pkg/update"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRead(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	entry := data.Entry{
+		UniqueID:    "id1",
+		Description: "A desc",
+		Tags:        []string{"tag1", "tag2"},
+		Site:        "https://mysupersite.pasuman",
+		ID:          "myId",
+		Password:    "p4$$w0rd!",
+	}
+
+	_, err := add.Add(pasumantest.TestMasterPassword, entry)
+	require.NoError(t, err)
+
+	require.NoError(t, convert.Convert(pasumantest.TestMasterPassword, true))
+
+	profile, err := ReadProfile("default", config.PasumanDataFile, pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+	require.Equal(t, Profile{Name: "default", Sealed: true, Entries: []data.Entry{entry}}, profile)
+
+	other := Profile{Name: "other", Entries: []data.Entry{{UniqueID: "id2", Password: "t0ps3cr3t!"}}}
+
+	var b bytes.Buffer
+
+	require.NoError(t, Write(&b, "passphrase", []Profile{profile, other}))
+
+	// nothing is written in plaintext
+	require.NotContains(t, b.String(), "id1")
+	require.NotContains(t, b.String(), "p4$$w0rd!")
+
+	a, err := Parse(bytes.NewReader(b.Bytes()))
+	require.NoError(t, err)
+
+	profiles, err := a.Profiles("passphrase")
+	require.NoError(t, err)
+	require.Equal(t, []Profile{profile, other}, profiles)
+
+	found, ok := Find(profiles, "other")
+	require.True(t, ok)
+	require.Equal(t, other, found)
+
+	_, ok = Find(profiles, "unknown")
+	require.False(t, ok)
+
+	_, err = a.Profiles("wrong passphrase")
+	require.ErrorIs(t, err, ErrCorrupted)
+
+	// the header is bound to the content
+	a.CreatedAt = "2000-01-01T00:00:00Z"
+	_, err = a.Profiles("passphrase")
+	require.ErrorIs(t, err, ErrCorrupted)
+}
+
+func TestReadProfileHistoryAndTrash(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{UniqueID: "id1", ID: "myId", Password: "p4$$w0rd!",
+		Attachments: []data.Attachment{{Name: "key.txt", Content: []byte("s3cr3t k3y")}}})
+	require.NoError(t, err)
+
+	require.NoError(t, update.Update(pasumantest.TestMasterPassword, "id1", data.Entry{Password: "n3wp4$$w0rd!"},
+		update.Removed{Attachments: []string{"key.txt"}}))
+
+	_, err = add.Add(pasumantest.TestMasterPassword, data.Entry{UniqueID: "id2", ID: "myId", Password: "t0ps3cr3t!"})
+	require.NoError(t, err)
+
+	require.NoError(t, remove.Remove(pasumantest.TestMasterPassword, "id2"))
+
+	profile, err := ReadProfile("default", config.PasumanDataFile, pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+
+	// previous versions and removed entries are decrypted too, with their attachments
+	require.Len(t, profile.Entries, 1)
+	require.Equal(t, "n3wp4$$w0rd!", profile.Entries[0].Password)
+	require.Len(t, profile.Entries[0].History, 1)
+
+	previous := profile.Entries[0].History[0].Entry
+	require.Equal(t, "p4$$w0rd!", previous.Password)
+	require.Equal(t, []byte("s3cr3t k3y"), previous.Attachments[0].Content)
+	require.Empty(t, previous.Attachments[0].Blob)
+
+	require.Len(t, profile.Trash, 1)
+	require.Equal(t, "t0ps3cr3t!", profile.Trash[0].Entry.Password)
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		content string
+		wantErr error
+	}{
+		{content: "", wantErr: ErrInvalidArchive},
+		{content: `{"version": 5, "entries": []}`, wantErr: ErrInvalidArchive},
+		{content: `{"format": "pasuman-archive", "version": 2, "content": ""}`, wantErr: errUnsupported},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.content))
+		require.ErrorIs(t, err, tt.wantErr, tt.content)
+	}
+}
@@ -0,0 +1,53 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package audit
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// suffix - suffix of the audit log of a profile, written beside the profile file.
+const suffix = ".audit.log"
+
+const fileMode = os.FileMode(0o600)
+
+// File - audit log of the profile written in dataFile.
+func File(dataFile string) string {
+	return dataFile + suffix
+}
+
+// Log - append event (e.g. "export of 3 entries with secrets") to the audit log of the profile written
+// in dataFile, with the time and the process.
+func Log(dataFile string, event string) error {
+	f, err := os.OpenFile(File(dataFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, fileMode)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(f, "%s pid=%d uid=%d %s\n", time.Now().UTC().Format(time.RFC3339), os.Getpid(),
+		os.Getuid(), event)
+	if err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,45 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLog(t *testing.T) {
+	t.Parallel()
+
+	dataFile := filepath.Join(t.TempDir(), "default.json")
+
+	require.NoError(t, Log(dataFile, "first event"))
+	require.NoError(t, Log(dataFile, "second event"))
+
+	info, err := os.Stat(File(dataFile))
+	require.NoError(t, err)
+	require.Equal(t, fileMode, info.Mode().Perm())
+
+	content, err := os.ReadFile(File(dataFile))
+	require.NoError(t, err)
+	require.Regexp(t, regexp.MustCompile(`^\S+Z pid=\d+ uid=-?\d+ first event\n\S+Z pid=\d+ uid=-?\d+ second event\n$`),
+		string(content))
+}
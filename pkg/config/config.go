@@ -24,14 +24,24 @@ import (
 	"io/fs"
 	"log"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/norbjd/pasuman/pkg/generate"
 	"github.com/norbjd/pasuman/pkg/util"
 )
 
 const (
 	defaultDirMode  = os.FileMode(0o700)
 	defaultFileMode = os.FileMode(0o600)
+
+	// DefaultHistorySize - number of previous versions kept for each entry, if not configured.
+	DefaultHistorySize = 10
+	// DefaultTrashRetentionDays - number of days removed entries are kept in the trash, if not configured.
+	DefaultTrashRetentionDays = 30
+
+	day = 24 * time.Hour
 )
 
 var (
@@ -40,6 +50,8 @@ var (
 	PasumanDataFile   string
 )
 
+var ErrUnknownPolicy = errors.New("unknown password policy")
+
 var errCannotInitConfigFile = errors.New("cannot init config file")
 
 func Init(profile string, createDataFile bool) {
@@ -59,8 +71,67 @@ func Init(profile string, createDataFile bool) {
 	initDataFile(config, profile, createDataFile)
 }
 
+// Config - content of the config file. HistorySize is the number of previous versions kept for each entry
+// (DefaultHistorySize if not set, 0 to keep none), and HistoryMaxAgeDays drops versions older than this number
+// of days (if set). TrashRetentionDays is the number of days removed entries are kept in the trash
+// (DefaultTrashRetentionDays if not set, 0 to keep them until the trash is emptied). Policies are named rules
+// to generate passwords (e.g. the ones of a bank site), see `generate.Rules`.
 type Config struct {
-	DataDirectory string `json:"data_directory"`
+	DataDirectory      string                    `json:"data_directory"`
+	HistorySize        *int                      `json:"history_size,omitempty"`
+	HistoryMaxAgeDays  int                       `json:"history_max_age_days,omitempty"`
+	TrashRetentionDays *int                      `json:"trash_retention_days,omitempty"`
+	Policies           map[string]generate.Rules `json:"policies,omitempty"`
+}
+
+// HistoryVersions - number of previous versions kept for each entry.
+func (c Config) HistoryVersions() int {
+	if c.HistorySize == nil || *c.HistorySize < 0 {
+		return DefaultHistorySize
+	}
+
+	return *c.HistorySize
+}
+
+// HistoryMaxAge - maximum age of previous versions of entries, or 0 if they are kept regardless of their age.
+func (c Config) HistoryMaxAge() time.Duration {
+	if c.HistoryMaxAgeDays <= 0 {
+		return 0
+	}
+
+	return time.Duration(c.HistoryMaxAgeDays) * day
+}
+
+// TrashRetention - how long removed entries are kept in the trash, or 0 if they are kept until it is emptied.
+func (c Config) TrashRetention() time.Duration {
+	days := DefaultTrashRetentionDays
+	if c.TrashRetentionDays != nil && *c.TrashRetentionDays >= 0 {
+		days = *c.TrashRetentionDays
+	}
+
+	return time.Duration(days) * day
+}
+
+// Policy - rules of the password policy name.
+func (c Config) Policy(name string) (generate.Rules, error) {
+	rules, ok := c.Policies[name]
+	if !ok {
+		return generate.Rules{}, fmt.Errorf("%w: %s", ErrUnknownPolicy, name)
+	}
+
+	return rules, nil
+}
+
+// PolicyNames - names of password policies, sorted.
+func (c Config) PolicyNames() []string {
+	names := make([]string, 0, len(c.Policies))
+	for name := range c.Policies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
 }
 
 func GetConfig() Config {
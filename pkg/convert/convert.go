@@ -0,0 +1,57 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package convert
+
+import (
+	"errors"
+
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+var (
+	ErrAlreadySealed   = errors.New("profile is already sealed")
+	ErrAlreadyUnsealed = errors.New("profile is already unsealed")
+)
+
+// Convert - seal entries of the profile (encrypt them as a whole, including non-sensitive data),
+// or unseal them.
+func Convert(masterPassword string, sealed bool) error {
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return err
+	}
+
+	if d.Sealed == sealed {
+		if sealed {
+			return ErrAlreadySealed
+		}
+
+		return ErrAlreadyUnsealed
+	}
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return err
+	}
+
+	d.Sealed = sealed
+
+	return d.ToFile(config.PasumanDataFile)
+}
@@ -0,0 +1,80 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package convert
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/list"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvert(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	entry := data.Entry{
+		UniqueID:    "id1",
+		Description: "A desc",
+		Tags:        []string{"tag1", "tag2"},
+		Site:        "https://mysupersite.pasuman",
+		ID:          "myId",
+		Password:    "p4$$w0rd!",
+	}
+
+	_, err := add.Add(pasumantest.TestMasterPassword, entry)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, Convert(pasumantest.TestMasterPassword, false), ErrAlreadyUnsealed)
+
+	require.NoError(t, Convert(pasumantest.TestMasterPassword, true))
+	require.ErrorIs(t, Convert(pasumantest.TestMasterPassword, true), ErrAlreadySealed)
+
+	byteContents, err := os.ReadFile(config.PasumanDataFile)
+	require.NoError(t, err)
+	require.NotContains(t, string(byteContents), "id1")
+	require.NotContains(t, string(byteContents), "A desc")
+
+	// nor in the backup of the previous (unsealed) file
+	byteContents, err = os.ReadFile(config.PasumanDataFile + data.BackupSuffix)
+	require.NoError(t, err)
+	require.NotContains(t, string(byteContents), "A desc")
+	require.NotContains(t, string(byteContents), "https://mysupersite.pasuman")
+
+	data.ForgetKeys()
+
+	_, err = list.List(constants.RootCmdDefaultProfile)
+	require.ErrorIs(t, err, data.ErrSealed)
+
+	require.NoError(t, Convert(pasumantest.TestMasterPassword, false))
+
+	byteContents, err = os.ReadFile(config.PasumanDataFile)
+	require.NoError(t, err)
+	require.Contains(t, string(byteContents), "A desc")
+
+	entries, err := list.List(constants.RootCmdDefaultProfile)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "A desc", entries[0].Description)
+}
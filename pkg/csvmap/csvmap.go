@@ -0,0 +1,400 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package csvmap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+// Field - field of an entry a column is mapped to.
+type Field string
+
+const (
+	UniqueID    Field = "unique_id"
+	Description Field = "description"
+	// Tags - tags, separated by commas.
+	Tags Field = "tags"
+	// Folder - tags, as nested folders separated by slashes (e.g. "Internet/Shopping").
+	Folder   Field = "folder"
+	Site     Field = "site"
+	ID       Field = "id"
+	Password Field = "password"
+	// OTP - key of one-time passwords (an otpauth:// URI or a base32 secret, see `otp.Parse`).
+	OTP Field = "otp"
+	// Ignored - column not mapped to any field: ignored when reading, and empty when writing.
+	Ignored Field = ""
+)
+
+// Fields - all fields columns can be mapped to.
+var Fields = []Field{UniqueID, Description, Tags, Folder, Site, ID, Password, OTP}
+
+// Column - column of a CSV file. Columns are matched by name (case insensitive) with the header of files read.
+type Column struct {
+	Name  string
+	Field Field
+	// Optional - whether the column can be missing from files read.
+	Optional bool
+}
+
+// Mapping - columns of a CSV file, in order.
+type Mapping []Column
+
+// Default - mapping of CSV files of pasuman: one column per field, named after it (all optional).
+var Default = Mapping{
+	{Name: string(UniqueID), Field: UniqueID, Optional: true},
+	{Name: string(Description), Field: Description, Optional: true},
+	{Name: string(Tags), Field: Tags, Optional: true},
+	{Name: string(Site), Field: Site, Optional: true},
+	{Name: string(ID), Field: ID, Optional: true},
+	{Name: string(Password), Field: Password, Optional: true},
+}
+
+// Presets - mappings of CSV files of other password managers.
+var Presets = map[string]Mapping{
+	// Chrome (and other Chromium based browsers): Settings > Passwords > Export passwords
+	"chrome": {
+		{Name: "name", Field: UniqueID},
+		{Name: "url", Field: Site},
+		{Name: "username", Field: ID},
+		{Name: "password", Field: Password},
+		{Name: "note", Field: Description, Optional: true},
+	},
+	// Firefox: about:logins > Export Logins (entries have no name: unique IDs are the hosts of sites)
+	"firefox": {
+		{Name: "url", Field: Site},
+		{Name: "username", Field: ID},
+		{Name: "password", Field: Password},
+	},
+	// LastPass: Advanced Options > Export
+	"lastpass": {
+		{Name: "url", Field: Site},
+		{Name: "username", Field: ID},
+		{Name: "password", Field: Password},
+		{Name: "totp", Field: OTP, Optional: true},
+		{Name: "extra", Field: Description},
+		{Name: "name", Field: UniqueID},
+		{Name: "grouping", Field: Folder},
+		{Name: "fav", Field: Ignored, Optional: true},
+	},
+}
+
+// utf8BOM - byte order mark some tools (e.g. spreadsheets) write at the beginning of UTF-8 files.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+var (
+	ErrInvalidMapping = errors.New("invalid column mapping")
+	errMissingColumns = errors.New("missing columns")
+	errInvalidCSV     = errors.New("invalid CSV file")
+)
+
+// ParseMapping - mapping from the name of a preset, or from `column=field` definitions (an empty field ignores
+// the column). Columns of definitions are optional when reading files, but at least one must be present.
+func ParseMapping(definitions []string) (Mapping, error) {
+	if len(definitions) == 1 && !strings.Contains(definitions[0], "=") {
+		if preset, ok := Presets[definitions[0]]; ok {
+			return preset, nil
+		}
+
+		return nil, fmt.Errorf("%w: unknown preset %s (available presets: %s)", ErrInvalidMapping,
+			definitions[0], strings.Join(PresetNames(), ", "))
+	}
+
+	mapping := make(Mapping, 0, len(definitions))
+
+	for _, definition := range definitions {
+		name, field, found := strings.Cut(definition, "=")
+		if !found || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%w: %s is not column=field", ErrInvalidMapping, definition)
+		}
+
+		column := Column{Name: strings.TrimSpace(name), Field: Field(strings.TrimSpace(field)), Optional: true}
+
+		if !column.Field.valid() {
+			return nil, fmt.Errorf("%w: unknown field %s (available fields: %s)", ErrInvalidMapping,
+				column.Field, strings.Join(fieldNames(), ", "))
+		}
+
+		mapping = append(mapping, column)
+	}
+
+	return mapping, nil
+}
+
+// PresetNames - names of presets, sorted.
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func fieldNames() []string {
+	names := make([]string, len(Fields))
+	for idx, field := range Fields {
+		names[idx] = string(field)
+	}
+
+	return names
+}
+
+func (f Field) valid() bool {
+	if f == Ignored {
+		return true
+	}
+
+	for _, field := range Fields {
+		if f == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sensitive - whether IDs, passwords or one-time password keys are mapped to a column.
+func (m Mapping) Sensitive() bool {
+	for _, column := range m {
+		if column.Field.sensitive() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithoutSensitive - mapping without columns mapped to IDs, passwords or one-time password keys.
+func (m Mapping) WithoutSensitive() Mapping {
+	var mapping Mapping
+
+	for _, column := range m {
+		if !column.Field.sensitive() {
+			mapping = append(mapping, column)
+		}
+	}
+
+	return mapping
+}
+
+func (f Field) sensitive() bool {
+	return f == ID || f == Password || f == OTP
+}
+
+// Read - entries of a CSV file with a header, columns mapped with mapping. Columns not in mapping are ignored.
+// If the unique ID of an entry is empty, the host of its site is used instead.
+func Read(r io.Reader, mapping Mapping) ([]data.Entry, error) {
+	reader := bufio.NewReader(r)
+
+	if start, err := reader.Peek(len(utf8BOM)); err == nil && bytes.Equal(start, utf8BOM) {
+		if _, err := reader.Discard(len(utf8BOM)); err != nil {
+			return nil, err
+		}
+	}
+
+	csvReader := csv.NewReader(reader)
+	// rows with missing or extra cells are accepted
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: no header", errInvalidCSV)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errInvalidCSV, err)
+	}
+
+	indexes, err := columnIndexes(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []data.Entry
+
+	for {
+		row, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errInvalidCSV, err)
+		}
+
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+
+		entries = append(entries, entry(row, mapping, indexes))
+	}
+
+	return entries, nil
+}
+
+// columnIndexes - index in header of each column of mapping, or -1 if it is missing and optional.
+func columnIndexes(header []string, mapping Mapping) ([]int, error) {
+	indexes := make([]int, len(mapping))
+
+	var missing []string
+
+	found := 0
+
+	for idx, column := range mapping {
+		indexes[idx] = -1
+
+		for headerIdx, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), column.Name) {
+				indexes[idx] = headerIdx
+				found++
+
+				break
+			}
+		}
+
+		if indexes[idx] == -1 && !column.Optional {
+			missing = append(missing, column.Name)
+		}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s", errMissingColumns, strings.Join(missing, ", "))
+	}
+
+	if found == 0 {
+		return nil, fmt.Errorf("%w: none of %s", errMissingColumns, strings.Join(mapping.names(), ", "))
+	}
+
+	return indexes, nil
+}
+
+func (m Mapping) names() []string {
+	names := make([]string, len(m))
+	for idx, column := range m {
+		names[idx] = column.Name
+	}
+
+	return names
+}
+
+func entry(row []string, mapping Mapping, indexes []int) data.Entry {
+	var entry data.Entry
+
+	for idx, column := range mapping {
+		if indexes[idx] == -1 || indexes[idx] >= len(row) {
+			continue
+		}
+
+		value := row[indexes[idx]]
+
+		switch column.Field {
+		case UniqueID:
+			entry.UniqueID = value
+		case Description:
+			entry.Description = value
+		case Tags:
+			entry.Tags = append(entry.Tags, split(value, ",")...)
+		case Folder:
+			entry.Tags = append(entry.Tags, split(value, "/")...)
+		case Site:
+			entry.Site = value
+		case ID:
+			entry.ID = value
+		case Password:
+			entry.Password = value
+		case OTP:
+			entry.AddOTP(column.Name, value)
+		case Ignored:
+		}
+	}
+
+	if strings.TrimSpace(entry.UniqueID) == "" && entry.Site != "" {
+		if site, err := url.Parse(entry.Site); err == nil {
+			entry.UniqueID = site.Hostname()
+		}
+	}
+
+	return entry
+}
+
+func split(value, separator string) []string {
+	var values []string
+
+	for _, v := range strings.Split(value, separator) {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// Write - write entries to a CSV file with a header, columns mapped with mapping.
+func Write(w io.Writer, entries []data.Entry, mapping Mapping) error {
+	csvWriter := csv.NewWriter(w)
+
+	if err := csvWriter.Write(mapping.names()); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		row := make([]string, len(mapping))
+
+		for idx, column := range mapping {
+			switch column.Field {
+			case UniqueID:
+				row[idx] = entry.UniqueID
+			case Description:
+				row[idx] = entry.Description
+			case Tags:
+				row[idx] = strings.Join(entry.Tags, ",")
+			case Folder:
+				row[idx] = strings.Join(entry.Tags, "/")
+			case Site:
+				row[idx] = entry.Site
+			case ID:
+				row[idx] = entry.ID
+			case Password:
+				row[idx] = entry.Password
+			case OTP:
+				row[idx] = entry.OTP
+			case Ignored:
+			}
+		}
+
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
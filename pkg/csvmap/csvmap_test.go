@@ -0,0 +1,207 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package csvmap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadPresets(t *testing.T) {
+	mail := data.Entry{
+		UniqueID:    "mail",
+		Description: "Personal mailbox\nSecond line",
+		Site:        "https://mail.pasuman",
+		ID:          "me@mail.pasuman",
+		Password:    `p4$$,"w0rd"`,
+	}
+
+	tests := []struct {
+		preset string
+		want   []data.Entry
+	}{
+		{
+			preset: "chrome",
+			want: []data.Entry{
+				mail,
+				{UniqueID: "forum.pasuman", Site: "https://forum.pasuman/login", ID: "myId", Password: "t0ps3cr3t!"},
+			},
+		},
+		{
+			preset: "firefox",
+			want: []data.Entry{
+				{UniqueID: "mail.pasuman", Site: "https://mail.pasuman", ID: "me@mail.pasuman", Password: `p4$$,"w0rd"`},
+				{UniqueID: "forum.pasuman", Site: "https://forum.pasuman:8443", ID: "myId", Password: "t0ps3cr3t!"},
+			},
+		},
+		{
+			preset: "lastpass",
+			want: []data.Entry{
+				mail,
+				{
+					UniqueID: "forum",
+					Tags:     []string{"Internet", "Forums"},
+					Site:     "https://forum.pasuman",
+					ID:       "myId",
+					Password: "t0ps3cr3t!",
+					OTP:      "JBSWY3DPEHPK3PXP",
+				},
+				{UniqueID: "wifi", Description: "Network: pasuman", Tags: []string{"Home"}, Site: "http://sn"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		mapping, err := ParseMapping([]string{tt.preset})
+		require.NoError(t, err, tt.preset)
+
+		f, err := os.Open(filepath.Join("testdata", tt.preset+".csv"))
+		require.NoError(t, err, tt.preset)
+
+		entries, err := Read(f, mapping)
+		require.NoError(t, err, tt.preset)
+		require.Equal(t, tt.want, entries, tt.preset)
+
+		f.Close()
+	}
+}
+
+func TestRead(t *testing.T) {
+	mapping, err := ParseMapping([]string{"Title=unique_id", "Labels=tags", "Secret=password", "2FA=otp", "Comment="})
+	require.NoError(t, err)
+
+	tests := []struct {
+		content string
+		want    []data.Entry
+		wantErr error
+	}{
+		{
+			// columns are matched case insensitively, in any order, and unmapped columns are ignored
+			content: "secret,Other, title ,labels\n" +
+				"p4$$w0rd,ignored,mail,\"tag1, tag2\"\n" +
+				"\n" +
+				"t0ps3cr3t!,ignored,forum\n",
+			want: []data.Entry{
+				{UniqueID: "mail", Tags: []string{"tag1", "tag2"}, Password: "p4$$w0rd"},
+				{UniqueID: "forum", Password: "t0ps3cr3t!"},
+			},
+		},
+		{
+			// keys of one-time passwords that cannot be used are kept in a sensitive custom field
+			content: "title,2FA\nmail,otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP\nforum,steam://N0TB4SE32\n",
+			want: []data.Entry{
+				{UniqueID: "mail", OTP: "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP"},
+				{UniqueID: "forum", Fields: []data.Field{{Name: "2FA", Value: "steam://N0TB4SE32", Sensitive: true}}},
+			},
+		},
+		{
+			// columns of mappings are optional
+			content: "title\nmail\n",
+			want:    []data.Entry{{UniqueID: "mail"}},
+		},
+		{
+			content: "Title\n",
+			want:    nil,
+		},
+		{
+			content: "",
+			wantErr: errInvalidCSV,
+		},
+		{
+			content: "title,secret\nmail,\"p4$$w0rd\n",
+			wantErr: errInvalidCSV,
+		},
+		{
+			content: "name,password\nmail,p4$$w0rd\n",
+			wantErr: errMissingColumns,
+		},
+	}
+
+	for _, tt := range tests {
+		entries, err := Read(strings.NewReader(tt.content), mapping)
+		require.ErrorIs(t, err, tt.wantErr, tt.content)
+		require.Equal(t, tt.want, entries, tt.content)
+	}
+
+	_, err = Read(strings.NewReader("name,url,password\nmail,https://mail.pasuman,p4$$w0rd\n"), Presets["chrome"])
+	require.ErrorIs(t, err, errMissingColumns)
+	require.ErrorContains(t, err, "username")
+}
+
+func TestParseMapping(t *testing.T) {
+	mapping, err := ParseMapping([]string{" Title = unique_id", "Group=folder", "Ignored="})
+	require.NoError(t, err)
+	require.Equal(t, Mapping{
+		{Name: "Title", Field: UniqueID, Optional: true},
+		{Name: "Group", Field: Folder, Optional: true},
+		{Name: "Ignored", Field: Ignored, Optional: true},
+	}, mapping)
+	require.False(t, mapping.Sensitive())
+
+	mapping, err = ParseMapping([]string{"lastpass"})
+	require.NoError(t, err)
+	require.True(t, mapping.Sensitive())
+
+	for _, definitions := range [][]string{{"unknown"}, {"Title"}, {"=unique_id"}, {"Title=unknown"}} {
+		_, err := ParseMapping(definitions)
+		require.ErrorIs(t, err, ErrInvalidMapping, definitions)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	entries := []data.Entry{
+		{
+			UniqueID:    "mail",
+			Description: "Personal mailbox\nSecond line",
+			Tags:        []string{"Internet", "Mail"},
+			Site:        "https://mail.pasuman",
+			ID:          "me@mail.pasuman",
+			Password:    `p4$$,"w0rd"`,
+		},
+		{UniqueID: "empty"},
+	}
+
+	var b bytes.Buffer
+
+	require.NoError(t, Write(&b, entries, Default))
+	require.Equal(t, ""+
+		"unique_id,description,tags,site,id,password\n"+
+		"mail,\"Personal mailbox\nSecond line\",\"Internet,Mail\",https://mail.pasuman,me@mail.pasuman,\"p4$$,\"\"w0rd\"\"\"\n"+
+		"empty,,,,,\n", b.String())
+
+	read, err := Read(&b, Default)
+	require.NoError(t, err)
+	require.Equal(t, entries, read)
+
+	b.Reset()
+
+	entries[0].OTP = "JBSWY3DPEHPK3PXP"
+
+	require.NoError(t, Write(&b, entries, Presets["lastpass"]))
+	require.Equal(t, ""+
+		"url,username,password,totp,extra,name,grouping,fav\n"+
+		"https://mail.pasuman,me@mail.pasuman,\"p4$$,\"\"w0rd\"\"\",JBSWY3DPEHPK3PXP,"+
+		"\"Personal mailbox\nSecond line\",mail,Internet/Mail,\n"+
+		",,,,,empty,,\n", b.String())
+}
@@ -0,0 +1,304 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/norbjd/pasuman/pkg/encrypt"
+	"github.com/norbjd/pasuman/pkg/util"
+)
+
+const (
+	// MaxAttachmentSize - maximum size of an attachment, in bytes.
+	MaxAttachmentSize = 10 << 20
+	// MaxNoteSize - maximum size of a secure note, in bytes: larger files must be attached instead.
+	MaxNoteSize = 64 << 10
+
+	attachmentsSuffix  = ".attachments"
+	attachmentsDirMode = os.FileMode(0o700)
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+	ErrTooLarge           = errors.New("too large")
+
+	errBlobMissing = errors.New("content of attachment is missing")
+)
+
+// Attachment - file attached to an entry. Its content is stored encrypted in a blob, in a directory beside
+// the profile file (see `AttachmentsDir`), so large files do not bloat it. Content is only set in memory
+// (and in archives): it is written to a new blob by `ToFile`.
+type Attachment struct {
+	Name    string `json:"name"`
+	Size    int    `json:"size"`
+	Blob    string `json:"blob,omitempty"`
+	Content []byte `json:"content,omitempty"`
+}
+
+// AttachmentsDir - directory of blobs of attachments of the profile written in file.
+func AttachmentsDir(file string) string {
+	return file + attachmentsSuffix
+}
+
+// blobAssociatedData - binds a blob to its name, so it cannot be swapped with another one without being
+// detected (names of blobs are authenticated by the MAC of the profile file).
+func blobAssociatedData(blob string) string {
+	return "attachments/" + blob
+}
+
+// ReadAttachment - decrypted content of an attachment of an entry of the profile written in file.
+func ReadAttachment(file string, keys encrypt.Keys, attachment Attachment) ([]byte, error) {
+	if attachment.Content != nil {
+		return attachment.Content, nil
+	}
+
+	// empty attachments read from archives have neither content nor blob
+	if attachment.Blob == "" && attachment.Size == 0 {
+		return []byte{}, nil
+	}
+
+	encrypted, err := os.ReadFile(filepath.Join(AttachmentsDir(file), attachment.Blob))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s (%s)", errBlobMissing, attachment.Name, attachment.Blob)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted, err := keys.Decrypt(string(encrypted), blobAssociatedData(attachment.Blob))
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot decrypt attachment %s: %v", ErrTampered, attachment.Name, err)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(decrypted)
+	if err != nil || len(content) != attachment.Size {
+		return nil, fmt.Errorf("%w: attachment %s has been modified", ErrTampered, attachment.Name)
+	}
+
+	return content, nil
+}
+
+// LoadAttachments - read the content of attachments of the entry, and forget their blobs: so, the entry can be
+// written to another profile (or archive).
+func (e *Entry) LoadAttachments(file string, keys encrypt.Keys) error {
+	if e.Attachments == nil {
+		return nil
+	}
+
+	e.Attachments = append([]Attachment(nil), e.Attachments...)
+
+	for idx := range e.Attachments {
+		content, err := ReadAttachment(file, keys, e.Attachments[idx])
+		if err != nil {
+			return err
+		}
+
+		e.Attachments[idx].Content = content
+		e.Attachments[idx].Blob = ""
+	}
+
+	return nil
+}
+
+// Attachment - attachment name of the entry.
+func (e *Entry) Attachment(name string) (Attachment, bool) {
+	for _, attachment := range e.Attachments {
+		if attachment.Name == name {
+			return attachment, true
+		}
+	}
+
+	return Attachment{}, false
+}
+
+// SetAttachment - add an attachment to the entry, or replace the one with the same name.
+func (e *Entry) SetAttachment(attachment Attachment) {
+	e.Attachments = append([]Attachment(nil), e.Attachments...)
+
+	for idx := range e.Attachments {
+		if e.Attachments[idx].Name == attachment.Name {
+			e.Attachments[idx] = attachment
+
+			return
+		}
+	}
+
+	e.Attachments = append(e.Attachments, attachment)
+}
+
+// RemoveAttachment - remove attachment name from the entry, and return false if there is no such attachment.
+// Its blob is removed once the profile file (and its backup) does not refer to it anymore.
+func (e *Entry) RemoveAttachment(name string) bool {
+	for idx := range e.Attachments {
+		if e.Attachments[idx].Name == name {
+			e.Attachments = append(append([]Attachment(nil), e.Attachments[:idx]...), e.Attachments[idx+1:]...)
+
+			if len(e.Attachments) == 0 {
+				e.Attachments = nil
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// blobs - blobs referred to by entries (including removed ones), and by their previous versions.
+func (data *Data) blobs() map[string]bool {
+	blobs := map[string]bool{}
+
+	addBlobs := func(attachments []Attachment) {
+		for _, attachment := range attachments {
+			if attachment.Blob != "" {
+				blobs[attachment.Blob] = true
+			}
+		}
+	}
+
+	addEntryBlobs := func(entry Entry) {
+		addBlobs(entry.Attachments)
+
+		for _, revision := range entry.History {
+			addBlobs(revision.Entry.Attachments)
+		}
+	}
+
+	for _, entry := range data.Entries {
+		addEntryBlobs(entry)
+	}
+
+	for _, trashed := range data.Trash {
+		addEntryBlobs(trashed.Entry)
+	}
+
+	return blobs
+}
+
+// writeAttachments - write the content of new attachments to new blobs, encrypted with the data key.
+func (data *Data) writeAttachments(file string) error {
+	for idx := range data.Entries {
+		if err := data.writeEntryAttachments(file, &data.Entries[idx]); err != nil {
+			return err
+		}
+	}
+
+	// entries restored from an archive come with their previous versions and the trash (see `LoadAttachments`)
+	for idx := range data.Trash {
+		if err := data.writeEntryAttachments(file, &data.Trash[idx].Entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeEntryAttachments - write new attachments of the entry, and of its previous versions.
+func (data *Data) writeEntryAttachments(file string, entry *Entry) error {
+	for attachmentIdx := range entry.Attachments {
+		if entry.Attachments[attachmentIdx].Content == nil {
+			continue
+		}
+
+		if len(entry.Attachments[attachmentIdx].Content) > MaxAttachmentSize {
+			return fmt.Errorf("%w: attachment %s is larger than %d bytes", ErrTooLarge,
+				entry.Attachments[attachmentIdx].Name, MaxAttachmentSize)
+		}
+
+		keys, ok := unlockedKeys[data.DataKey]
+		if !ok {
+			return errMasterPasswordRequired
+		}
+
+		// attachments may be shared with the entry this one has been copied from
+		entry.Attachments = append([]Attachment(nil), entry.Attachments...)
+		attachment := &entry.Attachments[attachmentIdx]
+
+		blob, err := util.NewUUIDV4()
+		if err != nil {
+			return err
+		}
+
+		encrypted, err := keys.Encrypt(base64.StdEncoding.EncodeToString(attachment.Content),
+			blobAssociatedData(blob))
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(AttachmentsDir(file), attachmentsDirMode); err != nil {
+			return err
+		}
+
+		if err := util.WriteFileAtomic(filepath.Join(AttachmentsDir(file), blob), []byte(encrypted),
+			fileMode); err != nil {
+			return err
+		}
+
+		attachment.Blob = blob
+		attachment.Size = len(attachment.Content)
+		attachment.Content = nil
+	}
+
+	if entry.History == nil {
+		return nil
+	}
+
+	// previous versions may be shared with the entry this one has been copied from
+	entry.History = append([]Revision(nil), entry.History...)
+
+	for idx := range entry.History {
+		if err := data.writeEntryAttachments(file, &entry.History[idx].Entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeUnreferencedAttachments - remove blobs that are neither referred to by data, nor by the data read
+// (now kept as a backup, see `ToFile`).
+func (data *Data) removeUnreferencedAttachments(file string) error {
+	dirEntries, err := os.ReadDir(AttachmentsDir(file))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	referenced := data.blobs()
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || referenced[dirEntry.Name()] || data.readBlobs[dirEntry.Name()] {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(AttachmentsDir(file), dirEntry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
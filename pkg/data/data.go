@@ -19,37 +19,215 @@ package data
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+
+	"github.com/norbjd/pasuman/pkg/util"
 )
 
+const (
+	fileMode = os.FileMode(0o600)
+
+	BackupSuffix = ".bak"
+)
+
+// Data - content of a profile file. Removed entries are kept in Trash. If Sealed is true, entries
+// (and the trash) are written encrypted as a whole in SealedEntries (and SealedTrash), instead of Entries
+// (and Trash).
 type Data struct {
-	MasterPassword string  `json:"master_password"`
-	Entries        []Entry `json:"entries"`
+	Version        int            `json:"version"`
+	MasterPassword string         `json:"master_password"`
+	DataKey        string         `json:"data_key,omitempty"`
+	Sealed         bool           `json:"sealed,omitempty"`
+	SealedEntries  string         `json:"sealed_entries,omitempty"`
+	Entries        []Entry        `json:"entries"`
+	SealedTrash    string         `json:"sealed_trash,omitempty"`
+	Trash          []TrashedEntry `json:"trash,omitempty"`
+	MAC            string         `json:"mac,omitempty"`
+
+	// locked - entries are sealed, and have not been read yet
+	locked bool
+	// macContent - content of the file read, authenticated by MAC (see `verify`)
+	macContent []byte
+	// readBlobs - blobs of attachments referred to by the file read, kept until it is not the backup anymore
+	readBlobs map[string]bool
 }
 
+// Entry - entry of a profile. ID, password, values of sensitive custom fields, OTP (the key of one-time
+// passwords, as an otpauth:// URI or a base32 secret) and the secure note are sensitive, and stored encrypted.
+// Attachments are stored encrypted beside the profile file (see `Attachment`). Previous versions of the entry
+// are kept in History. Policy is the name of the password policy (of the config file) the entry is bound to,
+// if any: it is used to generate new passwords of the entry.
 type Entry struct {
-	UniqueID    string   `json:"unique_id"`
-	Description string   `json:"description"`
-	Tags        []string `json:"tags"`
-	Site        string   `json:"site"`
-	ID          string   `json:"id"`
-	Password    string   `json:"password"`
+	UniqueID    string       `json:"unique_id"`
+	Type        string       `json:"type,omitempty"`
+	Description string       `json:"description"`
+	Tags        []string     `json:"tags"`
+	Site        string       `json:"site"`
+	ID          string       `json:"id"`
+	Password    string       `json:"password"`
+	Policy      string       `json:"policy,omitempty"`
+	Fields      []Field      `json:"fields,omitempty"`
+	OTP         string       `json:"otp,omitempty"`
+	Note        string       `json:"note,omitempty"`
+	Attachments []Attachment `json:"attachments,omitempty"`
+	History     []Revision   `json:"history,omitempty"`
 }
 
+// Field - custom field of an entry. Values of sensitive fields are encrypted, as IDs and passwords.
+type Field struct {
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+}
+
+// FromFile - read data from file, and migrate it to the current version if necessary.
+// If file cannot be parsed (for example, if it has been truncated), fall back to the backup
+// kept by `ToFile`, and warn about it.
+// If entries are sealed and keys have not been unlocked yet, `ErrSealed` is returned, but
+// data other than entries is read: use `Open` to read entries of sealed profiles.
 func (data *Data) FromFile(file string) error {
 	byteContents, err := os.ReadFile(file)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(byteContents, data)
+	err = data.fromJSON(byteContents)
+	if errors.Is(err, ErrUnsupportedVersion) {
+		return err
+	}
+
+	if err != nil {
+		if err := data.fromBackup(file, err); err != nil {
+			return err
+		}
+	}
+
+	if keys, ok := unlockedKeys[data.DataKey]; ok {
+		if err := data.verify(keys); err != nil {
+			return err
+		}
+	}
+
+	return data.unseal()
+}
+
+func (data *Data) fromBackup(file string, err error) error {
+	backupFile := file + BackupSuffix
+
+	backupByteContents, backupErr := os.ReadFile(backupFile)
+	if backupErr != nil {
+		return err
+	}
+
+	*data = Data{}
+
+	if backupErr := data.fromJSON(backupByteContents); backupErr != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "WARNING: %s is corrupted (%v), using backup %s instead.\n"+
+		"WARNING: changes made since this backup are lost!\n", file, err, backupFile)
+
+	return nil
+}
+
+func (data *Data) fromJSON(byteContents []byte) error {
+	var raw map[string]interface{}
+
+	if err := json.Unmarshal(byteContents, &raw); err != nil {
+		return err
+	}
+
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	macContent, err := macContent(raw)
+	if err != nil {
+		return err
+	}
+
+	if _, err := Migrate(raw); err != nil {
+		return err
+	}
+
+	migratedByteContents, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(migratedByteContents, data); err != nil {
+		return err
+	}
+
+	data.macContent = macContent
+	data.readBlobs = data.blobs()
+
+	return nil
+}
+
+// isSealed - whether byteContents (the content of a profile file) has its entries sealed.
+func isSealed(byteContents []byte) bool {
+	var sealed struct {
+		Sealed bool `json:"sealed"`
+	}
+
+	return json.Unmarshal(byteContents, &sealed) == nil && sealed.Sealed
 }
 
+// ToFile - write data to file atomically, with the current version. The previous content of file,
+// if valid, is kept in a backup file (see `BackupSuffix`); if entries are sealed by this write, data
+// is the backup too, so entries are not left in plaintext. New attachments are written before, and
+// blobs of attachments referred to neither by data nor by the backup are removed after.
 func (data *Data) ToFile(file string) error {
-	byteContents, err := json.MarshalIndent(data, "", "  ")
+	data.Version = CurrentVersion
+
+	if err := data.writeAttachments(file); err != nil {
+		return err
+	}
+
+	toWrite, err := data.seal()
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(file, byteContents, os.ModeAppend)
+	if err := toWrite.authenticate(); err != nil {
+		return err
+	}
+
+	byteContents, err := json.MarshalIndent(toWrite, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	previousByteContents, err := os.ReadFile(file)
+
+	switch {
+	case err == nil && json.Valid(previousByteContents):
+		// the previous file must not keep in plaintext entries sealed since (see `convert.Convert`)
+		if data.Sealed && !isSealed(previousByteContents) {
+			previousByteContents = byteContents
+		}
+
+		if err := util.WriteFileAtomic(file+BackupSuffix, previousByteContents, fileMode); err != nil {
+			return err
+		}
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if err := util.WriteFileAtomic(file, byteContents, fileMode); err != nil {
+		return err
+	}
+
+	if err := data.removeUnreferencedAttachments(file); err != nil {
+		return err
+	}
+
+	// data written is the backup of the next write
+	data.readBlobs = data.blobs()
+
+	return nil
 }
@@ -0,0 +1,142 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// requireSameData - compare data, ignoring what is only known when reading a file.
+func requireSameData(t *testing.T, want, got Data) {
+	t.Helper()
+
+	got.macContent = nil
+
+	require.Equal(t, want, got)
+}
+
+func TestToFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.json")
+
+	first := Data{Entries: []Entry{{UniqueID: "id1"}}}
+	require.NoError(t, first.ToFile(file))
+
+	_, err := os.Stat(file + BackupSuffix)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	second := Data{Entries: []Entry{{UniqueID: "id1"}, {UniqueID: "id2"}}}
+	require.NoError(t, second.ToFile(file))
+
+	var got Data
+	require.NoError(t, got.FromFile(file))
+	requireSameData(t, second, got)
+
+	var backup Data
+	require.NoError(t, backup.FromFile(file+BackupSuffix))
+	requireSameData(t, first, backup)
+}
+
+func TestFromFileFallbackToBackup(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.json")
+
+	first := Data{Entries: []Entry{{UniqueID: "id1"}}}
+	require.NoError(t, first.ToFile(file))
+
+	second := Data{Entries: []Entry{{UniqueID: "id1"}, {UniqueID: "id2"}}}
+	require.NoError(t, second.ToFile(file))
+
+	// simulate a truncated write
+	require.NoError(t, os.WriteFile(file, []byte(`{"entries": [{"unique_id": "id1"`), 0o600))
+
+	var got Data
+	require.NoError(t, got.FromFile(file))
+	requireSameData(t, first, got)
+
+	// a corrupted file must not replace a valid backup
+	require.NoError(t, got.ToFile(file))
+
+	var backup Data
+	require.NoError(t, backup.FromFile(file+BackupSuffix))
+	requireSameData(t, first, backup)
+
+	// no backup to fall back to
+	require.NoError(t, os.Remove(file+BackupSuffix))
+	require.NoError(t, os.WriteFile(file, []byte(`{"entries": [`), 0o600))
+	require.Error(t, got.FromFile(file))
+}
+
+var updateGoldenFiles = flag.Bool("update", false, "update golden files in testdata")
+
+// TestMigrateGolden - every version of the profile file format must have a sample file in testdata,
+// that must be migrated to the current version as in its golden file.
+// Run `go test ./pkg/data -run TestMigrateGolden -update` to update golden files
+// after adding a migration, and check the diff.
+func TestMigrateGolden(t *testing.T) {
+	for version := 0; version <= CurrentVersion; version++ {
+		file := filepath.Join("testdata", fmt.Sprintf("v%d.json", version))
+		goldenFile := filepath.Join("testdata", fmt.Sprintf("v%d.golden.json", version))
+
+		byteContents, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(byteContents, &raw))
+
+		fileVersion, err := Version(raw)
+		require.NoError(t, err)
+		require.Equal(t, version, fileVersion)
+
+		var d Data
+
+		if sealed, _ := raw["sealed"].(bool); sealed {
+			_, err = d.Open(file, "pass")
+		} else {
+			err = d.FromFile(file)
+		}
+
+		require.NoError(t, err)
+		require.Equal(t, CurrentVersion, d.Version)
+
+		got, err := json.MarshalIndent(d, "", "  ")
+		require.NoError(t, err)
+
+		if *updateGoldenFiles {
+			require.NoError(t, os.WriteFile(goldenFile, append(got, '\n'), 0o600))
+		}
+
+		want, err := os.ReadFile(goldenFile)
+		require.NoError(t, err)
+		require.JSONEq(t, string(want), string(got))
+	}
+}
+
+func TestMigrateUnsupportedVersion(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.json")
+
+	require.NoError(t, os.WriteFile(file, []byte(fmt.Sprintf(`{"version": %d}`, CurrentVersion+1)), 0o600))
+
+	var d Data
+	require.ErrorIs(t, d.FromFile(file), ErrUnsupportedVersion)
+}
@@ -0,0 +1,232 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/encrypt"
+	"github.com/norbjd/pasuman/pkg/otp"
+)
+
+const (
+	fieldID       = "id"
+	fieldPassword = "password"
+	fieldOTP      = "otp"
+	fieldNote     = "note"
+
+	// TypeLogin - type of entries storing credentials (the default).
+	TypeLogin = ""
+	// TypeNote - type of entries storing a secure note and attachments, rather than credentials.
+	TypeNote = "note"
+)
+
+var ErrFieldNotFound = errors.New("custom field not found")
+
+// sensitiveFields - fields of the entry stored encrypted, by name: custom fields are named after
+// their own name, prefixed by "fields/" (so a custom field named "id" is not the ID). The OTP key and the
+// note are optional: they are not encrypted if empty, so they are left out of profile files.
+func (e *Entry) sensitiveFields() map[string]*string {
+	fields := map[string]*string{
+		fieldID:       &e.ID,
+		fieldPassword: &e.Password,
+	}
+
+	if e.OTP != "" {
+		fields[fieldOTP] = &e.OTP
+	}
+
+	if e.Note != "" {
+		fields[fieldNote] = &e.Note
+	}
+
+	for idx := range e.Fields {
+		if e.Fields[idx].Sensitive {
+			fields["fields/"+strconv.Quote(e.Fields[idx].Name)] = &e.Fields[idx].Value
+		}
+	}
+
+	return fields
+}
+
+// Field - custom field name of the entry.
+func (e *Entry) Field(name string) (Field, bool) {
+	for _, field := range e.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+
+	return Field{}, false
+}
+
+// SetField - add a custom field to the entry, or replace the one with the same name.
+func (e *Entry) SetField(field Field) {
+	e.copyFields()
+
+	for idx := range e.Fields {
+		if e.Fields[idx].Name == field.Name {
+			e.Fields[idx] = field
+
+			return
+		}
+	}
+
+	e.Fields = append(e.Fields, field)
+}
+
+// AddSensitiveField - add a sensitive custom field to the entry (e.g. a hidden field, or a card code, of an imported
+// entry), unless value is empty. Names of custom fields are unique: a number is appended to names already taken
+// (" 2", " 3", etc.), and fields without name are named "Hidden field".
+func (e *Entry) AddSensitiveField(name, value string) {
+	if value == "" {
+		return
+	}
+
+	if name = strings.TrimSpace(name); name == "" {
+		name = "Hidden field"
+	}
+
+	candidate := name
+
+	for i := 2; ; i++ {
+		if _, exists := e.Field(candidate); !exists {
+			break
+		}
+
+		candidate = fmt.Sprintf("%s %d", name, i)
+	}
+
+	e.copyFields()
+	e.Fields = append(e.Fields, Field{Name: candidate, Value: value, Sensitive: true})
+}
+
+// AddOTP - set the one-time password key of the entry (an otpauth:// URI or a base32 secret, e.g. of an imported
+// entry), if it can be used (see `otp.Parse`) and the entry has none yet; otherwise, it is added as a sensitive
+// custom field name (see `AddSensitiveField`), so it is not lost.
+func (e *Entry) AddOTP(name, key string) {
+	if key = strings.TrimSpace(key); key == "" {
+		return
+	}
+
+	if _, err := otp.Parse(key); err == nil && e.OTP == "" {
+		e.OTP = key
+
+		return
+	}
+
+	e.AddSensitiveField(name, key)
+}
+
+// RemoveField - remove custom field name from the entry, and return false if there is no such field.
+func (e *Entry) RemoveField(name string) bool {
+	for idx := range e.Fields {
+		if e.Fields[idx].Name == name {
+			e.Fields = append(append([]Field(nil), e.Fields[:idx]...), e.Fields[idx+1:]...)
+
+			if len(e.Fields) == 0 {
+				e.Fields = nil
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// ClearSensitive - clear the ID, the password, the OTP key, the note and values of sensitive custom fields
+// of the entry and of its previous versions (names of custom fields and attachments are not sensitive, and are kept).
+func (e *Entry) ClearSensitive() {
+	e.copyFields()
+
+	for _, field := range e.sensitiveFields() {
+		*field = ""
+	}
+
+	if e.History != nil {
+		e.History = append([]Revision(nil), e.History...)
+
+		for idx := range e.History {
+			e.History[idx].Entry.ClearSensitive()
+		}
+	}
+}
+
+// copyFields - copy custom fields of the entry before changing their values, so the entry it has been
+// copied from (sharing the same fields) is left as is.
+func (e *Entry) copyFields() {
+	if e.Fields != nil {
+		e.Fields = append([]Field(nil), e.Fields...)
+	}
+}
+
+// associatedData - binds an encrypted field to its entry and name, so it cannot be moved
+// to another entry or field without being detected.
+func (e *Entry) associatedData(field string) string {
+	return strconv.Quote(e.UniqueID) + "/" + field
+}
+
+// Encrypt - encrypt sensitive fields of the entry.
+func (e *Entry) Encrypt(keys encrypt.Keys) error {
+	e.copyFields()
+
+	for name, field := range e.sensitiveFields() {
+		encrypted, err := keys.Encrypt(*field, e.associatedData(name))
+		if err != nil {
+			return err
+		}
+
+		*field = encrypted
+	}
+
+	return nil
+}
+
+// Decrypt - decrypt sensitive fields of the entry.
+func (e *Entry) Decrypt(keys encrypt.Keys) error {
+	e.copyFields()
+
+	for name, field := range e.sensitiveFields() {
+		if *field == "" {
+			continue
+		}
+
+		decrypted, err := keys.Decrypt(*field, e.associatedData(name))
+		if err != nil {
+			return fmt.Errorf("%w: cannot decrypt %s of entry %s: %v", ErrTampered, name, e.UniqueID, err)
+		}
+
+		*field = decrypted
+	}
+
+	return nil
+}
+
+func (e *Entry) isCurrentFormat() bool {
+	for _, field := range e.sensitiveFields() {
+		if *field != "" && !(encrypt.IsEncryptedWithKey(*field) && encrypt.IsCurrentFormat(*field)) {
+			return false
+		}
+	}
+
+	return true
+}
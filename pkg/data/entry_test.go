@@ -0,0 +1,57 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddOTP(t *testing.T) {
+	var entry Entry
+
+	entry.AddOTP("TOTP", " JBSWY3DPEHPK3PXP ")
+	// the entry already has a key
+	entry.AddOTP("TOTP", "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP")
+	entry.AddOTP("Steam", "steam://N0TB4SE32")
+	entry.AddOTP("Empty", "")
+
+	require.Equal(t, Entry{
+		OTP: "JBSWY3DPEHPK3PXP",
+		Fields: []Field{
+			{Name: "TOTP", Value: "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP", Sensitive: true},
+			{Name: "Steam", Value: "steam://N0TB4SE32", Sensitive: true},
+		},
+	}, entry)
+}
+
+func TestAddSensitiveField(t *testing.T) {
+	var entry Entry
+
+	entry.AddSensitiveField("PIN", "1234")
+	entry.AddSensitiveField(" PIN ", "5678")
+	entry.AddSensitiveField("", "s3cr3t")
+	entry.AddSensitiveField("Empty", "")
+
+	require.Equal(t, []Field{
+		{Name: "PIN", Value: "1234", Sensitive: true},
+		{Name: "PIN 2", Value: "5678", Sensitive: true},
+		{Name: "Hidden field", Value: "s3cr3t", Sensitive: true},
+	}, entry.Fields)
+}
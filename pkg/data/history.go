@@ -0,0 +1,136 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"errors"
+	"reflect"
+	"time"
+)
+
+var ErrVersionNotFound = errors.New("version not found in the history of the entry")
+
+// Revision - previous version of an entry, replaced at ReplacedAt (RFC 3339, UTC) by a change of fields Changed
+// (see `Changes`). Entry is kept as it was, with its sensitive data encrypted, but without its own history.
+type Revision struct {
+	Version    int      `json:"version"`
+	ReplacedAt string   `json:"replaced_at"`
+	Changed    []string `json:"changed"`
+	Entry      Entry    `json:"entry"`
+}
+
+// Retention - previous versions kept for each entry: at most Versions, and not older than MaxAge (if not 0).
+type Retention struct {
+	Versions int
+	MaxAge   time.Duration
+}
+
+// Version - version of the entry: versions are numbered from 1, and keep their number when older ones are dropped.
+func (e *Entry) Version() int {
+	if len(e.History) == 0 {
+		return 1
+	}
+
+	return e.History[len(e.History)-1].Version + 1
+}
+
+// Revision - previous version of the entry.
+func (e *Entry) Revision(version int) (Revision, bool) {
+	for _, revision := range e.History {
+		if revision.Version == version {
+			return revision, true
+		}
+	}
+
+	return Revision{}, false
+}
+
+// Record - keep previous (the encrypted entry replaced by this one, at replacedAt, by a change of fields changed)
+// in the history of the entry, then drop versions beyond retention.
+func (e *Entry) Record(previous Entry, changed []string, replacedAt time.Time, retention Retention) {
+	version := previous.Version()
+	history := previous.History
+
+	previous.History = nil
+
+	e.History = append(append([]Revision(nil), history...), Revision{
+		Version:    version,
+		ReplacedAt: replacedAt.UTC().Format(time.RFC3339),
+		Changed:    changed,
+		Entry:      previous,
+	})
+
+	e.PruneHistory(retention, replacedAt)
+}
+
+// PruneHistory - drop previous versions of the entry beyond retention, at now.
+func (e *Entry) PruneHistory(retention Retention, now time.Time) {
+	var kept []Revision
+
+	for _, revision := range e.History {
+		if retention.MaxAge > 0 {
+			replacedAt, err := time.Parse(time.RFC3339, revision.ReplacedAt)
+			if err == nil && now.Sub(replacedAt) > retention.MaxAge {
+				continue
+			}
+		}
+
+		kept = append(kept, revision)
+	}
+
+	if len(kept) > retention.Versions {
+		kept = kept[len(kept)-retention.Versions:]
+	}
+
+	if len(kept) == 0 {
+		kept = nil
+	}
+
+	e.History = kept
+}
+
+// Changes - names of fields (as in profile files) that differ between previous and current, both decrypted.
+func Changes(previous, current Entry) []string {
+	fields := []struct {
+		name              string
+		previous, current interface{}
+	}{
+		{"unique_id", previous.UniqueID, current.UniqueID},
+		{"type", previous.Type, current.Type},
+		{"description", previous.Description, current.Description},
+		{"tags", previous.Tags, current.Tags},
+		{"site", previous.Site, current.Site},
+		{"id", previous.ID, current.ID},
+		{"password", previous.Password, current.Password},
+		{"policy", previous.Policy, current.Policy},
+		{"fields", previous.Fields, current.Fields},
+		{"otp", previous.OTP, current.OTP},
+		{"note", previous.Note, current.Note},
+		{"attachments", previous.Attachments, current.Attachments},
+	}
+
+	var changed []string
+
+	for _, field := range fields {
+		if !reflect.DeepEqual(field.previous, field.current) {
+			changed = append(changed, field.name)
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,81 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+	start := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+	retention := Retention{Versions: 2, MaxAge: 30 * 24 * time.Hour}
+
+	entry := Entry{UniqueID: "id1", Password: "v1"}
+	require.Equal(t, 1, entry.Version())
+
+	for version := 2; version <= 4; version++ {
+		previous := entry
+		entry.Password = "v" + strconv.Itoa(version)
+		entry.Record(previous, []string{"password"}, start.AddDate(0, 0, version), retention)
+	}
+
+	// only the last 2 previous versions are kept, with their number
+	require.Equal(t, 4, entry.Version())
+	require.Len(t, entry.History, 2)
+	require.Equal(t, 2, entry.History[0].Version)
+	require.Equal(t, "v2", entry.History[0].Entry.Password)
+	require.Equal(t, "2022-06-04T12:00:00Z", entry.History[0].ReplacedAt)
+	require.Nil(t, entry.History[1].Entry.History)
+
+	_, ok := entry.Revision(1)
+	require.False(t, ok)
+
+	revision, ok := entry.Revision(3)
+	require.True(t, ok)
+	require.Equal(t, "v3", revision.Entry.Password)
+
+	entry.PruneHistory(retention, start.AddDate(0, 0, 34))
+	require.Len(t, entry.History, 1)
+	require.Equal(t, 3, entry.History[0].Version)
+
+	entry.PruneHistory(Retention{Versions: 0}, start)
+	require.Nil(t, entry.History)
+	require.Equal(t, 1, entry.Version())
+}
+
+func TestChanges(t *testing.T) {
+	previous := Entry{
+		UniqueID: "id1", Site: "https://mysupersite.pasuman", Password: "p4$$w0rd!",
+		Fields: []Field{{Name: "PIN", Value: "1234", Sensitive: true}},
+	}
+
+	require.Nil(t, Changes(previous, previous))
+
+	current := previous
+	current.Password = "n€wp4$$w0rd!"
+	current.Policy = "bank"
+	current.SetField(Field{Name: "PIN", Value: "5678", Sensitive: true})
+	current.SetAttachment(Attachment{Name: "codes.pdf", Content: []byte("%PDF")})
+
+	require.Equal(t, []string{"password", "policy", "fields", "attachments"}, Changes(previous, current))
+	require.Equal(t, "1234", previous.Fields[0].Value)
+}
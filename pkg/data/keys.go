@@ -0,0 +1,198 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/norbjd/pasuman/pkg/encrypt"
+	"github.com/norbjd/pasuman/pkg/util"
+)
+
+// unlockedKeys - keys already unlocked by this process, by wrapped data key,
+// so the key is derived from the master password only once.
+var unlockedKeys = map[string]encrypt.Keys{}
+
+// agentTokens - tokens standing for the master password of data keys given by the agent, by wrapped data key
+// (see `RememberKeys`).
+var agentTokens = map[string]string{}
+
+// ForgetKeys - forget keys unlocked by this process: the master password will be needed again.
+func ForgetKeys() {
+	unlockedKeys = map[string]encrypt.Keys{}
+	agentTokens = map[string]string{}
+}
+
+// RememberKeys - use the data key unlocked by another process (see `agent.Get`) for the data key wrappedDataKey,
+// so the master password is not needed to unlock it. The token returned is given to `Unlock` instead of the
+// master password, which is not known: values still encrypted with it cannot be decrypted (see `UpgradeEncryption`).
+func RememberKeys(wrappedDataKey string, dataKey []byte) (string, error) {
+	token, err := encrypt.NewKey()
+	if err != nil {
+		return "", err
+	}
+
+	unlockedKeys[wrappedDataKey] = encrypt.Keys{DataKey: dataKey}
+	agentTokens[wrappedDataKey] = base64.StdEncoding.EncodeToString(token)
+
+	return agentTokens[wrappedDataKey], nil
+}
+
+// Unlock - unwrap the data key with the master password, and return keys to encrypt and decrypt entries.
+// If there is no data key yet, a new one is generated: data must then be written with `ToFile` to keep it.
+func (data *Data) Unlock(masterPassword string) (encrypt.Keys, error) {
+	if masterPassword == "" {
+		return encrypt.Keys{}, util.ErrMasterPasswordMustNotBeEmpty
+	}
+
+	if data.DataKey == "" {
+		dataKey, err := encrypt.NewKey()
+		if err != nil {
+			return encrypt.Keys{}, err
+		}
+
+		return data.wrap(encrypt.Keys{MasterPassword: masterPassword, DataKey: dataKey})
+	}
+
+	if keys, ok := unlockedKeys[data.DataKey]; ok &&
+		subtle.ConstantTimeCompare([]byte(keys.MasterPassword), []byte(masterPassword)) == 1 {
+		return keys, data.verify(keys)
+	}
+
+	if token, ok := agentTokens[data.DataKey]; ok &&
+		subtle.ConstantTimeCompare([]byte(token), []byte(masterPassword)) == 1 {
+		keys := unlockedKeys[data.DataKey]
+
+		return keys, data.verify(keys)
+	}
+
+	base64DataKey, err := encrypt.Decrypt(masterPassword, data.DataKey)
+	if err != nil {
+		return encrypt.Keys{}, fmt.Errorf("%w: cannot unwrap data key: %v", util.ErrMasterPasswordIncorrect, err)
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(base64DataKey)
+	if err != nil {
+		return encrypt.Keys{}, err
+	}
+
+	keys := encrypt.Keys{MasterPassword: masterPassword, DataKey: dataKey}
+	unlockedKeys[data.DataKey] = keys
+
+	return keys, data.verify(keys)
+}
+
+// Rewrap - wrap the data key with a new master password, and return the new keys. Entries are not
+// re-encrypted: call `UpgradeEncryption` before, so no entry is encrypted with the old master password.
+func (data *Data) Rewrap(keys encrypt.Keys, newMasterPassword string) (encrypt.Keys, error) {
+	if newMasterPassword == "" {
+		return encrypt.Keys{}, util.ErrMasterPasswordMustNotBeEmpty
+	}
+
+	return data.wrap(encrypt.Keys{MasterPassword: newMasterPassword, DataKey: keys.DataKey})
+}
+
+func (data *Data) wrap(keys encrypt.Keys) (encrypt.Keys, error) {
+	if keys.MasterPassword == "" {
+		return encrypt.Keys{}, encrypt.ErrMasterPasswordUnknown
+	}
+
+	wrappedDataKey, err := encrypt.Encrypt(keys.MasterPassword, base64.StdEncoding.EncodeToString(keys.DataKey))
+	if err != nil {
+		return encrypt.Keys{}, err
+	}
+
+	data.DataKey = wrappedDataKey
+	unlockedKeys[wrappedDataKey] = keys
+
+	return keys, nil
+}
+
+// UpgradeEncryption - re-encrypt sensitive data (of entries, removed or not, and of their previous versions)
+// encrypted by older versions of pasuman (directly with the master password, or not bound to its entry), and re-wrap
+// the data key if it has been wrapped by an older version, so everything is written in the current format by `ToFile`.
+func (data *Data) UpgradeEncryption(keys encrypt.Keys) error {
+	if !encrypt.IsCurrentFormat(data.DataKey) {
+		if _, err := data.wrap(keys); err != nil {
+			return err
+		}
+	}
+
+	for idx := range data.Entries {
+		if err := data.Entries[idx].upgradeEncryption(keys); err != nil {
+			return err
+		}
+	}
+
+	for idx := range data.Trash {
+		if err := data.Trash[idx].Entry.upgradeEncryption(keys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// NeedsEncryptionUpgrade - whether `UpgradeEncryption` would change data: values encrypted with the master
+// password can only be read once upgraded, if keys are given by the agent.
+func (data *Data) NeedsEncryptionUpgrade() bool {
+	if !encrypt.IsCurrentFormat(data.DataKey) {
+		return true
+	}
+
+	entries := append([]Entry{}, data.Entries...)
+	for _, trashed := range data.Trash {
+		entries = append(entries, trashed.Entry)
+	}
+
+	for len(entries) > 0 {
+		entry := entries[0]
+		entries = entries[1:]
+
+		if !entry.isCurrentFormat() {
+			return true
+		}
+
+		for _, revision := range entry.History {
+			entries = append(entries, revision.Entry)
+		}
+	}
+
+	return false
+}
+
+// upgradeEncryption - re-encrypt sensitive data of the entry and of its previous versions, if necessary.
+func (e *Entry) upgradeEncryption(keys encrypt.Keys) error {
+	for idx := range e.History {
+		if err := e.History[idx].Entry.upgradeEncryption(keys); err != nil {
+			return err
+		}
+	}
+
+	if e.isCurrentFormat() {
+		return nil
+	}
+
+	if err := e.Decrypt(keys); err != nil {
+		return err
+	}
+
+	return e.Encrypt(keys)
+}
@@ -0,0 +1,93 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/norbjd/pasuman/pkg/encrypt"
+	"github.com/norbjd/pasuman/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnlockRewrap(t *testing.T) {
+	var d Data
+
+	keys, err := d.Unlock("pass")
+	require.NoError(t, err)
+	require.NotEmpty(t, d.DataKey)
+
+	encrypted, err := keys.Encrypt("p4$$w0rd!", "")
+	require.NoError(t, err)
+	require.True(t, encrypt.IsEncryptedWithKey(encrypted))
+
+	// forget unlocked keys, to really unwrap the data key
+	ForgetKeys()
+
+	_, err = d.Unlock("wrong")
+	require.ErrorIs(t, err, util.ErrMasterPasswordIncorrect)
+
+	newKeys, err := d.Rewrap(keys, "newpass")
+	require.NoError(t, err)
+
+	ForgetKeys()
+
+	_, err = d.Unlock("pass")
+	require.ErrorIs(t, err, util.ErrMasterPasswordIncorrect)
+
+	ForgetKeys()
+
+	unlocked, err := d.Unlock("newpass")
+	require.NoError(t, err)
+	require.Equal(t, newKeys, unlocked)
+
+	// values encrypted with the data key are still readable
+	decrypted, err := unlocked.Decrypt(encrypted, "")
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", decrypted)
+}
+
+func TestUpgradeEncryption(t *testing.T) {
+	var d Data
+	require.NoError(t, d.FromFile(filepath.Join("testdata", "v0.json")))
+
+	legacyPassword := d.Entries[0].Password
+	require.True(t, encrypt.IsLegacy(legacyPassword))
+
+	// keep only one entry to speed up the test
+	d.Entries = d.Entries[:1]
+
+	keys, err := d.Unlock("pass")
+	require.NoError(t, err)
+
+	require.NoError(t, d.UpgradeEncryption(keys))
+	require.True(t, encrypt.IsEncryptedWithKey(d.Entries[0].ID))
+	require.True(t, encrypt.IsEncryptedWithKey(d.Entries[0].Password))
+
+	require.True(t, d.Entries[0].isCurrentFormat())
+
+	entry := d.Entries[0]
+	require.NoError(t, entry.Decrypt(keys))
+	require.Equal(t, "p4$$w0rd!", entry.Password)
+
+	// nothing to do anymore
+	upgradedPassword := d.Entries[0].Password
+	require.NoError(t, d.UpgradeEncryption(keys))
+	require.Equal(t, upgradedPassword, d.Entries[0].Password)
+}
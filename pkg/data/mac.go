@@ -0,0 +1,110 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/norbjd/pasuman/pkg/encrypt"
+)
+
+const macKey = "mac"
+
+var (
+	ErrTampered = errors.New("profile file has been modified outside of pasuman: " +
+		"do not trust it, and restore a backup")
+
+	errMasterPasswordRequired = errors.New("master password is required to write the profile file")
+)
+
+// macContent - content of a profile file authenticated by its MAC: the whole file (as a JSON object,
+// with sorted keys and without indentation), except the MAC itself.
+func macContent(raw map[string]interface{}) ([]byte, error) {
+	withoutMAC := make(map[string]interface{}, len(raw))
+
+	for key, value := range raw {
+		if key != macKey {
+			withoutMAC[key] = value
+		}
+	}
+
+	return json.Marshal(withoutMAC)
+}
+
+// macRequired - files with a data key wrapped in the current format are always written with a MAC:
+// so, removing the MAC is detected too.
+func (data *Data) macRequired() bool {
+	return data.DataKey != "" && encrypt.IsCurrentFormat(data.DataKey)
+}
+
+// verify - check the MAC of the file read, once the keys are known.
+func (data *Data) verify(keys encrypt.Keys) error {
+	if data.macContent == nil {
+		return nil
+	}
+
+	if data.MAC == "" {
+		if data.macRequired() {
+			return fmt.Errorf("%w: MAC is missing", ErrTampered)
+		}
+
+		return nil
+	}
+
+	if !keys.VerifyMAC(data.macContent, data.MAC) {
+		return fmt.Errorf("%w: MAC mismatch", ErrTampered)
+	}
+
+	return nil
+}
+
+// authenticate - set the MAC of data, as it will be written.
+func (data *Data) authenticate() error {
+	data.MAC = ""
+
+	keys, ok := unlockedKeys[data.DataKey]
+	if !ok {
+		if data.macRequired() {
+			return errMasterPasswordRequired
+		}
+
+		return nil
+	}
+
+	byteContents, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+
+	if err := json.Unmarshal(byteContents, &raw); err != nil {
+		return err
+	}
+
+	content, err := macContent(raw)
+	if err != nil {
+		return err
+	}
+
+	data.MAC = keys.MAC(content)
+
+	return nil
+}
@@ -0,0 +1,182 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMAC(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.json")
+
+	d := Data{}
+
+	keys, err := d.Unlock("pass")
+	require.NoError(t, err)
+
+	d.Entries = []Entry{
+		{UniqueID: "id1", Site: "https://mysupersite.pasuman", ID: "myId", Password: "p4$$w0rd!"},
+		{UniqueID: "id2", Site: "https://anothersite.pasuman", ID: "otherId", Password: "t0ps3cr3t!"},
+	}
+
+	for idx := range d.Entries {
+		require.NoError(t, d.Entries[idx].Encrypt(keys))
+	}
+
+	require.NoError(t, d.ToFile(file))
+
+	byteContents, err := os.ReadFile(file)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		tamper func(raw map[string]interface{})
+	}{
+		{
+			name: "modified site",
+			tamper: func(raw map[string]interface{}) {
+				entries := raw["entries"].([]interface{})
+				entries[0].(map[string]interface{})["site"] = "https://phishing.pasuman"
+			},
+		},
+		{
+			name: "reordered entries",
+			tamper: func(raw map[string]interface{}) {
+				entries := raw["entries"].([]interface{})
+				entries[0], entries[1] = entries[1], entries[0]
+			},
+		},
+		{
+			name: "removed entry",
+			tamper: func(raw map[string]interface{}) {
+				raw["entries"] = raw["entries"].([]interface{})[1:]
+			},
+		},
+		{
+			name: "removed MAC",
+			tamper: func(raw map[string]interface{}) {
+				delete(raw, "mac")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var raw map[string]interface{}
+		require.NoError(t, json.Unmarshal(byteContents, &raw), tt.name)
+
+		tt.tamper(raw)
+
+		tampered, err := json.MarshalIndent(raw, "", "  ")
+		require.NoError(t, err, tt.name)
+
+		tamperedFile := filepath.Join(t.TempDir(), "default.json")
+		require.NoError(t, os.WriteFile(tamperedFile, tampered, 0o600), tt.name)
+
+		// without the master password, the MAC cannot be checked
+		ForgetKeys()
+
+		var notVerified Data
+		require.NoError(t, notVerified.FromFile(tamperedFile), tt.name)
+
+		var opened Data
+		_, err = opened.Open(tamperedFile, "pass")
+		require.ErrorIs(t, err, ErrTampered, tt.name)
+
+		// keys are known: checked by `FromFile` too
+		var read Data
+		require.ErrorIs(t, read.FromFile(tamperedFile), ErrTampered, tt.name)
+	}
+
+	// untouched file
+	ForgetKeys()
+
+	var opened Data
+	_, err = opened.Open(file, "pass")
+	require.NoError(t, err)
+}
+
+func TestEntryBoundToUniqueIDAndField(t *testing.T) {
+	var d Data
+
+	keys, err := d.Unlock("pass")
+	require.NoError(t, err)
+
+	entry1 := Entry{UniqueID: "id1", ID: "myId", Password: "p4$$w0rd!"}
+	require.NoError(t, entry1.Encrypt(keys))
+
+	entry2 := Entry{UniqueID: "id2", ID: "otherId", Password: "t0ps3cr3t!"}
+	require.NoError(t, entry2.Encrypt(keys))
+
+	// password copied to another entry
+	swapped := entry2
+	swapped.Password = entry1.Password
+	require.ErrorIs(t, swapped.Decrypt(keys), ErrTampered)
+
+	// ID and password swapped
+	swapped = entry1
+	swapped.ID, swapped.Password = entry1.Password, entry1.ID
+	require.ErrorIs(t, swapped.Decrypt(keys), ErrTampered)
+
+	decrypted := entry1
+	require.NoError(t, decrypted.Decrypt(keys))
+	require.Equal(t, "myId", decrypted.ID)
+	require.Equal(t, "p4$$w0rd!", decrypted.Password)
+}
+
+func TestCustomFieldsBoundToName(t *testing.T) {
+	var d Data
+
+	keys, err := d.Unlock("pass")
+	require.NoError(t, err)
+
+	entry := Entry{UniqueID: "id1", Password: "p4$$w0rd!", Fields: []Field{
+		{Name: "PIN", Value: "1234", Sensitive: true},
+		{Name: "password", Value: "not the password", Sensitive: true},
+		{Name: "Account number", Value: "FR76-1234"},
+	}}
+	require.NoError(t, entry.Encrypt(keys))
+	require.Equal(t, "FR76-1234", entry.Fields[2].Value)
+
+	// values of custom fields swapped
+	swapped := entry
+	swapped.Fields = []Field{entry.Fields[1], entry.Fields[0], entry.Fields[2]}
+	swapped.Fields[0].Name, swapped.Fields[1].Name = "PIN", "password"
+	require.ErrorIs(t, swapped.Decrypt(keys), ErrTampered)
+
+	// custom field named as a field of the entry
+	swapped = entry
+	swapped.Password = entry.Fields[1].Value
+	require.ErrorIs(t, swapped.Decrypt(keys), ErrTampered)
+
+	decrypted := entry
+	require.NoError(t, decrypted.Decrypt(keys))
+	require.Equal(t, "p4$$w0rd!", decrypted.Password)
+	require.Equal(t, []Field{
+		{Name: "PIN", Value: "1234", Sensitive: true},
+		{Name: "password", Value: "not the password", Sensitive: true},
+		{Name: "Account number", Value: "FR76-1234"},
+	}, decrypted.Fields)
+
+	// entries copied from the encrypted one are left as is
+	require.NotEqual(t, "1234", entry.Fields[0].Value)
+}
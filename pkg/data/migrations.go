@@ -0,0 +1,151 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"errors"
+	"fmt"
+)
+
+const versionKey = "version"
+
+var (
+	ErrUnsupportedVersion = errors.New("unsupported profile file version: upgrade pasuman")
+
+	errInvalidVersion = errors.New("invalid profile file version")
+)
+
+// Migration - upgrades a profile file from version `From` to version `From+1`.
+// Migrate works on the raw JSON object of the profile file, because the `Data` struct
+// only describes the current version; it must not update the version itself.
+type Migration struct {
+	From        int
+	Description string
+	Migrate     func(raw map[string]interface{}) error
+}
+
+// migrations - migrations[i] upgrades a profile file from version i to version i+1.
+// When the format of profile files changes (including new fields, that would be lost
+// if the file is written by an older pasuman), add a migration at the end
+// and a sample file of the new version in testdata (see `TestMigrateGolden`).
+var migrations = []Migration{
+	{
+		From:        0,
+		Description: "add format version to the profile file",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From: 1,
+		// legacy encrypted values are still readable, and are re-encrypted by `UpgradeEncryption`
+		Description: "record encryption algorithm and key derivation parameters in encrypted values",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From: 2,
+		// the data key is generated, and entries re-encrypted, by `Unlock` and `UpgradeEncryption`
+		Description: "encrypt entries with a data key, wrapped by the master password",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        3,
+		Description: "allow to seal entries (encrypt them as a whole)",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From: 4,
+		// values are bound to their entry, and the MAC is computed, by `UpgradeEncryption` and `ToFile`
+		Description: "bind encrypted values to their entry, and authenticate the profile file",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        5,
+		Description: "add custom fields to entries",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        6,
+		Description: "add one-time password keys to entries",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        7,
+		Description: "add secure notes and attachments to entries",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        8,
+		Description: "keep previous versions of entries",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        9,
+		Description: "keep removed entries in a trash",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+	{
+		From:        10,
+		Description: "bind entries to password policies",
+		Migrate:     func(raw map[string]interface{}) error { return nil },
+	},
+}
+
+// CurrentVersion - version of profile files written by this version of pasuman.
+var CurrentVersion = len(migrations)
+
+// Migrate - upgrade raw (a profile file JSON object) in place to the current version,
+// and return the migrations applied.
+func Migrate(raw map[string]interface{}) ([]Migration, error) {
+	version, err := Version(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("%w: file has version %d, latest version supported is %d",
+			ErrUnsupportedVersion, version, CurrentVersion)
+	}
+
+	applied := migrations[version:]
+
+	for _, migration := range applied {
+		if err := migration.Migrate(raw); err != nil {
+			return nil, fmt.Errorf("cannot migrate from version %d: %w", migration.From, err)
+		}
+
+		raw[versionKey] = migration.From + 1
+	}
+
+	return applied, nil
+}
+
+// Version - version of raw (a profile file JSON object). Files written before versioning
+// was introduced have no version, they are considered as version 0.
+func Version(raw map[string]interface{}) (int, error) {
+	rawVersion, ok := raw[versionKey]
+	if !ok {
+		return 0, nil
+	}
+
+	// encoding/json decodes numbers as float64
+	version, ok := rawVersion.(float64)
+	if !ok || version < 0 || version != float64(int(version)) {
+		return 0, fmt.Errorf("%w: %v", errInvalidVersion, rawVersion)
+	}
+
+	return int(version), nil
+}
@@ -0,0 +1,146 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/norbjd/pasuman/pkg/encrypt"
+)
+
+const (
+	sealedEntriesAssociatedData = "sealed_entries"
+	sealedTrashAssociatedData   = "sealed_trash"
+)
+
+var ErrSealed = errors.New("profile is sealed: master password required")
+
+// Open - read data from file, and unlock it with the master password. Contrary to `FromFile`,
+// entries of sealed profiles are always read.
+func (data *Data) Open(file string, masterPassword string) (encrypt.Keys, error) {
+	if err := data.FromFile(file); err != nil && !errors.Is(err, ErrSealed) {
+		return encrypt.Keys{}, err
+	}
+
+	keys, err := data.Unlock(masterPassword)
+	if err != nil {
+		return encrypt.Keys{}, err
+	}
+
+	return keys, data.unseal()
+}
+
+// unseal - decrypt sealed entries and trash, if keys have already been unlocked by this process (see `Unlock`).
+func (data *Data) unseal() error {
+	if !data.Sealed {
+		return nil
+	}
+
+	keys, ok := unlockedKeys[data.DataKey]
+	if !ok {
+		data.locked = true
+
+		return ErrSealed
+	}
+
+	if err := data.verify(keys); err != nil {
+		return err
+	}
+
+	entries, err := keys.Decrypt(data.SealedEntries, sealedEntriesAssociatedData)
+	if err != nil {
+		return err
+	}
+
+	data.Entries = nil
+
+	if err := json.Unmarshal([]byte(entries), &data.Entries); err != nil {
+		return err
+	}
+
+	data.Trash = nil
+
+	if data.SealedTrash != "" {
+		trash, err := keys.Decrypt(data.SealedTrash, sealedTrashAssociatedData)
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal([]byte(trash), &data.Trash); err != nil {
+			return err
+		}
+	}
+
+	data.locked = false
+
+	if data.readBlobs == nil {
+		data.readBlobs = map[string]bool{}
+	}
+
+	for blob := range data.blobs() {
+		data.readBlobs[blob] = true
+	}
+
+	return nil
+}
+
+// seal - return data as it must be written: if entries must be sealed, they are encrypted
+// with the data key, and removed from Entries (so is the trash, if not empty).
+func (data *Data) seal() (Data, error) {
+	if !data.Sealed {
+		unsealed := *data
+		unsealed.SealedEntries = ""
+		unsealed.SealedTrash = ""
+
+		return unsealed, nil
+	}
+
+	keys, ok := unlockedKeys[data.DataKey]
+	if data.locked || !ok {
+		return Data{}, ErrSealed
+	}
+
+	entries, err := json.Marshal(data.Entries)
+	if err != nil {
+		return Data{}, err
+	}
+
+	sealed := *data
+	sealed.Entries = nil
+
+	if sealed.SealedEntries, err = keys.Encrypt(string(entries), sealedEntriesAssociatedData); err != nil {
+		return Data{}, err
+	}
+
+	sealed.Trash = nil
+	sealed.SealedTrash = ""
+
+	if len(data.Trash) > 0 {
+		trash, err := json.Marshal(data.Trash)
+		if err != nil {
+			return Data{}, err
+		}
+
+		if sealed.SealedTrash, err = keys.Encrypt(string(trash), sealedTrashAssociatedData); err != nil {
+			return Data{}, err
+		}
+	}
+
+	return sealed, nil
+}
@@ -0,0 +1,67 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealed(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "default.json")
+
+	entries := []Entry{{UniqueID: "id1", Description: "A desc", Site: "https://mysupersite.pasuman"}}
+	trash := []TrashedEntry{{DeletedAt: "2022-06-01T12:00:00Z", Entry: Entry{UniqueID: "id2", Description: "Removed"}}}
+
+	d := Data{Sealed: true, Entries: entries, Trash: trash}
+
+	_, err := d.Unlock("pass")
+	require.NoError(t, err)
+	require.NoError(t, d.ToFile(file))
+
+	byteContents, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.NotContains(t, string(byteContents), "id1")
+	require.NotContains(t, string(byteContents), "A desc")
+	require.NotContains(t, string(byteContents), "mysupersite")
+	require.NotContains(t, string(byteContents), "Removed")
+
+	// keys are still unlocked
+	var unsealed Data
+	require.NoError(t, unsealed.FromFile(file))
+	require.Equal(t, entries, unsealed.Entries)
+	require.Equal(t, trash, unsealed.Trash)
+
+	ForgetKeys()
+
+	var locked Data
+	require.ErrorIs(t, locked.FromFile(file), ErrSealed)
+	require.Equal(t, d.DataKey, locked.DataKey)
+	require.Empty(t, locked.Entries)
+	require.Empty(t, locked.Trash)
+	require.ErrorIs(t, locked.ToFile(file), ErrSealed)
+
+	var opened Data
+	_, err = opened.Open(file, "pass")
+	require.NoError(t, err)
+	require.Equal(t, entries, opened.Entries)
+	require.Equal(t, trash, opened.Trash)
+}
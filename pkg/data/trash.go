@@ -0,0 +1,97 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrNotInTrash = errors.New("entry not found in the trash")
+
+// TrashedEntry - entry removed at DeletedAt (RFC 3339, UTC), kept as it was (encrypted, with its history)
+// until it is restored or purged.
+type TrashedEntry struct {
+	DeletedAt string `json:"deleted_at"`
+	Entry     Entry  `json:"entry"`
+}
+
+// MoveToTrash - move the entry uniqueID from entries to the trash, removed at deletedAt. Returns false if there
+// is no such entry.
+func (data *Data) MoveToTrash(uniqueID string, deletedAt time.Time) bool {
+	for idx := range data.Entries {
+		if data.Entries[idx].UniqueID != uniqueID {
+			continue
+		}
+
+		data.Trash = append(data.Trash, TrashedEntry{
+			DeletedAt: deletedAt.UTC().Format(time.RFC3339),
+			Entry:     data.Entries[idx],
+		})
+		data.Entries = append(data.Entries[:idx], data.Entries[idx+1:]...)
+
+		return true
+	}
+
+	return false
+}
+
+// TakeFromTrash - remove the entry uniqueID from the trash and return it, for the caller to add it back to entries.
+// If several entries with this unique ID have been removed, the last one removed is taken.
+func (data *Data) TakeFromTrash(uniqueID string) (TrashedEntry, error) {
+	for idx := len(data.Trash) - 1; idx >= 0; idx-- {
+		if data.Trash[idx].Entry.UniqueID != uniqueID {
+			continue
+		}
+
+		trashed := data.Trash[idx]
+		data.Trash = append(data.Trash[:idx], data.Trash[idx+1:]...)
+
+		if len(data.Trash) == 0 {
+			data.Trash = nil
+		}
+
+		return trashed, nil
+	}
+
+	return TrashedEntry{}, ErrNotInTrash
+}
+
+// PurgeTrash - drop entries removed for longer than retention, at now (none if retention is 0).
+// Returns the number of entries dropped.
+func (data *Data) PurgeTrash(retention time.Duration, now time.Time) int {
+	if retention <= 0 {
+		return 0
+	}
+
+	var kept []TrashedEntry
+
+	for _, trashed := range data.Trash {
+		deletedAt, err := time.Parse(time.RFC3339, trashed.DeletedAt)
+		if err == nil && now.Sub(deletedAt) > retention {
+			continue
+		}
+
+		kept = append(kept, trashed)
+	}
+
+	purged := len(data.Trash) - len(kept)
+	data.Trash = kept
+
+	return purged
+}
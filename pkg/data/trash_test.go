@@ -0,0 +1,62 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package data
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	start := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	d := Data{Entries: []Entry{{UniqueID: "id1", Password: "v1"}, {UniqueID: "id2"}}}
+
+	require.False(t, d.MoveToTrash("id3", start))
+	require.True(t, d.MoveToTrash("id1", start))
+	require.Equal(t, []Entry{{UniqueID: "id2"}}, d.Entries)
+
+	// an entry added again, then removed again, is kept twice
+	d.Entries = append(d.Entries, Entry{UniqueID: "id1", Password: "v2"})
+	require.True(t, d.MoveToTrash("id1", start.AddDate(0, 0, 10)))
+	require.True(t, d.MoveToTrash("id2", start.AddDate(0, 0, 20)))
+	require.Empty(t, d.Entries)
+	require.Len(t, d.Trash, 3)
+	require.Equal(t, "2022-06-01T12:00:00Z", d.Trash[0].DeletedAt)
+
+	// the last one removed is taken first
+	trashed, err := d.TakeFromTrash("id1")
+	require.NoError(t, err)
+	require.Equal(t, "v2", trashed.Entry.Password)
+	require.Equal(t, "2022-06-11T12:00:00Z", trashed.DeletedAt)
+
+	_, err = d.TakeFromTrash("id3")
+	require.ErrorIs(t, err, ErrNotInTrash)
+
+	require.Equal(t, 0, d.PurgeTrash(0, start.AddDate(1, 0, 0)))
+	require.Equal(t, 0, d.PurgeTrash(30*24*time.Hour, start.AddDate(0, 0, 30)))
+	require.Equal(t, 1, d.PurgeTrash(30*24*time.Hour, start.AddDate(0, 0, 31)))
+	require.Len(t, d.Trash, 1)
+	require.Equal(t, "id2", d.Trash[0].Entry.UniqueID)
+
+	_, err = d.TakeFromTrash("id2")
+	require.NoError(t, err)
+	require.Nil(t, d.Trash)
+}
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/norbjd/pasuman/pkg/constants"
@@ -34,8 +35,23 @@ import (
 )
 
 const (
-	encryptedMessageStringSeparator   = "*"
-	encryptedMessageSplitStringLength = 3
+	// legacy format: <salt>*<nonce>*<message>
+	legacyEncryptedMessageStringSeparator   = "*"
+	legacyEncryptedMessageSplitStringLength = 3
+
+	// current format: $pasuman$v=<version>$alg=<algorithm>$kdf=<kdf>,<kdf params>$<salt>$<nonce>$<message>
+	encryptedMessageStringPrefix      = "$pasuman$"
+	encryptedMessageStringSeparator   = "$"
+	encryptedMessageSplitStringLength = 8
+
+	legacyVersion = 0
+	// since version 2, associated data (if any) is authenticated along with the message
+	associatedDataVersion = 2
+	currentVersion        = 2
+
+	algorithmAES256GCM = "aes-256-gcm"
+	kdfArgon2id        = "argon2id"
+	kdfNone            = "none"
 
 	saltLength  = 64
 	nonceLength = 12
@@ -49,27 +65,173 @@ const (
 	paddingSeparator                 = '\x00'
 )
 
-var errEncryptedMessageStringInvalid = errors.New("encrypted message string is invalid")
+var (
+	errEncryptedMessageStringInvalid = errors.New("encrypted message string is invalid")
+	errUnsupportedVersion            = errors.New("unsupported encrypted message version: upgrade pasuman")
+	errUnsupportedAlgorithm          = errors.New("unsupported encryption algorithm")
+	errUnsupportedKDF                = errors.New("unsupported key derivation function")
+	errInvalidKDFParams              = errors.New("invalid key derivation function parameters")
+	errInvalidKey                    = errors.New("invalid key")
+	errKeyRequired                   = errors.New("message is encrypted with a key, not with the master password")
+	errMasterPasswordRequired        = errors.New("message is encrypted with the master password, not with a key")
+)
+
+// KDF - key derivation function used to derive the encryption key from the master password,
+// with its parameters.
+type KDF struct {
+	Name    string
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// DefaultKDF - KDF used to encrypt new messages. It can be changed without breaking existing
+// encrypted messages, because each message records the KDF and parameters used to encrypt it.
+var DefaultKDF = KDF{
+	Name:    kdfArgon2id,
+	Time:    argon2Time,
+	Memory:  argon2Memory,
+	Threads: argon2Threads,
+}
+
+// legacyKDF - KDF used by messages encrypted in the legacy format, that does not record it.
+var legacyKDF = KDF{
+	Name:    kdfArgon2id,
+	Time:    16,
+	Memory:  256 * 1024,
+	Threads: 4,
+}
+
+// noKDF - messages encrypted by `EncryptWithKey` are encrypted with the key directly.
+var noKDF = KDF{Name: kdfNone}
+
+func (k KDF) String() string {
+	if k.Name == kdfNone {
+		return kdfNone
+	}
+
+	return fmt.Sprintf("%s,t=%d,m=%d,p=%d", k.Name, k.Time, k.Memory, k.Threads)
+}
+
+func (k *KDF) FromString(s string) error {
+	if s == kdfNone {
+		*k = noKDF
+
+		return nil
+	}
+
+	split := strings.Split(s, ",")
+
+	if split[0] != kdfArgon2id {
+		return fmt.Errorf("%w: %s", errUnsupportedKDF, split[0])
+	}
+
+	k.Name = split[0]
+
+	params := map[string]uint64{}
+
+	for _, param := range split[1:] {
+		name, value, found := strings.Cut(param, "=")
+		if !found {
+			return fmt.Errorf("%w: %s", errInvalidKDFParams, s)
+		}
+
+		parsedValue, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errInvalidKDFParams, s)
+		}
+
+		params[name] = parsedValue
+	}
+
+	time, memory, threads := params["t"], params["m"], params["p"]
+	if len(params) != 3 || time == 0 || threads == 0 || threads > 255 || memory < 8*threads {
+		return fmt.Errorf("%w: %s", errInvalidKDFParams, s)
+	}
+
+	k.Time = uint32(time)
+	k.Memory = uint32(memory)
+	k.Threads = uint8(threads)
+
+	return nil
+}
+
+func (k KDF) key(masterPassword string, salt []byte) []byte {
+	return argon2.IDKey([]byte(masterPassword), salt, k.Time, k.Memory, k.Threads, argon2KeyLength)
+}
 
 type EncryptedMessage struct {
+	version       int
+	algorithm     string
+	kdf           KDF
 	base64Salt    string
 	base64Nonce   string
 	base64Message string
 }
 
 func (e *EncryptedMessage) String() string {
-	return fmt.Sprintf("%s%s%s%s%s",
-		e.base64Salt, encryptedMessageStringSeparator,
-		e.base64Nonce, encryptedMessageStringSeparator,
-		e.base64Message)
+	if e.version == legacyVersion {
+		return strings.Join([]string{e.base64Salt, e.base64Nonce, e.base64Message},
+			legacyEncryptedMessageStringSeparator)
+	}
+
+	return encryptedMessageStringPrefix + strings.Join([]string{
+		fmt.Sprintf("v=%d", e.version),
+		"alg=" + e.algorithm,
+		"kdf=" + e.kdf.String(),
+		e.base64Salt,
+		e.base64Nonce,
+		e.base64Message,
+	}, encryptedMessageStringSeparator)
 }
 
 func (e *EncryptedMessage) FromString(s string) error {
+	if !strings.HasPrefix(s, encryptedMessageStringPrefix) {
+		return e.fromLegacyString(s)
+	}
+
 	split := strings.Split(s, encryptedMessageStringSeparator)
-	if len(split) != encryptedMessageSplitStringLength {
+	if len(split) < 3 {
+		return fmt.Errorf("%w: %s", errEncryptedMessageStringInvalid, s)
+	}
+
+	version, err := strconv.Atoi(strings.TrimPrefix(split[2], "v="))
+	if err != nil || !strings.HasPrefix(split[2], "v=") {
+		return fmt.Errorf("%w: %s", errEncryptedMessageStringInvalid, s)
+	}
+
+	if version < 1 || version > currentVersion {
+		return fmt.Errorf("%w: %d", errUnsupportedVersion, version)
+	}
+
+	if len(split) != encryptedMessageSplitStringLength ||
+		!strings.HasPrefix(split[3], "alg=") || !strings.HasPrefix(split[4], "kdf=") {
+		return fmt.Errorf("%w: %s", errEncryptedMessageStringInvalid, s)
+	}
+
+	e.version = version
+	e.algorithm = strings.TrimPrefix(split[3], "alg=")
+
+	if err := e.kdf.FromString(strings.TrimPrefix(split[4], "kdf=")); err != nil {
+		return err
+	}
+
+	e.base64Salt = split[5]
+	e.base64Nonce = split[6]
+	e.base64Message = split[7]
+
+	return nil
+}
+
+func (e *EncryptedMessage) fromLegacyString(s string) error {
+	split := strings.Split(s, legacyEncryptedMessageStringSeparator)
+	if len(split) != legacyEncryptedMessageSplitStringLength {
 		return fmt.Errorf("%w: %s", errEncryptedMessageStringInvalid, s)
 	}
 
+	e.version = legacyVersion
+	e.algorithm = algorithmAES256GCM
+	e.kdf = legacyKDF
 	e.base64Salt = split[0]
 	e.base64Nonce = split[1]
 	e.base64Message = split[2]
@@ -77,8 +239,41 @@ func (e *EncryptedMessage) FromString(s string) error {
 	return nil
 }
 
+// IsLegacy - whether s has been encrypted in the legacy format.
+func IsLegacy(s string) bool {
+	return !strings.HasPrefix(s, encryptedMessageStringPrefix)
+}
+
+// IsEncryptedWithKey - whether s has been encrypted by `EncryptWithKey`.
+func IsEncryptedWithKey(s string) bool {
+	var encryptedMessage EncryptedMessage
+	if err := encryptedMessage.FromString(s); err != nil {
+		return false
+	}
+
+	return encryptedMessage.kdf.Name == kdfNone
+}
+
+// IsCurrentFormat - whether s has been encrypted in the current format. Messages in older formats
+// can still be decrypted, but should be re-encrypted.
+func IsCurrentFormat(s string) bool {
+	var encryptedMessage EncryptedMessage
+	if err := encryptedMessage.FromString(s); err != nil {
+		return false
+	}
+
+	return encryptedMessage.version == currentVersion
+}
+
+// Encrypt - encrypt a message with a key derived from the master password (see `DefaultKDF`).
 func Encrypt(masterPassword, stringToEncrypt string) (string, error) {
-	if masterPassword == "" {
+	return EncryptWithPassword(masterPassword, stringToEncrypt, "")
+}
+
+// EncryptWithPassword - encrypt a message with a key derived from password (see `DefaultKDF`), like `Encrypt`,
+// but bound to associated data (see `EncryptWithKey`).
+func EncryptWithPassword(password, stringToEncrypt string, associatedData string) (string, error) {
+	if password == "" {
 		return "", util.ErrMasterPasswordMustNotBeEmpty
 	}
 
@@ -87,15 +282,22 @@ func Encrypt(masterPassword, stringToEncrypt string) (string, error) {
 		return "", err
 	}
 
-	key := argon2.IDKey([]byte(masterPassword), salt,
-		argon2Time, argon2Memory, argon2Threads, argon2KeyLength)
+	return seal(DefaultKDF, salt, DefaultKDF.key(password, salt), stringToEncrypt, associatedData)
+}
 
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
+// EncryptWithKey - encrypt a message with key (see `NewKey`) directly, without deriving it.
+// The message can only be decrypted with the same associated data: use it to bind the message
+// to its context, so it cannot be moved elsewhere.
+func EncryptWithKey(key []byte, stringToEncrypt string, associatedData string) (string, error) {
+	if len(key) != argon2KeyLength {
+		return "", errInvalidKey
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
+	return seal(noKDF, nil, key, stringToEncrypt, associatedData)
+}
+
+func seal(kdf KDF, salt, key []byte, stringToEncrypt string, associatedData string) (string, error) {
+	aesgcm, err := newAEAD(algorithmAES256GCM, key)
 	if err != nil {
 		return "", err
 	}
@@ -125,9 +327,12 @@ func Encrypt(masterPassword, stringToEncrypt string) (string, error) {
 		return "", err
 	}
 
-	ciphertext := aesgcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext := aesgcm.Seal(nil, nonce, plaintext, []byte(associatedData))
 
 	encryptedMessage := EncryptedMessage{
+		version:       currentVersion,
+		algorithm:     algorithmAES256GCM,
+		kdf:           kdf,
 		base64Salt:    base64.StdEncoding.EncodeToString(salt),
 		base64Nonce:   base64.StdEncoding.EncodeToString(nonce),
 		base64Message: base64.StdEncoding.EncodeToString(ciphertext),
@@ -136,41 +341,68 @@ func Encrypt(masterPassword, stringToEncrypt string) (string, error) {
 	return encryptedMessage.String(), nil
 }
 
+// Decrypt - decrypt a message encrypted by `Encrypt`, using the algorithm and KDF recorded in it.
+// Messages encrypted in the legacy format (without header) are supported.
 func Decrypt(masterPassword, stringToDecrypt string) (string, error) {
+	return DecryptWithPassword(masterPassword, stringToDecrypt, "")
+}
+
+// DecryptWithPassword - decrypt a message encrypted by `EncryptWithPassword`, with the same associated data.
+func DecryptWithPassword(password, stringToDecrypt string, associatedData string) (string, error) {
 	var encryptedMessage EncryptedMessage
 	if err := encryptedMessage.FromString(stringToDecrypt); err != nil {
 		return "", err
 	}
 
+	if encryptedMessage.kdf.Name == kdfNone {
+		return "", errKeyRequired
+	}
+
 	salt, err := base64.StdEncoding.DecodeString(encryptedMessage.base64Salt)
 	if err != nil {
 		return "", err
 	}
 
-	key := argon2.IDKey([]byte(masterPassword), salt,
-		argon2Time, argon2Memory, argon2Threads, argon2KeyLength)
+	return encryptedMessage.open(encryptedMessage.kdf.key(password, salt), associatedData)
+}
 
-	ciphertext, err := base64.StdEncoding.DecodeString(encryptedMessage.base64Message)
-	if err != nil {
+// DecryptWithKey - decrypt a message encrypted by `EncryptWithKey`, with the same associated data.
+// Associated data is ignored for messages encrypted by older versions of pasuman, that did not support it.
+func DecryptWithKey(key []byte, stringToDecrypt string, associatedData string) (string, error) {
+	var encryptedMessage EncryptedMessage
+	if err := encryptedMessage.FromString(stringToDecrypt); err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(key)
+	if encryptedMessage.kdf.Name != kdfNone {
+		return "", errMasterPasswordRequired
+	}
+
+	return encryptedMessage.open(key, associatedData)
+}
+
+func (e *EncryptedMessage) open(key []byte, associatedData string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(e.base64Message)
 	if err != nil {
 		return "", err
 	}
 
-	aesgcm, err := cipher.NewGCM(block)
+	aesgcm, err := newAEAD(e.algorithm, key)
 	if err != nil {
 		return "", err
 	}
 
-	nonce, err := base64.StdEncoding.DecodeString(encryptedMessage.base64Nonce)
+	nonce, err := base64.StdEncoding.DecodeString(e.base64Nonce)
 	if err != nil {
 		return "", err
 	}
 
-	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, nil)
+	var additionalData []byte
+	if e.version >= associatedDataVersion {
+		additionalData = []byte(associatedData)
+	}
+
+	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
 		return "", err
 	}
@@ -179,3 +411,26 @@ func Decrypt(masterPassword, stringToDecrypt string) (string, error) {
 
 	return decrypted, nil
 }
+
+func newAEAD(algorithm string, key []byte) (cipher.AEAD, error) {
+	if algorithm != algorithmAES256GCM {
+		return nil, fmt.Errorf("%w: %s", errUnsupportedAlgorithm, algorithm)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// NewKey - generate a random key, to be used with `EncryptWithKey`.
+func NewKey() ([]byte, error) {
+	key := make([]byte, argon2KeyLength)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -18,6 +18,9 @@
 package encrypt
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -83,10 +86,200 @@ func TestEncryptDecrypt(t *testing.T) {
 		// if password length <= 512 bytes, encrypted string have length 810
 		// due to padding
 		require.GreaterOrEqual(t, len(got), 810)
+		require.True(t, strings.HasPrefix(got, "$pasuman$v=2$alg=aes-256-gcm$kdf=argon2id,t=16,m=262144,p=4$"))
 		require.Len(t, strings.Split(got, encryptedMessageStringSeparator), encryptedMessageSplitStringLength)
+		require.False(t, IsLegacy(got))
 
 		decrypted, err := Decrypt("pass", got)
 		require.NoError(t, err)
 		require.Equal(t, tt.stringToEncrypt, decrypted)
 	}
 }
+
+func TestDecryptLegacy(t *testing.T) {
+	byteContents, err := os.ReadFile(filepath.Join("..", "data", "testdata", "v0.json"))
+	require.NoError(t, err)
+
+	var legacyData struct {
+		Entries []struct {
+			ID       string `json:"id"`
+			Password string `json:"password"`
+		} `json:"entries"`
+	}
+
+	require.NoError(t, json.Unmarshal(byteContents, &legacyData))
+	require.NotEmpty(t, legacyData.Entries)
+
+	entry := legacyData.Entries[0]
+
+	require.True(t, IsLegacy(entry.ID))
+	require.True(t, IsLegacy(entry.Password))
+
+	id, err := Decrypt("pass", entry.ID)
+	require.NoError(t, err)
+	require.Equal(t, "myId", id)
+
+	password, err := Decrypt("pass", entry.Password)
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", password)
+
+	_, err = Decrypt("wrong", entry.Password)
+	require.Error(t, err)
+}
+
+func TestDecryptUsesKDFFromHeader(t *testing.T) {
+	defaultKDF := DefaultKDF
+	defer func() { DefaultKDF = defaultKDF }()
+
+	DefaultKDF = KDF{Name: "argon2id", Time: 1, Memory: 64, Threads: 1}
+
+	encrypted, err := Encrypt("pass", "p4$$w0rd!")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encrypted, "$pasuman$v=2$alg=aes-256-gcm$kdf=argon2id,t=1,m=64,p=1$"))
+
+	// messages encrypted with other parameters can still be decrypted
+	DefaultKDF = defaultKDF
+
+	decrypted, err := Decrypt("pass", encrypted)
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", decrypted)
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	tests := []struct {
+		stringToDecrypt string
+		wantErr         error
+	}{
+		{
+			stringToDecrypt: "salt*nonce",
+			wantErr:         errEncryptedMessageStringInvalid,
+		},
+		{
+			stringToDecrypt: "$pasuman$v=3$alg=aes-256-gcm$kdf=argon2id,t=1,m=64,p=1$salt$nonce$message",
+			wantErr:         errUnsupportedVersion,
+		},
+		{
+			stringToDecrypt: "$pasuman$v=1$alg=aes-256-gcm$kdf=argon2id,t=1,m=64,p=1$salt$nonce",
+			wantErr:         errEncryptedMessageStringInvalid,
+		},
+		{
+			stringToDecrypt: "$pasuman$v=1$alg=chacha20-poly1305$kdf=argon2id,t=1,m=64,p=1$c2FsdA==$bm9uY2U=$bWVzc2FnZQ==",
+			wantErr:         errUnsupportedAlgorithm,
+		},
+		{
+			stringToDecrypt: "$pasuman$v=1$alg=aes-256-gcm$kdf=scrypt,N=32768,r=8,p=1$c2FsdA==$bm9uY2U=$bWVzc2FnZQ==",
+			wantErr:         errUnsupportedKDF,
+		},
+		{
+			stringToDecrypt: "$pasuman$v=1$alg=aes-256-gcm$kdf=argon2id,t=0,m=64,p=1$c2FsdA==$bm9uY2U=$bWVzc2FnZQ==",
+			wantErr:         errInvalidKDFParams,
+		},
+		{
+			stringToDecrypt: "$pasuman$v=1$alg=aes-256-gcm$kdf=argon2id,t=1,m=64$c2FsdA==$bm9uY2U=$bWVzc2FnZQ==",
+			wantErr:         errInvalidKDFParams,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := Decrypt("pass", tt.stringToDecrypt)
+		require.ErrorIs(t, err, tt.wantErr, tt.stringToDecrypt)
+	}
+}
+
+func TestEncryptDecryptWithKey(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+
+	encrypted, err := EncryptWithKey(key, "p4$$w0rd!", "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(encrypted, "$pasuman$v=2$alg=aes-256-gcm$kdf=none$$"))
+	require.True(t, IsEncryptedWithKey(encrypted))
+
+	decrypted, err := DecryptWithKey(key, encrypted, "")
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", decrypted)
+
+	otherKey, err := NewKey()
+	require.NoError(t, err)
+
+	_, err = DecryptWithKey(otherKey, encrypted, "")
+	require.Error(t, err)
+
+	_, err = Decrypt("pass", encrypted)
+	require.ErrorIs(t, err, errKeyRequired)
+
+	_, err = EncryptWithKey([]byte("too short"), "p4$$w0rd!", "")
+	require.ErrorIs(t, err, errInvalidKey)
+
+	keys := Keys{MasterPassword: "pass", DataKey: key}
+
+	decrypted, err = keys.Decrypt(encrypted, "")
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", decrypted)
+}
+
+func TestDecryptWithKeyChecksAssociatedData(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+
+	encrypted, err := EncryptWithKey(key, "p4$$w0rd!", `"entry1"/password`)
+	require.NoError(t, err)
+
+	decrypted, err := DecryptWithKey(key, encrypted, `"entry1"/password`)
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", decrypted)
+
+	// a value copied to another entry or field cannot be decrypted
+	_, err = DecryptWithKey(key, encrypted, `"entry2"/password`)
+	require.Error(t, err)
+
+	_, err = DecryptWithKey(key, encrypted, `"entry1"/id`)
+	require.Error(t, err)
+
+	// values written before associated data was introduced are not bound to anything
+	withoutAssociatedData, err := EncryptWithKey(key, "p4$$w0rd!", "")
+	require.NoError(t, err)
+
+	v1 := strings.Replace(withoutAssociatedData, "$pasuman$v=2$", "$pasuman$v=1$", 1)
+	require.False(t, IsCurrentFormat(v1))
+
+	decrypted, err = DecryptWithKey(key, v1, `"entry1"/password`)
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", decrypted)
+}
+
+func TestDecryptWithPasswordChecksAssociatedData(t *testing.T) {
+	encrypted, err := EncryptWithPassword("passphrase", "content", "archive/1")
+	require.NoError(t, err)
+
+	decrypted, err := DecryptWithPassword("passphrase", encrypted, "archive/1")
+	require.NoError(t, err)
+	require.Equal(t, "content", decrypted)
+
+	_, err = DecryptWithPassword("passphrase", encrypted, "archive/2")
+	require.Error(t, err)
+
+	_, err = DecryptWithPassword("wrong", encrypted, "archive/1")
+	require.Error(t, err)
+
+	_, err = Decrypt("passphrase", encrypted)
+	require.Error(t, err)
+}
+
+func TestMAC(t *testing.T) {
+	key, err := NewKey()
+	require.NoError(t, err)
+
+	keys := Keys{MasterPassword: "pass", DataKey: key}
+
+	mac := keys.MAC([]byte("content"))
+	require.True(t, keys.VerifyMAC([]byte("content"), mac))
+	require.False(t, keys.VerifyMAC([]byte("modified content"), mac))
+	require.False(t, keys.VerifyMAC([]byte("content"), "invalid"))
+
+	otherKey, err := NewKey()
+	require.NoError(t, err)
+
+	otherKeys := Keys{MasterPassword: "pass", DataKey: otherKey}
+	require.False(t, otherKeys.VerifyMAC([]byte("content"), mac))
+}
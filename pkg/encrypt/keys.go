@@ -0,0 +1,83 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// ErrMasterPasswordUnknown - the master password is needed, but only the data key is known (see `Keys`).
+var ErrMasterPasswordUnknown = errors.New("master password is needed: " +
+	"run any command asking for it (e.g. `pasuman migrate`) to re-encrypt values encrypted with it")
+
+// macKeyInfo - the MAC key is derived from the data key, so the data key is not used for two purposes.
+const macKeyInfo = "pasuman mac key"
+
+// Keys - keys of a profile. Values are encrypted with DataKey (a random key, see `NewKey`),
+// but values encrypted by older versions of pasuman are encrypted with MasterPassword. MasterPassword is empty
+// if only the data key is known (when it is given by the agent).
+type Keys struct {
+	MasterPassword string
+	DataKey        []byte
+}
+
+// Encrypt - encrypt a value with the data key, bound to associatedData.
+func (k Keys) Encrypt(stringToEncrypt string, associatedData string) (string, error) {
+	return EncryptWithKey(k.DataKey, stringToEncrypt, associatedData)
+}
+
+// Decrypt - decrypt a value encrypted with the data key, or with the master password.
+func (k Keys) Decrypt(stringToDecrypt string, associatedData string) (string, error) {
+	if IsEncryptedWithKey(stringToDecrypt) {
+		return DecryptWithKey(k.DataKey, stringToDecrypt, associatedData)
+	}
+
+	if k.MasterPassword == "" {
+		return "", ErrMasterPasswordUnknown
+	}
+
+	return Decrypt(k.MasterPassword, stringToDecrypt)
+}
+
+// MAC - message authentication code of content (HMAC-SHA256), with a key derived from the data key.
+func (k Keys) MAC(content []byte) string {
+	return base64.StdEncoding.EncodeToString(k.mac(content))
+}
+
+// VerifyMAC - whether mac is the message authentication code of content (see `MAC`).
+func (k Keys) VerifyMAC(content []byte, mac string) bool {
+	decodedMAC, err := base64.StdEncoding.DecodeString(mac)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(decodedMAC, k.mac(content))
+}
+
+func (k Keys) mac(content []byte) []byte {
+	keyHash := hmac.New(sha256.New, k.DataKey)
+	keyHash.Write([]byte(macKeyInfo))
+
+	contentHash := hmac.New(sha256.New, keyHash.Sum(nil))
+	contentHash.Write(content)
+
+	return contentHash.Sum(nil)
+}
@@ -0,0 +1,195 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/csvmap"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"gopkg.in/yaml.v3"
+)
+
+// Format - format of exported files.
+type Format string
+
+const (
+	JSON Format = "json"
+	YAML Format = "yaml"
+	CSV  Format = "csv"
+)
+
+// Formats - all supported formats.
+var Formats = []Format{JSON, YAML, CSV}
+
+var ErrInvalidFormat = errors.New("invalid export format")
+
+// IsValidFormat - true if format is supported.
+func IsValidFormat(format Format) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// entry - exported entry. ID, password, OTP key and note are only exported with secrets. Only names and sizes
+// of attachments are exported: their content is only kept by encrypted archives.
+type entry struct {
+	UniqueID    string       `json:"unique_id" yaml:"unique_id"`
+	Type        string       `json:"type,omitempty" yaml:"type,omitempty"`
+	Description string       `json:"description" yaml:"description"`
+	Tags        []string     `json:"tags" yaml:"tags"`
+	Site        string       `json:"site" yaml:"site"`
+	ID          *string      `json:"id,omitempty" yaml:"id,omitempty"`
+	Password    *string      `json:"password,omitempty" yaml:"password,omitempty"`
+	Fields      []field      `json:"fields,omitempty" yaml:"fields,omitempty"`
+	OTP         *string      `json:"otp,omitempty" yaml:"otp,omitempty"`
+	Note        *string      `json:"note,omitempty" yaml:"note,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty" yaml:"attachments,omitempty"`
+}
+
+// attachment - exported attachment, without its content.
+type attachment struct {
+	Name string `json:"name" yaml:"name"`
+	Size int    `json:"size" yaml:"size"`
+}
+
+// field - exported custom field. Values of sensitive fields are only exported with secrets.
+type field struct {
+	Name      string  `json:"name" yaml:"name"`
+	Value     *string `json:"value,omitempty" yaml:"value,omitempty"`
+	Sensitive bool    `json:"sensitive,omitempty" yaml:"sensitive,omitempty"`
+}
+
+// Entries - entries of the profile, sorted by unique ID. If includeSecrets is true, IDs and passwords are
+// decrypted with the master password, otherwise they are left out.
+func Entries(masterPassword string, includeSecrets bool) ([]data.Entry, error) {
+	var d data.Data
+
+	if err := d.FromFile(config.PasumanDataFile); err != nil {
+		return nil, err
+	}
+
+	entries := d.Entries
+
+	for idx := range entries {
+		if !includeSecrets {
+			entries[idx].ClearSensitive()
+
+			continue
+		}
+
+		decrypted, err := get.Sensitive(masterPassword, entries[idx].UniqueID)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[idx] = decrypted
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].UniqueID < entries[j].UniqueID
+	})
+
+	return entries, nil
+}
+
+// Write - write entries to w in format. With the CSV format, columns are mapped with mapping (custom fields,
+// OTP keys, notes and attachments are not exported). If includeSecrets is false, IDs, passwords, OTP keys, notes
+// and values of sensitive custom fields are left out (in CSV files, columns mapped to IDs and passwords are left
+// out too).
+func Write(w io.Writer, format Format, entries []data.Entry, includeSecrets bool, mapping csvmap.Mapping) error {
+	if format == CSV {
+		if !includeSecrets {
+			mapping = mapping.WithoutSensitive()
+		}
+
+		return csvmap.Write(w, entries, mapping)
+	}
+
+	exported := make([]entry, len(entries))
+
+	for idx := range entries {
+		exported[idx] = entry{
+			UniqueID:    entries[idx].UniqueID,
+			Type:        entries[idx].Type,
+			Description: entries[idx].Description,
+			Tags:        entries[idx].Tags,
+			Site:        entries[idx].Site,
+		}
+
+		if exported[idx].Tags == nil {
+			exported[idx].Tags = []string{}
+		}
+
+		if includeSecrets {
+			exported[idx].ID = &entries[idx].ID
+			exported[idx].Password = &entries[idx].Password
+
+			if entries[idx].OTP != "" {
+				exported[idx].OTP = &entries[idx].OTP
+			}
+
+			if entries[idx].Note != "" {
+				exported[idx].Note = &entries[idx].Note
+			}
+		}
+
+		for _, a := range entries[idx].Attachments {
+			exported[idx].Attachments = append(exported[idx].Attachments, attachment{Name: a.Name, Size: a.Size})
+		}
+
+		for fieldIdx, f := range entries[idx].Fields {
+			exportedField := field{Name: f.Name, Sensitive: f.Sensitive}
+
+			if includeSecrets || !f.Sensitive {
+				exportedField.Value = &entries[idx].Fields[fieldIdx].Value
+			}
+
+			exported[idx].Fields = append(exported[idx].Fields, exportedField)
+		}
+	}
+
+	switch format {
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(exported)
+	case YAML:
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2) // nolint: gomnd
+
+		if err := encoder.Encode(exported); err != nil {
+			return err
+		}
+
+		return encoder.Close()
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+}
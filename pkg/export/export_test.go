@@ -0,0 +1,185 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/csvmap"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntries(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	id2 := data.Entry{
+		UniqueID:    "id2",
+		Description: "Another desc",
+		Tags:        []string{"tag1", "tag3"},
+		Site:        "https://anothersite.pasuman",
+		ID:          "otherId",
+		Password:    "t0ps3cr3t!",
+	}
+	id1 := data.Entry{
+		UniqueID:    "id1",
+		Description: "A desc",
+		Tags:        []string{"tag1", "tag2"},
+		Site:        "https://mysupersite.pasuman",
+		ID:          "myId",
+		Password:    "p4$$w0rd!",
+	}
+
+	for _, entry := range []data.Entry{id2, id1} {
+		_, err := add.Add(pasumantest.TestMasterPassword, entry)
+		require.NoError(t, err)
+	}
+
+	got, err := Entries(pasumantest.TestMasterPassword, true)
+	require.NoError(t, err)
+	require.Equal(t, []data.Entry{id1, id2}, got)
+
+	got, err = Entries("", false)
+	require.NoError(t, err)
+
+	id1.ID, id1.Password, id2.ID, id2.Password = "", "", "", ""
+	require.Equal(t, []data.Entry{id1, id2}, got)
+
+	_, err = Entries("wrong", true)
+	require.Error(t, err)
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	entries := []data.Entry{
+		{
+			UniqueID: "id1", Description: "A desc", Tags: []string{"tag1"}, Site: "https://site", ID: "me", Password: "pw",
+			Fields: []data.Field{{Name: "PIN", Value: "1234", Sensitive: true}}, OTP: "GEZDGNBV",
+		},
+		{UniqueID: "id2"},
+	}
+
+	tests := []struct {
+		format         Format
+		includeSecrets bool
+		want           string
+	}{
+		{
+			format: JSON,
+			want: `[
+  {
+    "unique_id": "id1",
+    "description": "A desc",
+    "tags": [
+      "tag1"
+    ],
+    "site": "https://site",
+    "fields": [
+      {
+        "name": "PIN",
+        "sensitive": true
+      }
+    ]
+  },
+  {
+    "unique_id": "id2",
+    "description": "",
+    "tags": [],
+    "site": ""
+  }
+]
+`,
+		},
+		{
+			format:         JSON,
+			includeSecrets: true,
+			want: `[
+  {
+    "unique_id": "id1",
+    "description": "A desc",
+    "tags": [
+      "tag1"
+    ],
+    "site": "https://site",
+    "id": "me",
+    "password": "pw",
+    "fields": [
+      {
+        "name": "PIN",
+        "value": "1234",
+        "sensitive": true
+      }
+    ],
+    "otp": "GEZDGNBV"
+  },
+  {
+    "unique_id": "id2",
+    "description": "",
+    "tags": [],
+    "site": "",
+    "id": "",
+    "password": ""
+  }
+]
+`,
+		},
+		{
+			format:         YAML,
+			includeSecrets: true,
+			want: `- unique_id: id1
+  description: A desc
+  tags:
+    - tag1
+  site: https://site
+  id: me
+  password: pw
+  fields:
+    - name: PIN
+      value: "1234"
+      sensitive: true
+  otp: GEZDGNBV
+- unique_id: id2
+  description: ""
+  tags: []
+  site: ""
+  id: ""
+  password: ""
+`,
+		},
+		{
+			format: CSV,
+			want:   "unique_id,description,tags,site\nid1,A desc,tag1,https://site\nid2,,,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		var b strings.Builder
+
+		require.NoError(t, Write(&b, tt.format, entries, tt.includeSecrets, csvmap.Default))
+		require.Equal(t, tt.want, b.String(), tt.format, tt.includeSecrets)
+	}
+
+	require.ErrorIs(t, Write(&strings.Builder{}, "xml", entries, false, csvmap.Default), ErrInvalidFormat)
+}
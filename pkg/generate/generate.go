@@ -19,22 +19,28 @@ package generate
 
 import (
 	"crypto/rand"
+	"math"
 	"math/big"
 
 	"github.com/norbjd/pasuman/pkg/constants"
 )
 
+// Generate - a random password of length characters, picked uniformly from `constants.Alphabet`.
 func Generate(length uint) (string, error) {
-	generated := make([]byte, length)
+	return GenerateWithPolicy(Policy{Length: length})
+}
 
-	for i := uint(0); i < length; i++ {
-		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(constants.Alphabet))))
-		if err != nil {
-			return "", err
-		}
+// Entropy - entropy, in bits, of passwords generated by `Generate` with this length.
+func Entropy(length uint) float64 {
+	return float64(length) * math.Log2(float64(len(constants.Alphabet)))
+}
 
-		generated[i] = constants.Alphabet[num.Int64()]
+// randomIndex - an integer picked uniformly in [0, n), using crypto/rand.
+func randomIndex(n int) (int, error) {
+	num, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+	if err != nil {
+		return 0, err
 	}
 
-	return string(generated), nil
+	return int(num.Int64()), nil
 }
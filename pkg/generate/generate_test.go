@@ -50,3 +50,8 @@ func TestGenerate(t *testing.T) {
 		}
 	}
 }
+
+func TestEntropy(t *testing.T) {
+	require.InDelta(t, 839.0, Entropy(128), 0.05)
+	require.Zero(t, Entropy(0))
+}
@@ -0,0 +1,112 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// digits - number of values of the digit appended to passphrases.
+const digits = 10
+
+// PassphraseOptions - passphrases generated by `Passphrase` are made of Words words picked uniformly from Wordlist
+// (the EFF large wordlist if empty), joined by Separator. With Capitalize, the first letter of each word is upper
+// case; with AppendDigit, a random digit is appended to the passphrase.
+type PassphraseOptions struct {
+	Words       uint
+	Separator   string
+	Capitalize  bool
+	AppendDigit bool
+	Wordlist    []string
+}
+
+// wordlist - words passphrases are made of, capitalized with Capitalize: words that only differ by the case of
+// their first letter are merged then, so they are not counted twice in the entropy.
+func (o PassphraseOptions) wordlist() []string {
+	wordlist := o.Wordlist
+	if len(wordlist) == 0 {
+		wordlist = EFFLargeWordlist()
+	}
+
+	if !o.Capitalize {
+		return wordlist
+	}
+
+	capitalized := make([]string, 0, len(wordlist))
+	seen := make(map[string]bool, len(wordlist))
+
+	for _, word := range wordlist {
+		if word = capitalize(word); !seen[word] {
+			seen[word] = true
+			capitalized = append(capitalized, word)
+		}
+	}
+
+	return capitalized
+}
+
+// Passphrase - a random passphrase, as described by options.
+func Passphrase(options PassphraseOptions) (string, error) {
+	wordlist := options.wordlist()
+	words := make([]string, options.Words)
+
+	for i := range words {
+		idx, err := randomIndex(len(wordlist))
+		if err != nil {
+			return "", err
+		}
+
+		words[i] = wordlist[idx]
+	}
+
+	passphrase := strings.Join(words, options.Separator)
+
+	if options.AppendDigit {
+		digit, err := randomIndex(digits)
+		if err != nil {
+			return "", err
+		}
+
+		passphrase += strconv.Itoa(digit)
+	}
+
+	return passphrase, nil
+}
+
+// Entropy - entropy, in bits, of passphrases generated with options. Capitalization does not add any,
+// as every word is capitalized. With an empty separator, it is overstated if different words make the same
+// passphrase once joined (e.g. "sun" and "flower", and "sunflower").
+func (o PassphraseOptions) Entropy() float64 {
+	entropy := float64(o.Words) * math.Log2(float64(len(o.wordlist())))
+
+	if o.AppendDigit {
+		entropy += math.Log2(digits)
+	}
+
+	return entropy
+}
+
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+
+	return string(unicode.ToUpper(r)) + word[size:]
+}
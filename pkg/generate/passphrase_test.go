@@ -0,0 +1,82 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassphrase(t *testing.T) {
+	effWordlist := map[string]bool{}
+	for _, word := range EFFLargeWordlist() {
+		effWordlist[word] = true
+	}
+
+	tests := []struct {
+		options PassphraseOptions
+		pattern string
+		entropy float64
+	}{
+		{
+			options: PassphraseOptions{Words: 6, Separator: "-"},
+			pattern: `^[a-z-]+$`,
+			entropy: 77.5,
+		},
+		{
+			options: PassphraseOptions{Words: 4, Separator: " ", Capitalize: true, AppendDigit: true},
+			pattern: `^([A-Z][a-z-]+ ){3}[A-Z][a-z-]+[0-9]$`,
+			entropy: 55,
+		},
+		{
+			options: PassphraseOptions{Words: 3, Separator: ".", Wordlist: []string{"correct", "horse"}},
+			pattern: `^(correct|horse)\.(correct|horse)\.(correct|horse)$`,
+			entropy: 3,
+		},
+		{
+			// words only differing by case are merged once capitalized
+			options: PassphraseOptions{Words: 2, Capitalize: true, Wordlist: []string{"apple", "Apple", "pear"}},
+			pattern: `^(Apple|Pear){2}$`,
+			entropy: 2,
+		},
+		{
+			options: PassphraseOptions{Words: 0, AppendDigit: true},
+			pattern: `^[0-9]$`,
+			entropy: 3.3,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := Passphrase(tt.options)
+		require.NoError(t, err)
+		require.Regexp(t, regexp.MustCompile(tt.pattern), got)
+		require.InDelta(t, tt.entropy, tt.options.Entropy(), 0.05)
+
+		if len(tt.options.Wordlist) == 0 && tt.options.Words > 0 {
+			words := strings.Split(strings.TrimRight(got, "0123456789"), tt.options.Separator)
+			require.Len(t, words, int(tt.options.Words))
+
+			for _, word := range words {
+				require.True(t, effWordlist[strings.ToLower(word)], word)
+			}
+		}
+	}
+}
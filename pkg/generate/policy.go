@@ -0,0 +1,394 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+
+	"github.com/norbjd/pasuman/pkg/constants"
+)
+
+// Character classes of passwords.
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+
+	// ambiguous - characters easily mistaken for one another (0/O, 1/l/I/|).
+	ambiguous = "0O1lI|"
+
+	// maxCompletions - maximum number of values computed to generate a password (see `completions`), so large
+	// minimum numbers of characters of several classes do not exhaust memory.
+	maxCompletions = 1 << 22
+
+	// maxLength - maximum length of passwords, so they fit in memory.
+	maxLength = 1 << 20
+)
+
+var (
+	ErrInvalidPolicy = errors.New("invalid password policy")
+
+	// Classes - all character classes.
+	Classes = []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}
+)
+
+// Policy - rules of passwords generated by `GenerateWithPolicy`: Length characters picked from classes Classes
+// (all if empty) and from Include, except characters of Exclude (and ambiguous ones, with NoAmbiguous), with at
+// least MinCounts[class] characters of each class. Characters of Include count for their own class: e.g. with
+// Classes lower and digit, Include "!@#$" and MinCounts symbol 1, passwords have one of !@#$ at least.
+type Policy struct {
+	Length      uint
+	Classes     []string
+	MinCounts   map[string]uint
+	Include     string
+	Exclude     string
+	NoAmbiguous bool
+}
+
+// charset - characters of a class allowed by a policy, and the minimum number of them in passwords.
+type charset struct {
+	class string
+	chars []rune
+	min   uint
+}
+
+// classOf - class of r: characters that are neither letters nor digits are symbols.
+func classOf(r rune) string {
+	switch {
+	case unicode.IsLower(r):
+		return ClassLower
+	case unicode.IsUpper(r):
+		return ClassUpper
+	case unicode.IsDigit(r):
+		return ClassDigit
+	default:
+		return ClassSymbol
+	}
+}
+
+func isClass(class string) bool {
+	for _, c := range Classes {
+		if c == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+// charsets - characters allowed by the policy, by class (in the order of `Classes`, empty classes left out).
+func (p Policy) charsets() ([]charset, error) {
+	allowedClasses := map[string]bool{}
+
+	for _, class := range p.Classes {
+		if !isClass(class) {
+			return nil, fmt.Errorf("%w: unknown class %s (must be one of %s)", ErrInvalidPolicy, class,
+				strings.Join(Classes, ", "))
+		}
+
+		allowedClasses[class] = true
+	}
+
+	if p.Length > maxLength {
+		return nil, fmt.Errorf("%w: length %d is larger than %d", ErrInvalidPolicy, p.Length, maxLength)
+	}
+
+	var minCount uint
+
+	for class, count := range p.MinCounts {
+		if !isClass(class) {
+			return nil, fmt.Errorf("%w: unknown class %s (must be one of %s)", ErrInvalidPolicy, class,
+				strings.Join(Classes, ", "))
+		}
+
+		// counts are checked one by one before being summed, so the sum cannot wrap around
+		if count > p.Length {
+			return nil, fmt.Errorf("%w: at least %d characters of class %s are required, but length is %d",
+				ErrInvalidPolicy, count, class, p.Length)
+		}
+
+		if count > p.Length-minCount {
+			return nil, fmt.Errorf("%w: at least %d characters are required, but length is %d", ErrInvalidPolicy,
+				minCount+count, p.Length)
+		}
+
+		minCount += count
+	}
+
+	excluded := p.Exclude
+	if p.NoAmbiguous {
+		excluded += ambiguous
+	}
+
+	seen := map[rune]bool{}
+	chars := map[string][]rune{}
+
+	for _, r := range constants.Alphabet + p.Include {
+		class := classOf(r)
+
+		if seen[r] || strings.ContainsRune(excluded, r) ||
+			(len(allowedClasses) > 0 && !allowedClasses[class] && !strings.ContainsRune(p.Include, r)) {
+			continue
+		}
+
+		seen[r] = true
+		chars[class] = append(chars[class], r)
+	}
+
+	var charsets []charset
+
+	for _, class := range Classes {
+		if len(chars[class]) == 0 {
+			if p.MinCounts[class] > 0 {
+				return nil, fmt.Errorf("%w: at least %d characters of class %s are required, but none is allowed",
+					ErrInvalidPolicy, p.MinCounts[class], class)
+			}
+
+			continue
+		}
+
+		charsets = append(charsets, charset{class: class, chars: chars[class], min: p.MinCounts[class]})
+	}
+
+	if len(charsets) == 0 && p.Length > 0 {
+		return nil, fmt.Errorf("%w: no character is allowed", ErrInvalidPolicy)
+	}
+
+	return charsets, nil
+}
+
+// completions - numbers of ways to complete passwords, so they comply with a policy. While a password is generated,
+// its state is the number of characters of each class so far, up to the minimum of the class (as a mixed radix
+// number, a digit per class): the password complies with the policy once all minimums are reached.
+// completions[r][state] is the number of ways to complete a password in state with r more characters.
+type completions struct {
+	charsets []charset
+	strides  []int
+	counts   [][]*big.Int
+}
+
+func newCompletions(charsets []charset, length int) (completions, error) {
+	c := completions{charsets: charsets, strides: make([]int, len(charsets))}
+
+	errTooLarge := fmt.Errorf("%w: minimum numbers of characters are too large", ErrInvalidPolicy)
+
+	if length >= maxCompletions {
+		return completions{}, errTooLarge
+	}
+
+	states := 1
+
+	for i, charset := range charsets {
+		c.strides[i] = states
+
+		// states*(length+1) stays at most maxCompletions, so it is checked before being multiplied
+		if int(charset.min)+1 > maxCompletions/(states*(length+1)) {
+			return completions{}, errTooLarge
+		}
+
+		states *= int(charset.min) + 1
+	}
+
+	c.counts = make([][]*big.Int, length+1)
+
+	for r := range c.counts {
+		c.counts[r] = make([]*big.Int, states)
+
+		for state := range c.counts[r] {
+			c.counts[r][state] = big.NewInt(0)
+
+			if r == 0 {
+				// all minimums are reached in the last state only
+				if state == states-1 {
+					c.counts[r][state].SetInt64(1)
+				}
+
+				continue
+			}
+
+			for i := range charsets {
+				c.counts[r][state].Add(c.counts[r][state], c.weight(r, state, i))
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// next - state of a password in state, once a character of charsets[i] is added.
+func (c completions) next(state int, i int) int {
+	if (state/c.strides[i])%(int(c.charsets[i].min)+1) < int(c.charsets[i].min) {
+		return state + c.strides[i]
+	}
+
+	return state
+}
+
+// weight - number of ways to complete a password in state with r more characters, the next one of charsets[i].
+func (c completions) weight(r int, state int, i int) *big.Int {
+	weight := big.NewInt(int64(len(c.charsets[i].chars)))
+
+	return weight.Mul(weight, c.counts[r-1][c.next(state, i)])
+}
+
+// GenerateWithPolicy - a password picked uniformly, using crypto/rand, among all passwords that comply with policy.
+// Without minimum numbers of characters, each character is picked uniformly among allowed ones. Otherwise, each
+// character is picked in turn: its class with a probability proportional to the number of compliant passwords
+// starting with the characters picked so far followed by a character of the class, then a character of the class.
+func GenerateWithPolicy(policy Policy) (string, error) {
+	password, _, err := generateWithPolicy(policy)
+
+	return password, err
+}
+
+// generateWithPolicy - a password generated by `GenerateWithPolicy`, and the entropy of such passwords (see
+// `Policy.Entropy`): numbers of compliant passwords are only computed once for both.
+func generateWithPolicy(policy Policy) (string, float64, error) {
+	charsets, err := policy.charsets()
+	if err != nil {
+		return "", 0, err
+	}
+
+	length := int(policy.Length)
+	generated := make([]rune, length)
+
+	if !hasMinimums(charsets) {
+		chars := allChars(charsets)
+
+		for position := range generated {
+			idx, err := randomIndex(len(chars))
+			if err != nil {
+				return "", 0, err
+			}
+
+			generated[position] = chars[idx]
+		}
+
+		return string(generated), uniformEntropy(length, len(chars)), nil
+	}
+
+	completions, err := newCompletions(charsets, length)
+	if err != nil {
+		return "", 0, err
+	}
+
+	state := 0
+
+	for position := range generated {
+		r := length - position
+
+		pick, err := rand.Int(rand.Reader, completions.counts[r][state])
+		if err != nil {
+			return "", 0, err
+		}
+
+		i := 0
+
+		for ; i < len(charsets)-1; i++ {
+			weight := completions.weight(r, state, i)
+			if pick.Cmp(weight) < 0 {
+				break
+			}
+
+			pick.Sub(pick, weight)
+		}
+
+		idx, err := randomIndex(len(charsets[i].chars))
+		if err != nil {
+			return "", 0, err
+		}
+
+		generated[position] = charsets[i].chars[idx]
+		state = completions.next(state, i)
+	}
+
+	return string(generated), log2(completions.counts[length][0]), nil
+}
+
+// Entropy - entropy, in bits, of passwords generated by `GenerateWithPolicy` with the policy: the base 2 logarithm
+// of the number of passwords that comply with it.
+func (p Policy) Entropy() (float64, error) {
+	charsets, err := p.charsets()
+	if err != nil {
+		return 0, err
+	}
+
+	if !hasMinimums(charsets) {
+		return uniformEntropy(int(p.Length), len(allChars(charsets))), nil
+	}
+
+	completions, err := newCompletions(charsets, int(p.Length))
+	if err != nil {
+		return 0, err
+	}
+
+	return log2(completions.counts[p.Length][0]), nil
+}
+
+// hasMinimums - whether a minimum number of characters is set for a class of charsets: otherwise, all passwords
+// made of their characters comply with the policy, and numbers of compliant passwords need not be computed.
+func hasMinimums(charsets []charset) bool {
+	for _, charset := range charsets {
+		if charset.min > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allChars - characters of all charsets.
+func allChars(charsets []charset) []rune {
+	var chars []rune
+
+	for _, charset := range charsets {
+		chars = append(chars, charset.chars...)
+	}
+
+	return chars
+}
+
+// uniformEntropy - entropy, in bits, of passwords of length characters, each picked uniformly among n.
+func uniformEntropy(length int, n int) float64 {
+	if length == 0 {
+		return 0
+	}
+
+	return float64(length) * math.Log2(float64(n))
+}
+
+// log2 - base 2 logarithm of x, which may not fit in a float64.
+func log2(x *big.Int) float64 {
+	const mantissaBits = 64
+
+	shift := x.BitLen() - mantissaBits
+	if shift < 0 {
+		shift = 0
+	}
+
+	top, _ := new(big.Float).SetInt(new(big.Int).Rsh(x, uint(shift))).Float64()
+
+	return math.Log2(top) + float64(shift)
+}
@@ -0,0 +1,223 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateWithPolicy(t *testing.T) {
+	tests := []struct {
+		policy  Policy
+		allowed string
+	}{
+		{
+			policy:  Policy{Length: 16},
+			allowed: constants.Alphabet,
+		},
+		{
+			policy: Policy{
+				Length: 8, Classes: []string{ClassLower, ClassUpper, ClassDigit}, Include: "!@#$",
+				MinCounts: map[string]uint{ClassUpper: 1, ClassDigit: 1, ClassSymbol: 1},
+			},
+			allowed: "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$",
+		},
+		{
+			policy:  Policy{Length: 20, Exclude: "\"'`", MinCounts: map[string]uint{ClassSymbol: 3}},
+			allowed: strings.NewReplacer("\"", "", "'", "", "`", "").Replace(constants.Alphabet),
+		},
+		{
+			policy:  Policy{Length: 32, Classes: []string{ClassUpper, ClassDigit}, NoAmbiguous: true},
+			allowed: "ABCDEFGHJKLMNPQRSTUVWXYZ23456789",
+		},
+		{
+			// minimums that a password picked among allowed characters would rarely reach
+			policy: Policy{
+				Length: 12, Classes: []string{ClassLower, ClassDigit},
+				MinCounts: map[string]uint{ClassDigit: 11},
+			},
+			allowed: "abcdefghijklmnopqrstuvwxyz0123456789",
+		},
+		{
+			policy: Policy{
+				Length: 4, Classes: []string{ClassSymbol}, Include: "€é", MinCounts: map[string]uint{ClassLower: 1},
+			},
+			allowed: "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~€é",
+		},
+		{
+			policy:  Policy{Length: 0},
+			allowed: "",
+		},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			got, err := GenerateWithPolicy(tt.policy)
+			require.NoError(t, err, tt.policy)
+			require.Len(t, []rune(got), int(tt.policy.Length), tt.policy)
+
+			classCounts := map[string]uint{}
+
+			for _, r := range got {
+				require.True(t, strings.ContainsRune(tt.allowed, r), "%q in %q", r, got)
+
+				classCounts[classOf(r)]++
+			}
+
+			for class, minCount := range tt.policy.MinCounts {
+				require.GreaterOrEqual(t, classCounts[class], minCount, "%s in %q", class, got)
+			}
+		}
+	}
+}
+
+// TestGenerateWithPolicyUniform - all passwords that comply with a policy must be as likely, including those
+// with more characters of a class than its minimum.
+func TestGenerateWithPolicyUniform(t *testing.T) {
+	// 0, 1, a and b, with 2 digits at least: 3 * 2^2 * 2 passwords with 2 digits, and 2^3 with 3 digits
+	policy := Policy{
+		Length: 3, Classes: []string{ClassDigit}, Exclude: "23456789", Include: "ab",
+		MinCounts: map[string]uint{ClassDigit: 2},
+	}
+
+	const (
+		passwords = 32
+		samples   = passwords * 500
+		// chi-squared critical value for 31 degrees of freedom, at a significance of 1e-6: the test fails
+		// by chance once in a million runs
+		critical = 84.2
+	)
+
+	entropy, err := policy.Entropy()
+	require.NoError(t, err)
+	require.InDelta(t, 5, entropy, 1e-9)
+
+	frequencies := map[string]int{}
+
+	for i := 0; i < samples; i++ {
+		got, err := GenerateWithPolicy(policy)
+		require.NoError(t, err)
+
+		frequencies[got]++
+	}
+
+	require.Len(t, frequencies, passwords)
+
+	expected := float64(samples) / passwords
+	chiSquared := 0.0
+
+	for password, frequency := range frequencies {
+		require.GreaterOrEqual(t, countDigits(password), 2, password)
+
+		chiSquared += (float64(frequency) - expected) * (float64(frequency) - expected) / expected
+	}
+
+	require.Less(t, chiSquared, critical, frequencies)
+}
+
+func countDigits(s string) int {
+	count := 0
+
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestPolicyEntropy(t *testing.T) {
+	tests := []struct {
+		policy  Policy
+		entropy float64
+	}{
+		{
+			policy:  Policy{Length: 128},
+			entropy: Entropy(128),
+		},
+		{
+			policy:  Policy{Length: 10, Classes: []string{ClassDigit}},
+			entropy: 33.2,
+		},
+		{
+			// 11^4 - 10^4 passwords with an "a" at least
+			policy: Policy{
+				Length: 4, Classes: []string{ClassDigit}, Include: "a", MinCounts: map[string]uint{ClassLower: 1},
+			},
+			entropy: 12.2,
+		},
+	}
+
+	for _, tt := range tests {
+		entropy, err := tt.policy.Entropy()
+		require.NoError(t, err)
+		require.InDelta(t, tt.entropy, entropy, 0.05, tt.policy)
+
+		// the entropy given with generated passwords is the same
+		_, generatedEntropy, err := generateWithPolicy(tt.policy)
+		require.NoError(t, err)
+		require.InDelta(t, entropy, generatedEntropy, 1e-9, tt.policy)
+	}
+}
+
+func TestGenerateWithPolicyLong(t *testing.T) {
+	// without minimums, characters are picked uniformly: numbers of compliant passwords are not computed
+	policy := Policy{Length: 100000, Classes: []string{ClassLower, ClassDigit}}
+
+	got, entropy, err := generateWithPolicy(policy)
+	require.NoError(t, err)
+	require.Len(t, got, 100000)
+	require.InDelta(t, 100000*math.Log2(36), entropy, 1e-6)
+
+	for _, r := range got {
+		require.True(t, strings.ContainsRune("abcdefghijklmnopqrstuvwxyz0123456789", r), "%q", r)
+	}
+
+	policyEntropy, err := policy.Entropy()
+	require.NoError(t, err)
+	require.InDelta(t, entropy, policyEntropy, 1e-9)
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	for _, policy := range []Policy{
+		{Length: 8, Classes: []string{"emoji"}},
+		{Length: 8, MinCounts: map[string]uint{"emoji": 1}},
+		{Length: 2, MinCounts: map[string]uint{ClassUpper: 2, ClassDigit: 1}},
+		{Length: 8, Classes: []string{ClassLower}, MinCounts: map[string]uint{ClassDigit: 1}},
+		{Length: 8, Classes: []string{ClassDigit}, Exclude: "0123456789"},
+		{Length: 1 << 20, MinCounts: map[string]uint{ClassLower: 100, ClassUpper: 100, ClassDigit: 100}},
+		{Length: 10, MinCounts: map[string]uint{ClassLower: math.MaxUint, ClassUpper: 2}},
+		{Length: math.MaxUint, MinCounts: map[string]uint{ClassLower: math.MaxUint, ClassUpper: 1}},
+		{Length: math.MaxUint / 2, MinCounts: map[string]uint{ClassLower: math.MaxUint / 4, ClassUpper: 1}},
+		{Length: math.MaxUint, Classes: []string{ClassLower}},
+		{Length: maxLength, MinCounts: map[string]uint{ClassLower: maxLength}},
+	} {
+		_, err := GenerateWithPolicy(policy)
+		require.ErrorIs(t, err, ErrInvalidPolicy, policy)
+
+		_, err = policy.Entropy()
+		require.ErrorIs(t, err, ErrInvalidPolicy, policy)
+	}
+}
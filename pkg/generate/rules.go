@@ -0,0 +1,140 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"fmt"
+	"os"
+)
+
+const (
+	// DefaultLength - length of passwords, if not set.
+	DefaultLength = 128
+	// DefaultWords - number of words of passphrases, if not set.
+	DefaultWords = 6
+	// DefaultSeparator - separator between words of passphrases, if not set.
+	DefaultSeparator = "-"
+)
+
+// Rules - how to generate a password: a passphrase (see `PassphraseOptions`) if Passphrase is set, a random
+// password complying with a `Policy` otherwise. Length, Words and Separator default to DefaultLength, DefaultWords
+// and DefaultSeparator; Wordlist is a file to pick words from (see `ParseWordlist`). Named password policies
+// of the config file are rules.
+type Rules struct {
+	Passphrase bool `json:"passphrase,omitempty"`
+
+	Length      *uint           `json:"length,omitempty"`
+	Classes     []string        `json:"classes,omitempty"`
+	MinCounts   map[string]uint `json:"min_counts,omitempty"`
+	Include     string          `json:"include,omitempty"`
+	Exclude     string          `json:"exclude,omitempty"`
+	NoAmbiguous bool            `json:"no_ambiguous,omitempty"`
+
+	Words       *uint   `json:"words,omitempty"`
+	Separator   *string `json:"separator,omitempty"`
+	Capitalize  bool    `json:"capitalize,omitempty"`
+	AppendDigit bool    `json:"append_digit,omitempty"`
+	Wordlist    string  `json:"wordlist,omitempty"`
+}
+
+// Generate - a random password (or passphrase) complying with r, and its entropy in bits.
+func (r Rules) Generate() (string, float64, error) {
+	if r.Passphrase {
+		if r.Length != nil || len(r.Classes) > 0 || len(r.MinCounts) > 0 || r.Include != "" || r.Exclude != "" ||
+			r.NoAmbiguous {
+			return "", 0, fmt.Errorf("%w: length, classes, min_counts, include, exclude and no_ambiguous "+
+				"do not apply to passphrases", ErrInvalidPolicy)
+		}
+
+		options, err := r.passphraseOptions()
+		if err != nil {
+			return "", 0, err
+		}
+
+		if options.Words == 0 {
+			return "", 0, fmt.Errorf("%w: passphrases must have one word at least", ErrInvalidPolicy)
+		}
+
+		passphrase, err := Passphrase(options)
+		if err != nil {
+			return "", 0, err
+		}
+
+		return passphrase, options.Entropy(), nil
+	}
+
+	if r.Words != nil || r.Separator != nil || r.Capitalize || r.AppendDigit || r.Wordlist != "" {
+		return "", 0, fmt.Errorf("%w: words, separator, capitalize, append_digit and wordlist "+
+			"only apply to passphrases", ErrInvalidPolicy)
+	}
+
+	policy := r.policy()
+	if policy.Length == 0 {
+		return "", 0, fmt.Errorf("%w: passwords must have one character at least", ErrInvalidPolicy)
+	}
+
+	return generateWithPolicy(policy)
+}
+
+func (r Rules) policy() Policy {
+	policy := Policy{
+		Length:      DefaultLength,
+		Classes:     r.Classes,
+		MinCounts:   r.MinCounts,
+		Include:     r.Include,
+		Exclude:     r.Exclude,
+		NoAmbiguous: r.NoAmbiguous,
+	}
+
+	if r.Length != nil {
+		policy.Length = *r.Length
+	}
+
+	return policy
+}
+
+func (r Rules) passphraseOptions() (PassphraseOptions, error) {
+	options := PassphraseOptions{
+		Words:       DefaultWords,
+		Separator:   DefaultSeparator,
+		Capitalize:  r.Capitalize,
+		AppendDigit: r.AppendDigit,
+	}
+
+	if r.Words != nil {
+		options.Words = *r.Words
+	}
+
+	if r.Separator != nil {
+		options.Separator = *r.Separator
+	}
+
+	if r.Wordlist != "" {
+		wordlist, err := os.Open(r.Wordlist)
+		if err != nil {
+			return PassphraseOptions{}, err
+		}
+		defer wordlist.Close()
+
+		if options.Wordlist, err = ParseWordlist(wordlist); err != nil {
+			return PassphraseOptions{}, err
+		}
+	}
+
+	return options, nil
+}
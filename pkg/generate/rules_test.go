@@ -0,0 +1,116 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules(t *testing.T) {
+	wordlist := filepath.Join(t.TempDir(), "wordlist.txt")
+	require.NoError(t, os.WriteFile(wordlist, []byte("correct\nhorse\nbattery\nstaple\n"), 0o600))
+
+	tests := []struct {
+		rules   string
+		pattern string
+		entropy string
+	}{
+		{
+			rules:   `{}`,
+			pattern: `^.{128}$`,
+			entropy: "839.0",
+		},
+		{
+			rules:   `{"length": 8, "classes": ["digit"], "exclude": "0123456"}`,
+			pattern: `^[789]{8}$`,
+			entropy: "12.7",
+		},
+		{
+			rules:   `{"passphrase": true}`,
+			pattern: `^[a-z-]+(-[a-z-]+){5}$`,
+			entropy: "77.5",
+		},
+		{
+			rules: fmt.Sprintf(`{"passphrase": true, "words": 3, "separator": "", "capitalize": true, `+
+				`"wordlist": %q}`, wordlist),
+			pattern: `^((Correct|Horse|Battery|Staple)){3}$`,
+			entropy: "6.0",
+		},
+	}
+
+	for _, tt := range tests {
+		var rules Rules
+		require.NoError(t, json.Unmarshal([]byte(tt.rules), &rules))
+
+		password, entropy, err := rules.Generate()
+		require.NoError(t, err, tt.rules)
+		require.Regexp(t, regexp.MustCompile(tt.pattern), password, tt.rules)
+		require.Equal(t, tt.entropy, fmt.Sprintf("%.1f", entropy), tt.rules)
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	tests := []struct {
+		rules   string
+		wantErr error
+	}{
+		{
+			rules:   `{"passphrase": true, "length": 20}`,
+			wantErr: ErrInvalidPolicy,
+		},
+		{
+			rules:   `{"passphrase": true, "classes": ["lower"]}`,
+			wantErr: ErrInvalidPolicy,
+		},
+		{
+			rules:   `{"words": 4}`,
+			wantErr: ErrInvalidPolicy,
+		},
+		{
+			rules:   `{"length": 2, "min_counts": {"digit": 3}}`,
+			wantErr: ErrInvalidPolicy,
+		},
+		{
+			rules:   `{"length": 0}`,
+			wantErr: ErrInvalidPolicy,
+		},
+		{
+			rules:   `{"passphrase": true, "words": 0}`,
+			wantErr: ErrInvalidPolicy,
+		},
+		{
+			rules:   `{"passphrase": true, "wordlist": "/does/not/exist"}`,
+			wantErr: os.ErrNotExist,
+		},
+	}
+
+	for _, tt := range tests {
+		var rules Rules
+		require.NoError(t, json.Unmarshal([]byte(tt.rules), &rules))
+
+		_, _, err := rules.Generate()
+		require.ErrorIs(t, err, tt.wantErr, tt.rules)
+	}
+}
@@ -0,0 +1,102 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"bufio"
+	_ "embed" // for the EFF large wordlist
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// minWords - minimum number of distinct words of a wordlist.
+const minWords = 2
+
+var (
+	ErrInvalidWordlist = errors.New("invalid wordlist")
+
+	// effLargeWordlist - https://www.eff.org/files/2016/07/18/eff_large_wordlist.txt (7776 words, as dice rolls
+	// followed by a word), by the Electronic Frontier Foundation, under CC BY 3.0 US.
+	//go:embed eff_large_wordlist.txt
+	effLargeWordlist string
+
+	effLargeWordlistOnce  sync.Once
+	effLargeWordlistWords []string
+)
+
+// EFFLargeWordlist - words of the EFF large wordlist, embedded in pasuman. It is only parsed once: the words
+// returned must not be modified.
+func EFFLargeWordlist() []string {
+	effLargeWordlistOnce.Do(func() {
+		words, err := ParseWordlist(strings.NewReader(effLargeWordlist))
+		if err != nil {
+			panic(err)
+		}
+
+		effLargeWordlistWords = words
+	})
+
+	return effLargeWordlistWords
+}
+
+// ParseWordlist - words of a wordlist read from r: one word per line, optionally preceded by its dice roll
+// (as in EFF wordlists). Empty lines are ignored, and so are duplicate words (they would not add entropy).
+func ParseWordlist(r io.Reader) ([]string, error) {
+	var words []string
+
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+
+		if len(fields) == 2 && isDiceRoll(fields[0]) {
+			fields = fields[1:]
+		}
+
+		switch {
+		case len(fields) == 0:
+			continue
+		case len(fields) > 1:
+			return nil, fmt.Errorf("%w: line %d has more than one word", ErrInvalidWordlist, line)
+		case seen[fields[0]]:
+			continue
+		}
+
+		seen[fields[0]] = true
+		words = append(words, fields[0])
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(words) < minWords {
+		return nil, fmt.Errorf("%w: at least %d distinct words are required", ErrInvalidWordlist, minWords)
+	}
+
+	return words, nil
+}
+
+func isDiceRoll(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }) == -1
+}
@@ -0,0 +1,79 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package generate
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEFFLargeWordlist(t *testing.T) {
+	words := EFFLargeWordlist()
+
+	// 6^5 words, one for each roll of 5 dice, sorted
+	require.Len(t, words, 7776)
+	require.True(t, sort.StringsAreSorted(words))
+	require.Equal(t, "abacus", words[0])
+	require.Equal(t, "zoom", words[len(words)-1])
+}
+
+func TestParseWordlist(t *testing.T) {
+	tests := []struct {
+		wordlist string
+		want     []string
+		wantErr  error
+	}{
+		{
+			wordlist: "correct\nhorse\nbattery\nstaple\n",
+			want:     []string{"correct", "horse", "battery", "staple"},
+		},
+		{
+			wordlist: "11111\tabacus\r\n11112\tabdomen\r\n\r\n",
+			want:     []string{"abacus", "abdomen"},
+		},
+		{
+			wordlist: "  correct  \nhorse\ncorrect\n",
+			want:     []string{"correct", "horse"},
+		},
+		{
+			wordlist: "correct horse\nbattery staple\n",
+			wantErr:  ErrInvalidWordlist,
+		},
+		{
+			wordlist: "correct\ncorrect\n",
+			wantErr:  ErrInvalidWordlist,
+		},
+		{
+			wordlist: "",
+			wantErr:  ErrInvalidWordlist,
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseWordlist(strings.NewReader(tt.wordlist))
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.wordlist)
+		} else {
+			require.NoError(t, err, tt.wordlist)
+			require.Equal(t, tt.want, got, tt.wordlist)
+		}
+	}
+}
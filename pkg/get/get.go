@@ -19,21 +19,15 @@ package get
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/data"
-	"github.com/norbjd/pasuman/pkg/encrypt"
 )
 
 var ErrNotFound = errors.New("entry not found")
 
-func getEntry(uniqueID string) (data.Entry, error) {
-	var d data.Data
-
-	if err := d.FromFile(config.PasumanDataFile); err != nil {
-		return data.Entry{}, err
-	}
-
+func getEntry(d data.Data, uniqueID string) (data.Entry, error) {
 	var entry data.Entry
 
 	for _, e := range d.Entries {
@@ -52,32 +46,60 @@ func getEntry(uniqueID string) (data.Entry, error) {
 }
 
 func NotSensitive(uniqueID string) (data.Entry, error) {
-	entry, err := getEntry(uniqueID)
+	var d data.Data
+
+	if err := d.FromFile(config.PasumanDataFile); err != nil {
+		return data.Entry{}, err
+	}
+
+	entry, err := getEntry(d, uniqueID)
 	if err != nil {
 		return data.Entry{}, err
 	}
 
-	entry.ID = ""
-	entry.Password = ""
+	entry.ClearSensitive()
 
 	return entry, nil
 }
 
 func Sensitive(masterPassword string, uniqueID string) (data.Entry, error) {
-	entry, err := getEntry(uniqueID)
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
 	if err != nil {
 		return data.Entry{}, err
 	}
 
-	entry.ID, err = encrypt.Decrypt(masterPassword, entry.ID)
+	entry, err := getEntry(d, uniqueID)
 	if err != nil {
 		return data.Entry{}, err
 	}
 
-	entry.Password, err = encrypt.Decrypt(masterPassword, entry.Password)
-	if err != nil {
+	if err := entry.Decrypt(keys); err != nil {
 		return data.Entry{}, err
 	}
 
 	return entry, nil
 }
+
+// Attachment - decrypted content of attachment name of the entry uniqueID.
+func Attachment(masterPassword string, uniqueID string, name string) ([]byte, error) {
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := getEntry(d, uniqueID)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment, ok := entry.Attachment(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", data.ErrAttachmentNotFound, name)
+	}
+
+	return data.ReadAttachment(config.PasumanDataFile, keys, attachment)
+}
@@ -50,6 +50,10 @@ func TestNotSensitive(t *testing.T) {
 		Site:        "https://anothersite.pasuman",
 		ID:          "otherId",
 		Password:    "t0ps3cr3t!",
+		Fields: []data.Field{
+			{Name: "Account number", Value: "FR76-1234"},
+			{Name: "PIN", Value: "4321", Sensitive: true},
+		},
 	}
 	_, err = add.Add(pasumantest.TestMasterPassword, entry2)
 	require.NoError(t, err)
@@ -83,8 +87,7 @@ func TestNotSensitive(t *testing.T) {
 		if err != nil {
 			require.ErrorIs(t, err, tt.wantErr)
 		} else {
-			tt.want.ID = ""
-			tt.want.Password = ""
+			tt.want.ClearSensitive()
 			require.Equal(t, tt.want, got)
 		}
 	}
@@ -112,6 +115,10 @@ func TestSensitive(t *testing.T) {
 		Site:        "https://anothersite.pasuman",
 		ID:          "otherId",
 		Password:    "t0ps3cr3t!",
+		Fields: []data.Field{
+			{Name: "Account number", Value: "FR76-1234"},
+			{Name: "PIN", Value: "4321", Sensitive: true},
+		},
 	}
 	_, err = add.Add(pasumantest.TestMasterPassword, entry2)
 	require.NoError(t, err)
@@ -0,0 +1,222 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+var (
+	errEncryptedBitwardenExport = errors.New("encrypted Bitwarden exports are not supported: " +
+		"export the vault as JSON (not \"JSON (Encrypted)\") and import this file")
+	errNotBitwardenJSON = errors.New("not a Bitwarden JSON file")
+)
+
+// Types of Bitwarden items and custom fields. Secure notes (type 2) only have notes and custom fields.
+const (
+	bitwardenLogin    = 1
+	bitwardenCard     = 3
+	bitwardenIdentity = 4
+
+	bitwardenHiddenField = 1
+)
+
+// nolint: tagliatelle
+type bitwardenFile struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Collections []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"collections"`
+	Items *[]bitwardenItem `json:"items"`
+}
+
+// bitwardenItem - password history of items is ignored.
+// nolint: tagliatelle
+type bitwardenItem struct {
+	Type          int      `json:"type"`
+	Name          string   `json:"name"`
+	Notes         string   `json:"notes"`
+	FolderID      string   `json:"folderId"`
+	CollectionIDs []string `json:"collectionIds"`
+	Fields        []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+		Type  int    `json:"type"`
+	} `json:"fields"`
+	Login *struct {
+		URIs []struct {
+			URI string `json:"uri"`
+		} `json:"uris"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		TOTP     string `json:"totp"`
+	} `json:"login"`
+	Card *struct {
+		CardholderName string `json:"cardholderName"`
+		Brand          string `json:"brand"`
+		Number         string `json:"number"`
+		ExpMonth       string `json:"expMonth"`
+		ExpYear        string `json:"expYear"`
+		Code           string `json:"code"`
+	} `json:"card"`
+	Identity map[string]*string `json:"identity"`
+}
+
+// bitwardenIdentityFields - fields of identities, in the order of the Bitwarden UI, and whether they are sensitive.
+var bitwardenIdentityFields = []struct {
+	key, name string
+	sensitive bool
+}{
+	{"title", "Title", false},
+	{"firstName", "First name", false},
+	{"middleName", "Middle name", false},
+	{"lastName", "Last name", false},
+	{"company", "Company", false},
+	{"email", "Email", false},
+	{"phone", "Phone", false},
+	{"address1", "Address 1", false},
+	{"address2", "Address 2", false},
+	{"address3", "Address 3", false},
+	{"city", "City", false},
+	{"state", "State", false},
+	{"postalCode", "Postal code", false},
+	{"country", "Country", false},
+	{"ssn", "SSN", true},
+	{"passportNumber", "Passport number", true},
+	{"licenseNumber", "License number", true},
+}
+
+// parseBitwardenJSON - read entries of an unencrypted Bitwarden JSON export. Folders and collections are imported
+// as tags; URIs other than the first one and custom fields are folded into the description, except sensitive values
+// (hidden fields, card codes, etc.) that are imported as sensitive custom fields. TOTP secrets are imported as
+// one-time password keys.
+func parseBitwardenJSON(r io.Reader) ([]data.Entry, error) {
+	var file bitwardenFile
+
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", errNotBitwardenJSON, err)
+	}
+
+	if file.Encrypted {
+		return nil, errEncryptedBitwardenExport
+	}
+
+	if file.Items == nil {
+		return nil, fmt.Errorf("%w: no items", errNotBitwardenJSON)
+	}
+
+	folders := make(map[string]string, len(file.Folders))
+	for _, folder := range file.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	collections := make(map[string]string, len(file.Collections))
+	for _, collection := range file.Collections {
+		collections[collection.ID] = collection.Name
+	}
+
+	entries := make([]data.Entry, 0, len(*file.Items))
+
+	for _, item := range *file.Items {
+		tags := folderTags(folders[item.FolderID])
+		for _, collectionID := range item.CollectionIDs {
+			tags = append(tags, folderTags(collections[collectionID])...)
+		}
+
+		entries = append(entries, bitwardenEntry(item, uniqueTags(tags)))
+	}
+
+	return entries, nil
+}
+
+// nolint: funlen, gocognit
+func bitwardenEntry(item bitwardenItem, tags []string) data.Entry {
+	entry := data.Entry{UniqueID: item.Name, Tags: tags}
+
+	var extra []string
+
+	switch item.Type {
+	case bitwardenLogin:
+		if item.Login != nil {
+			for idx, uri := range item.Login.URIs {
+				if idx == 0 {
+					entry.Site = uri.URI
+				} else {
+					extra = append(extra, field("URL", uri.URI)...)
+				}
+			}
+
+			entry.ID = item.Login.Username
+			entry.Password = item.Login.Password
+
+			entry.AddOTP("TOTP", item.Login.TOTP)
+		}
+	case bitwardenCard:
+		if item.Card != nil {
+			entry.ID = item.Card.CardholderName
+			entry.Password = item.Card.Number
+
+			extra = append(extra, field("Brand", item.Card.Brand)...)
+
+			if item.Card.ExpMonth != "" || item.Card.ExpYear != "" {
+				extra = append(extra, field("Expiration", item.Card.ExpMonth+"/"+item.Card.ExpYear)...)
+			}
+
+			entry.AddSensitiveField("Security code", item.Card.Code)
+		}
+	case bitwardenIdentity:
+		for _, identityField := range bitwardenIdentityFields {
+			value := item.Identity[identityField.key]
+			if value == nil || *value == "" {
+				continue
+			}
+
+			if identityField.sensitive {
+				entry.AddSensitiveField(identityField.name, *value)
+			} else {
+				extra = append(extra, field(identityField.name, *value)...)
+			}
+		}
+
+		if username := item.Identity["username"]; username != nil {
+			entry.ID = *username
+		}
+	}
+
+	for _, customField := range item.Fields {
+		if customField.Type != bitwardenHiddenField {
+			extra = append(extra, field(customField.Name, customField.Value)...)
+		} else {
+			entry.AddSensitiveField(customField.Name, customField.Value)
+		}
+	}
+
+	entry.Description = fold(item.Notes, extra)
+
+	return entry
+}
@@ -0,0 +1,333 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/csvmap"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/encrypt"
+	"github.com/norbjd/pasuman/pkg/util"
+)
+
+// Policy - what to do when an imported entry has the same unique ID as an existing entry.
+type Policy string
+
+const (
+	// Skip - keep the existing entry, do not import the new one.
+	Skip Policy = "skip"
+	// Rename - import the new entry with a unique ID suffixed with a number (`-2`, `-3`, etc.).
+	Rename Policy = "rename"
+	// Overwrite - replace the existing entry with the new one (the existing one is kept in its history).
+	Overwrite Policy = "overwrite"
+)
+
+// Policies - all supported policies.
+var Policies = []Policy{Skip, Rename, Overwrite}
+
+// Format - format of files (or directories) that can be imported.
+type Format string
+
+const (
+	KeePassXML      Format = "keepass-xml"
+	BitwardenJSON   Format = "bitwarden-json"
+	OnePassword1PUX Format = "1password-1pux"
+	// CSV - columns can be mapped to fields of entries with ParseCSV.
+	CSV Format = "csv"
+	// Pass - a directory, read with ParsePass.
+	Pass Format = "pass"
+)
+
+// Formats - all supported formats.
+var Formats = []Format{KeePassXML, BitwardenJSON, OnePassword1PUX, CSV, Pass}
+
+var (
+	ErrInvalidPolicy = errors.New("invalid collision policy")
+	ErrInvalidFormat = errors.New("invalid import format")
+)
+
+// Result - what happened to an imported entry.
+type Result struct {
+	UniqueID string
+	// RenamedFrom - unique ID of the entry in the imported file, if it has been renamed.
+	RenamedFrom string
+	Skipped     bool
+	Overwritten bool
+}
+
+// Summary - results of an import, in the order of the imported file.
+type Summary []Result
+
+// Count - number of entries imported (including renamed and overwritten ones), renamed, overwritten and skipped.
+func (s Summary) Count() (imported, renamed, overwritten, skipped int) {
+	for _, result := range s {
+		switch {
+		case result.Skipped:
+			skipped++
+		case result.Overwritten:
+			imported++
+			overwritten++
+		case result.RenamedFrom != "":
+			imported++
+			renamed++
+		default:
+			imported++
+		}
+	}
+
+	return imported, renamed, overwritten, skipped
+}
+
+// Parse - read entries from r, in format. Sensitive data of entries is not encrypted.
+func Parse(format Format, r io.Reader) ([]data.Entry, error) {
+	switch format {
+	case KeePassXML:
+		return parseKeePassXML(r)
+	case BitwardenJSON:
+		return parseBitwardenJSON(r)
+	case OnePassword1PUX:
+		return parse1PUX(r)
+	case CSV:
+		return ParseCSV(r, csvmap.Default)
+	case Pass:
+		return nil, errPassNotAFile
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFormat, format)
+	}
+}
+
+// ParseCSV - read entries of a CSV file, whose columns are mapped to fields of entries with mapping.
+func ParseCSV(r io.Reader, mapping csvmap.Mapping) ([]data.Entry, error) {
+	return csvmap.Read(r, mapping)
+}
+
+// Import - add entries to the profile, handling unique ID collisions with policy. Entries are all encrypted,
+// and the profile file written, at once: if anything fails, nothing is imported.
+func Import(masterPassword string, entries []data.Entry, policy Policy) (Summary, error) {
+	if policy != Skip && policy != Rename && policy != Overwrite {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPolicy, policy)
+	}
+
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]int, len(d.Entries))
+	for idx, entry := range d.Entries {
+		indexes[entry.UniqueID] = idx
+	}
+
+	summary := make(Summary, 0, len(entries))
+
+	conf := config.GetConfig()
+	retention := data.Retention{Versions: conf.HistoryVersions(), MaxAge: conf.HistoryMaxAge()}
+	now := time.Now()
+
+	for _, entry := range entries {
+		entry.UniqueID = strings.TrimSpace(entry.UniqueID)
+
+		if entry.UniqueID == "" {
+			if entry.UniqueID, err = util.NewUUIDV4(); err != nil {
+				return nil, err
+			}
+		}
+
+		result := Result{UniqueID: entry.UniqueID}
+
+		index, exists := indexes[entry.UniqueID]
+
+		switch {
+		case exists && policy == Skip:
+			result.Skipped = true
+			summary = append(summary, result)
+
+			continue
+		case exists && policy == Rename:
+			result.RenamedFrom = entry.UniqueID
+			entry.UniqueID = newUniqueID(entry.UniqueID, indexes)
+			result.UniqueID = entry.UniqueID
+		case exists && policy == Overwrite:
+			result.Overwritten = true
+		}
+
+		var (
+			previous data.Entry
+			changed  []string
+		)
+
+		if result.Overwritten {
+			// the overwritten entry is kept in the history of the imported one, as by `update.Update`
+			previous = d.Entries[index]
+			decrypted := previous
+
+			if err := decrypted.Decrypt(keys); err != nil {
+				return nil, err
+			}
+
+			changed = data.Changes(decrypted, entry)
+		}
+
+		if err := encryptEntry(&entry, keys); err != nil {
+			return nil, err
+		}
+
+		if result.Overwritten {
+			// previous versions of the overwritten entry are kept, rather than the ones of the imported entry
+			entry.History = previous.History
+
+			if len(changed) > 0 {
+				entry.Record(previous, changed, now, retention)
+			}
+
+			d.Entries[index] = entry
+		} else {
+			indexes[entry.UniqueID] = len(d.Entries)
+			d.Entries = append(d.Entries, entry)
+		}
+
+		summary = append(summary, result)
+	}
+
+	return summary, d.ToFile(config.PasumanDataFile)
+}
+
+// ImportTrash - add removed entries (e.g. of an archive, see `archive.Profile`) to the trash of the profile.
+// They can then be restored as other removed entries, or dropped when the trash is purged.
+func ImportTrash(masterPassword string, trash []data.TrashedEntry) error {
+	if len(trash) == 0 {
+		return nil
+	}
+
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return err
+	}
+
+	for _, trashed := range trash {
+		if err := encryptEntry(&trashed.Entry, keys); err != nil {
+			return err
+		}
+
+		d.Trash = append(d.Trash, trashed)
+	}
+
+	return d.ToFile(config.PasumanDataFile)
+}
+
+// encryptEntry - encrypt the entry, and its previous versions if any (e.g. restored from an archive).
+func encryptEntry(entry *data.Entry, keys encrypt.Keys) error {
+	if err := entry.Encrypt(keys); err != nil {
+		return err
+	}
+
+	if entry.History == nil {
+		return nil
+	}
+
+	entry.History = append([]data.Revision(nil), entry.History...)
+
+	for idx := range entry.History {
+		if err := encryptEntry(&entry.History[idx].Entry, keys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newUniqueID - first unique ID not in indexes among uniqueID-2, uniqueID-3, etc.
+func newUniqueID(uniqueID string, indexes map[string]int) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", uniqueID, i)
+
+		if _, exists := indexes[candidate]; !exists {
+			return candidate
+		}
+	}
+}
+
+// fold - description made of notes followed by extra lines (other URLs, custom fields, etc.) that have no place
+// in an entry, so that they are not lost.
+func fold(notes string, extra []string) string {
+	lines := make([]string, 0, len(extra)+1)
+
+	if notes = strings.TrimSpace(notes); notes != "" {
+		lines = append(lines, notes)
+	}
+
+	lines = append(lines, extra...)
+
+	return strings.Join(lines, "\n")
+}
+
+// field - "name: value" line of a description, or nothing if value is empty.
+func field(name, value string) []string {
+	if value = strings.TrimSpace(value); value == "" {
+		return nil
+	}
+
+	return []string{name + ": " + value}
+}
+
+// folderTags - tags of a folder, one per level (folders are nested with "/", e.g. "Internet/Shopping").
+func folderTags(folder string) []string {
+	var tags []string
+
+	for _, name := range strings.Split(folder, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			tags = append(tags, name)
+		}
+	}
+
+	return tags
+}
+
+// uniqueTags - tags without duplicates, in order.
+func uniqueTags(tags []string) []string {
+	var unique []string
+
+	seen := make(map[string]bool, len(tags))
+
+	for _, tag := range tags {
+		if !seen[tag] {
+			seen[tag] = true
+			unique = append(unique, tag)
+		}
+	}
+
+	return unique
+}
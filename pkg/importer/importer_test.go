@@ -0,0 +1,623 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func parseFile(t *testing.T, format Format, file string) []data.Entry {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join("testdata", file))
+	require.NoError(t, err)
+
+	defer f.Close()
+
+	entries, err := Parse(format, f)
+	require.NoError(t, err)
+
+	return entries
+}
+
+// decryptedEntries - entries of the profile, with sensitive data decrypted.
+func decryptedEntries(t *testing.T) []data.Entry {
+	t.Helper()
+
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+
+	for idx := range d.Entries {
+		require.NoError(t, d.Entries[idx].Decrypt(keys))
+	}
+
+	return d.Entries
+}
+
+func TestParseKeePassXML(t *testing.T) {
+	entries := parseFile(t, KeePassXML, "keepass.xml")
+
+	require.Equal(t, []data.Entry{
+		{
+			UniqueID:    "mail",
+			Description: "Personal mailbox\nSecond line",
+			Site:        "https://mail.pasuman",
+			ID:          "me@mail.pasuman",
+			Password:    "p4$$w0rd!&<",
+		},
+		{
+			UniqueID: "forum",
+			Tags:     []string{"Internet"},
+			Site:     "https://forum.pasuman",
+			ID:       "myId",
+			Password: "t0ps3cr3t!",
+		},
+		{
+			UniqueID: "forum",
+			Tags:     []string{"Internet", "Shopping"},
+			ID:       "shopper",
+			Password: "sh0pp1ng",
+		},
+	}, entries)
+}
+
+func TestParseKeePassXMLCustomStrings(t *testing.T) {
+	entries, err := Parse(KeePassXML, strings.NewReader(`<?xml version="1.0"?>
+<KeePassFile>
+	<Root>
+		<Group>
+			<Name>Root</Name>
+			<Entry>
+				<String><Key>Notes</Key><Value>Bank account</Value></String>
+				<String><Key>PIN</Key><Value ProtectInMemory="True">1234</Value></String>
+				<String><Key>Password</Key><Value ProtectInMemory="True">s3cr3t</Value></String>
+				<String><Key>Recovery email</Key><Value>me@mail.pasuman</Value></String>
+				<String><Key>Security answer</Key><Value Protected="True">blue</Value></String>
+				<String><Key>Title</Key><Value>bank</Value></String>
+				<String><Key>Empty</Key><Value></Value></String>
+			</Entry>
+		</Group>
+	</Root>
+</KeePassFile>`))
+	require.NoError(t, err)
+
+	require.Equal(t, []data.Entry{
+		{
+			UniqueID:    "bank",
+			Description: "Bank account\nRecovery email: me@mail.pasuman",
+			Password:    "s3cr3t",
+			Fields: []data.Field{
+				{Name: "PIN", Value: "1234", Sensitive: true},
+				{Name: "Security answer", Value: "blue", Sensitive: true},
+			},
+		},
+	}, entries)
+}
+
+func TestParseKeePassXMLOneTimePassword(t *testing.T) {
+	tests := []struct {
+		strings   string
+		wantEntry data.Entry
+	}{
+		{
+			strings: `<String><Key>otp</Key><Value>otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP</Value></String>`,
+			wantEntry: data.Entry{
+				UniqueID: "mail",
+				OTP:      "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP",
+			},
+		},
+		{
+			strings: `<String><Key>TOTP Seed</Key><Value>JBSWY3DPEHPK3PXP</Value></String>
+				<String><Key>TOTP Settings</Key><Value>60;8</Value></String>`,
+			wantEntry: data.Entry{
+				UniqueID: "mail",
+				OTP:      "otpauth://totp/?digits=8&period=60&secret=JBSWY3DPEHPK3PXP",
+			},
+		},
+		{
+			strings: `<String><Key>TOTP Seed</Key><Value>JBSWY3DPEHPK3PXP</Value></String>`,
+			wantEntry: data.Entry{
+				UniqueID: "mail",
+				OTP:      "JBSWY3DPEHPK3PXP",
+			},
+		},
+		{
+			strings: `<String><Key>TOTP Seed</Key><Value>JBSWY3DPEHPK3PXP</Value></String>
+				<String><Key>TOTP Settings</Key><Value>30;S</Value></String>`,
+			wantEntry: data.Entry{
+				UniqueID:    "mail",
+				Description: "TOTP Settings: 30;S",
+				Fields:      []data.Field{{Name: "TOTP Seed", Value: "JBSWY3DPEHPK3PXP", Sensitive: true}},
+			},
+		},
+		{
+			strings: `<String><Key>otp</Key><Value>otpauth://hotp/mail</Value></String>`,
+			wantEntry: data.Entry{
+				UniqueID: "mail",
+				Fields:   []data.Field{{Name: "otp", Value: "otpauth://hotp/mail", Sensitive: true}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		entries, err := Parse(KeePassXML, strings.NewReader(`<?xml version="1.0"?>
+<KeePassFile>
+	<Root>
+		<Group>
+			<Name>Root</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>mail</Value></String>
+				`+tt.strings+`
+			</Entry>
+		</Group>
+	</Root>
+</KeePassFile>`))
+		require.NoError(t, err, tt.strings)
+		require.Equal(t, []data.Entry{tt.wantEntry}, entries, tt.strings)
+	}
+}
+
+func TestParseKeePassInvalid(t *testing.T) {
+	_, err := Parse(KeePassXML, strings.NewReader("\x03\xd9\xa2\x9a\x67\xfb\x4b\xb5 binary database"))
+	require.ErrorIs(t, err, errKDBXNotSupported)
+
+	_, err = Parse(KeePassXML, strings.NewReader(`<?xml version="1.0"?><html></html>`))
+	require.ErrorIs(t, err, errNotKeePassXML)
+
+	_, err = Parse("unknown", strings.NewReader(""))
+	require.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestParseBitwardenJSON(t *testing.T) {
+	entries := parseFile(t, BitwardenJSON, "bitwarden.json")
+
+	require.Equal(t, []data.Entry{
+		{
+			UniqueID: "mail",
+			Description: "Personal mailbox\n" +
+				"URL: https://webmail.pasuman\n" +
+				"Recovery email: backup@mail.pasuman",
+			Site:     "https://mail.pasuman",
+			ID:       "me@mail.pasuman",
+			Password: "p4$$w0rd!",
+			Fields:   []data.Field{{Name: "Recovery code", Value: "R3C0V3RY", Sensitive: true}},
+			OTP:      "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP",
+		},
+		{
+			UniqueID: "shop",
+			Tags:     []string{"Internet", "Shopping"},
+			Site:     "https://shop.pasuman",
+			ID:       "shopper",
+			Password: "sh0pp1ng",
+		},
+		{
+			UniqueID:    "wifi",
+			Description: "Network: pasuman\nKey: in the drawer",
+			Tags:        []string{"Internet"},
+		},
+		{
+			UniqueID:    "card",
+			Description: "Brand: Visa\nExpiration: 12/2030",
+			ID:          "John Doe",
+			Password:    "4111111111111111",
+			Fields:      []data.Field{{Name: "Security code", Value: "123", Sensitive: true}},
+		},
+		{
+			UniqueID: "me",
+			Description: "Title: Mr\n" +
+				"First name: John\n" +
+				"Last name: Doe\n" +
+				"Email: john@doe.pasuman\n" +
+				"City: Paris\n" +
+				"Country: FR",
+			ID:     "jdoe",
+			Fields: []data.Field{{Name: "SSN", Value: "123-45-6789", Sensitive: true}},
+		},
+	}, entries)
+}
+
+func TestParseBitwardenInvalid(t *testing.T) {
+	_, err := Parse(BitwardenJSON, strings.NewReader(`{"encrypted": true, "encKeyValidation_DO_NOT_EDIT": "2.xxx"}`))
+	require.ErrorIs(t, err, errEncryptedBitwardenExport)
+
+	_, err = Parse(BitwardenJSON, strings.NewReader(`{"entries": []}`))
+	require.ErrorIs(t, err, errNotBitwardenJSON)
+
+	_, err = Parse(BitwardenJSON, strings.NewReader("name,login_uri\n"))
+	require.ErrorIs(t, err, errNotBitwardenJSON)
+}
+
+func TestParse1PUX(t *testing.T) {
+	entries := parseFile(t, OnePassword1PUX, "1password.1pux")
+
+	require.Equal(t, []data.Entry{
+		{
+			UniqueID: "mail",
+			Description: "Personal mailbox\n" +
+				"URL: https://webmail.pasuman\n" +
+				"Recovery email: backup@mail.pasuman",
+			Tags:     []string{"Personal", "Internet", "Mail"},
+			Site:     "https://mail.pasuman",
+			ID:       "me@mail.pasuman",
+			Password: "p4$$w0rd!",
+			Fields:   []data.Field{{Name: "Recovery code", Value: "R3C0V3RY", Sensitive: true}},
+			OTP:      "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP",
+		},
+		{
+			UniqueID: "router",
+			Tags:     []string{"Personal", "Archived"},
+			Password: "0ldr0ut3r",
+		},
+		{
+			UniqueID: "card",
+			Description: "cardholder name: John Doe\n" +
+				"type: visa\n" +
+				"number: (imported as password)\n" +
+				"expiry date: 12/2030",
+			Tags:     []string{"Personal", "Finance"},
+			Password: "4111111111111111",
+			Fields:   []data.Field{{Name: "verification number", Value: "123", Sensitive: true}},
+		},
+		{
+			UniqueID:    "wifi",
+			Description: "Network: pasuman\nKey: in the drawer",
+			Tags:        []string{"Shared"},
+		},
+	}, entries)
+}
+
+func TestOnePasswordEntry(t *testing.T) {
+	var item onePasswordItem
+
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"details": {
+			"loginFields": [
+				{"value": "me@bank.pasuman", "name": "login", "fieldType": "T", "designation": "username"},
+				{"value": "p4$$w0rd!", "name": "password", "fieldType": "P", "designation": "password"},
+				{"value": "1234", "name": "pin", "fieldType": "P", "designation": ""},
+				{"value": "FR76", "name": "account", "fieldType": "T", "designation": ""},
+				{"value": "", "name": "remember", "fieldType": "C", "designation": ""}
+			],
+			"sections": [
+				{
+					"title": "",
+					"fields": [
+						{"title": "Branch", "value": {"string": "Paris", "concealed": "s3cr3t"}}
+					]
+				}
+			]
+		},
+		"overview": {"title": "bank"}
+	}`), &item))
+
+	require.Equal(t, data.Entry{
+		UniqueID:    "bank",
+		Description: "account: FR76\nBranch: Paris",
+		Tags:        []string{"Personal"},
+		ID:          "me@bank.pasuman",
+		Password:    "p4$$w0rd!",
+		Fields: []data.Field{
+			{Name: "pin", Value: "1234", Sensitive: true},
+			{Name: "Branch", Value: "s3cr3t", Sensitive: true},
+		},
+	}, onePasswordEntry("Personal", item))
+}
+
+func TestParse1PUXInvalid(t *testing.T) {
+	_, err := Parse(OnePassword1PUX, strings.NewReader(`{"accounts": []}`))
+	require.ErrorIs(t, err, errNot1PUX)
+
+	var archive bytes.Buffer
+
+	w := zip.NewWriter(&archive)
+	_, err = w.Create("export.attributes")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	_, err = Parse(OnePassword1PUX, &archive)
+	require.ErrorIs(t, err, errNot1PUX)
+}
+
+func TestParseCSV(t *testing.T) {
+	// by default, columns are named after fields
+	entries, err := Parse(CSV, strings.NewReader("unique_id,site,id,password\nmail,https://mail.pasuman,me,p4$$w0rd!\n"))
+	require.NoError(t, err)
+	require.Equal(t, []data.Entry{
+		{UniqueID: "mail", Site: "https://mail.pasuman", ID: "me", Password: "p4$$w0rd!"},
+	}, entries)
+}
+
+func TestParsePass(t *testing.T) {
+	// files of the test password store are not encrypted
+	entries, err := ParsePass(filepath.Join("testdata", "password-store"), os.ReadFile)
+	require.NoError(t, err)
+
+	require.Equal(t, []data.Entry{
+		{
+			UniqueID: "shop",
+			Tags:     []string{"Internet", "Shopping"},
+			ID:       "shopper",
+			Password: "sh0pp1ng",
+		},
+		{
+			UniqueID: "forum",
+			Tags:     []string{"Internet"},
+			Site:     "https://forum.pasuman",
+			ID:       "myId",
+			Password: "t0ps3cr3t!",
+		},
+		{
+			UniqueID:    "mail",
+			Description: "Personal mailbox",
+			Site:        "https://mail.pasuman",
+			ID:          "me@mail.pasuman",
+			Password:    "p4$$w0rd!",
+			OTP:         "otpauth://totp/mail?secret=JBSWY3DPEHPK3PXP",
+		},
+	}, entries)
+}
+
+func TestParsePassSameNames(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, file := range []string{"mail.gpg", "work/github.gpg", "personal/github.gpg"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(file)), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte("p4$$w0rd!"), 0o600))
+	}
+
+	entries, err := ParsePass(dir, os.ReadFile)
+	require.NoError(t, err)
+
+	require.Equal(t, []data.Entry{
+		{UniqueID: "mail", Password: "p4$$w0rd!"},
+		{UniqueID: "personal/github", Tags: []string{"personal"}, Password: "p4$$w0rd!"},
+		{UniqueID: "work/github", Tags: []string{"work"}, Password: "p4$$w0rd!"},
+	}, entries)
+}
+
+func TestParsePassInvalid(t *testing.T) {
+	_, err := Parse(Pass, strings.NewReader(""))
+	require.ErrorIs(t, err, errPassNotAFile)
+
+	_, err = ParsePass(filepath.Join("testdata", "keepass.xml"), os.ReadFile)
+	require.ErrorIs(t, err, errNotPasswordStore)
+
+	_, err = ParsePass(filepath.Join("testdata", "unknown"), os.ReadFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	errNoKey := errors.New("no secret key")
+
+	_, err = ParsePass(filepath.Join("testdata", "password-store"), func(string) ([]byte, error) {
+		return nil, errNoKey
+	})
+	require.ErrorIs(t, err, errDecryptionFailed)
+	require.ErrorContains(t, err, errNoKey.Error())
+}
+
+func TestImport(t *testing.T) {
+	existing := data.Entry{UniqueID: "forum", Description: "Existing", ID: "existingId", Password: "3x1st1ng"}
+
+	tests := []struct {
+		policy      Policy
+		wantSummary Summary
+		wantEntries []data.Entry
+		// wantChanged - fields changed by each version kept in the history of the entries, by unique ID
+		wantChanged map[string][][]string
+	}{
+		{
+			policy: Skip,
+			wantSummary: Summary{
+				{UniqueID: "mail"},
+				{UniqueID: "forum", Skipped: true},
+				{UniqueID: "forum", Skipped: true},
+			},
+			wantEntries: []data.Entry{
+				existing,
+				{
+					UniqueID:    "mail",
+					Description: "Personal mailbox\nSecond line",
+					Site:        "https://mail.pasuman",
+					ID:          "me@mail.pasuman",
+					Password:    "p4$$w0rd!&<",
+				},
+			},
+		},
+		{
+			policy: Rename,
+			wantSummary: Summary{
+				{UniqueID: "mail"},
+				{UniqueID: "forum-2", RenamedFrom: "forum"},
+				{UniqueID: "forum-3", RenamedFrom: "forum"},
+			},
+			wantEntries: []data.Entry{
+				existing,
+				{
+					UniqueID:    "mail",
+					Description: "Personal mailbox\nSecond line",
+					Site:        "https://mail.pasuman",
+					ID:          "me@mail.pasuman",
+					Password:    "p4$$w0rd!&<",
+				},
+				{
+					UniqueID: "forum-2",
+					Tags:     []string{"Internet"},
+					Site:     "https://forum.pasuman",
+					ID:       "myId",
+					Password: "t0ps3cr3t!",
+				},
+				{
+					UniqueID: "forum-3",
+					Tags:     []string{"Internet", "Shopping"},
+					ID:       "shopper",
+					Password: "sh0pp1ng",
+				},
+			},
+		},
+		{
+			policy: Overwrite,
+			wantSummary: Summary{
+				{UniqueID: "mail"},
+				{UniqueID: "forum", Overwritten: true},
+				{UniqueID: "forum", Overwritten: true},
+			},
+			wantEntries: []data.Entry{
+				{
+					UniqueID: "forum",
+					Tags:     []string{"Internet", "Shopping"},
+					ID:       "shopper",
+					Password: "sh0pp1ng",
+				},
+				{
+					UniqueID:    "mail",
+					Description: "Personal mailbox\nSecond line",
+					Site:        "https://mail.pasuman",
+					ID:          "me@mail.pasuman",
+					Password:    "p4$$w0rd!&<",
+				},
+			},
+			wantChanged: map[string][][]string{
+				"forum": {
+					{"description", "tags", "site", "id", "password"},
+					{"tags", "site", "id", "password"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+
+		_, err := add.Add(pasumantest.TestMasterPassword, existing)
+		require.NoError(t, err)
+
+		summary, err := Import(pasumantest.TestMasterPassword, parseFile(t, KeePassXML, "keepass.xml"), tt.policy)
+		require.NoError(t, err, tt.policy)
+		require.Equal(t, tt.wantSummary, summary, tt.policy)
+
+		entries := decryptedEntries(t)
+		changed := make(map[string][][]string)
+
+		for idx := range entries {
+			for _, revision := range entries[idx].History {
+				changed[entries[idx].UniqueID] = append(changed[entries[idx].UniqueID], revision.Changed)
+			}
+
+			entries[idx].History = nil
+		}
+
+		require.Equal(t, tt.wantEntries, entries, tt.policy)
+
+		if tt.wantChanged == nil {
+			tt.wantChanged = map[string][][]string{}
+		}
+
+		require.Equal(t, tt.wantChanged, changed, tt.policy)
+
+		if tt.policy == Overwrite {
+			// the overwritten entry is the first version of the imported one
+			var d data.Data
+
+			keys, err := d.Open(config.PasumanDataFile, pasumantest.TestMasterPassword)
+			require.NoError(t, err)
+
+			revision, ok := d.Entries[0].Revision(1)
+			require.True(t, ok)
+			require.NoError(t, revision.Entry.Decrypt(keys))
+			require.Equal(t, existing, revision.Entry)
+		}
+
+		os.RemoveAll(tempDir)
+	}
+}
+
+func TestImportInvalidPolicy(t *testing.T) {
+	_, err := Import(pasumantest.TestMasterPassword, nil, "unknown")
+	require.ErrorIs(t, err, ErrInvalidPolicy)
+}
+
+func TestImportGeneratesUniqueIDs(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	summary, err := Import(pasumantest.TestMasterPassword, []data.Entry{{Password: "p4$$w0rd!"}}, Skip)
+	require.NoError(t, err)
+	require.Len(t, summary, 1)
+	require.NotEmpty(t, summary[0].UniqueID)
+
+	imported, renamed, overwritten, skipped := summary.Count()
+	require.Equal(t, []int{1, 0, 0, 0}, []int{imported, renamed, overwritten, skipped})
+}
+
+func TestImportTrash(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	previous := data.Entry{UniqueID: "id1", ID: "myId", Password: "p4$$w0rd!",
+		Attachments: []data.Attachment{{Name: "key.txt", Content: []byte("s3cr3t k3y")}}}
+	trashed := data.TrashedEntry{
+		DeletedAt: "2022-01-01T00:00:00Z",
+		Entry: data.Entry{UniqueID: "id1", ID: "myId", Password: "n3wp4$$w0rd!", History: []data.Revision{
+			{Version: 1, ReplacedAt: "2021-01-01T00:00:00Z", Changed: []string{"password"}, Entry: previous},
+		}},
+	}
+
+	require.NoError(t, ImportTrash(pasumantest.TestMasterPassword, []data.TrashedEntry{trashed}))
+
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+	require.Len(t, d.Trash, 1)
+
+	// the removed entry and its previous versions are encrypted, attachments are written to blobs
+	entry := d.Trash[0].Entry
+	require.NotEqual(t, "n3wp4$$w0rd!", entry.Password)
+	require.NoError(t, entry.Decrypt(keys))
+	require.Equal(t, "n3wp4$$w0rd!", entry.Password)
+
+	revision := entry.History[0].Entry
+	require.NotEqual(t, "p4$$w0rd!", revision.Password)
+	require.NoError(t, revision.Decrypt(keys))
+	require.Equal(t, "p4$$w0rd!", revision.Password)
+
+	require.Nil(t, revision.Attachments[0].Content)
+	require.NotEmpty(t, revision.Attachments[0].Blob)
+
+	content, err := data.ReadAttachment(config.PasumanDataFile, keys, revision.Attachments[0])
+	require.NoError(t, err)
+	require.Equal(t, []byte("s3cr3t k3y"), content)
+
+	// the trashed entry given is left as is
+	require.Equal(t, "p4$$w0rd!", trashed.Entry.History[0].Entry.Password)
+}
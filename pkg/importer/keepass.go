@@ -0,0 +1,212 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+// kdbxSignature - first bytes of KeePass databases (KDBX files).
+var kdbxSignature = []byte{0x03, 0xd9, 0xa2, 0x9a, 0x67, 0xfb, 0x4b, 0xb5}
+
+var (
+	errKDBXNotSupported = errors.New("KDBX files are not supported: export the database as XML " +
+		"(e.g. in KeePassXC: Database > Export > XML File) and import this file")
+	errNotKeePassXML = errors.New("not a KeePass XML file")
+)
+
+type keePassFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    struct {
+		RecycleBinEnabled string `xml:"RecycleBinEnabled"`
+		RecycleBinUUID    string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keePassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keePassEntry `xml:"Entry"`
+	Groups  []keePassGroup `xml:"Group"`
+}
+
+// keePassEntry - history of entries (in a `History` element) is ignored.
+type keePassEntry struct {
+	Strings []keePassString `xml:"String"`
+}
+
+// keePassString - string of an entry: protected strings have a ProtectInMemory (in XML exports) or Protected (in
+// databases) attribute.
+type keePassString struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Text            string `xml:",chardata"`
+		ProtectInMemory string `xml:"ProtectInMemory,attr"`
+		Protected       string `xml:"Protected,attr"`
+	} `xml:"Value"`
+}
+
+func (s keePassString) protected() bool {
+	return strings.EqualFold(s.Value.ProtectInMemory, "true") || strings.EqualFold(s.Value.Protected, "true")
+}
+
+// Keys of strings of one-time passwords: otp (an otpauth:// URI, set by KeePassXC), or TOTP Seed and TOTP Settings
+// ("<period>;<digits>"), set by older versions of KeePassXC and by the KeeTrayTOTP plugin of KeePass.
+const (
+	keePassOTP          = "otp"
+	keePassTOTPSeed     = "TOTP Seed"
+	keePassTOTPSettings = "TOTP Settings"
+)
+
+// keePassStandardKeys - keys of strings every entry has.
+var keePassStandardKeys = map[string]bool{"Title": true, "Notes": true, "URL": true, "UserName": true,
+	"Password": true}
+
+func (e keePassEntry) get(key string) string {
+	for _, s := range e.Strings {
+		if s.Key == key {
+			return s.Value.Text
+		}
+	}
+
+	return ""
+}
+
+// parseKeePassXML - read entries of a KeePass XML export (KeePass 2.x format, as exported by KeePass and KeePassXC).
+// Names of groups an entry is in, except the root group, are its tags. Entries of the recycle bin are ignored.
+func parseKeePassXML(r io.Reader) ([]data.Entry, error) {
+	reader := bufio.NewReader(r)
+
+	if header, err := reader.Peek(len(kdbxSignature)); err == nil && bytes.Equal(header, kdbxSignature) {
+		return nil, errKDBXNotSupported
+	}
+
+	var file keePassFile
+
+	if err := xml.NewDecoder(reader).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", errNotKeePassXML, err)
+	}
+
+	recycleBinUUID := ""
+	if strings.EqualFold(file.Meta.RecycleBinEnabled, "true") {
+		recycleBinUUID = file.Meta.RecycleBinUUID
+	}
+
+	var entries []data.Entry
+
+	for _, rootGroup := range file.Root.Groups {
+		entries = append(entries, keePassGroupEntries(rootGroup, nil, recycleBinUUID)...)
+	}
+
+	return entries, nil
+}
+
+// keePassEntryOf - entry of a KeePass entry: one-time password strings are its one-time password key, protected
+// custom strings are sensitive custom fields, and other custom strings are added to the description.
+func keePassEntryOf(e keePassEntry, tags []string) data.Entry {
+	entry := data.Entry{
+		UniqueID: e.get("Title"),
+		Tags:     tags,
+		Site:     e.get("URL"),
+		ID:       e.get("UserName"),
+		Password: e.get("Password"),
+	}
+
+	var extra []string
+
+	totpKey, totpKeyOK := keePassTOTPKey(e.get(keePassTOTPSeed), e.get(keePassTOTPSettings))
+
+	for _, s := range e.Strings {
+		switch {
+		case keePassStandardKeys[s.Key]:
+		case s.Key == keePassOTP:
+			entry.AddOTP(s.Key, s.Value.Text)
+		case s.Key == keePassTOTPSeed && totpKeyOK:
+			entry.AddOTP(s.Key, totpKey)
+		case s.Key == keePassTOTPSeed:
+			entry.AddSensitiveField(s.Key, s.Value.Text)
+		case s.Key == keePassTOTPSettings && totpKeyOK:
+		case s.protected():
+			entry.AddSensitiveField(s.Key, s.Value.Text)
+		default:
+			extra = append(extra, field(s.Key, s.Value.Text)...)
+		}
+	}
+
+	entry.Description = fold(e.get("Notes"), extra)
+
+	return entry
+}
+
+// keePassTOTPKey - one-time password key of the legacy TOTP Seed and TOTP Settings strings, and whether they can
+// be converted (e.g. settings of Steam codes cannot).
+func keePassTOTPKey(seed, settings string) (string, bool) {
+	if seed = strings.TrimSpace(seed); seed == "" {
+		return "", false
+	}
+
+	if settings = strings.TrimSpace(settings); settings == "" {
+		return seed, true
+	}
+
+	period, digits, found := strings.Cut(settings, ";")
+	if !found {
+		return "", false
+	}
+
+	for _, n := range []string{period, digits} {
+		if _, err := strconv.Atoi(n); err != nil {
+			return "", false
+		}
+	}
+
+	return "otpauth://totp/?" + url.Values{"secret": {seed}, "period": {period}, "digits": {digits}}.Encode(), true
+}
+
+func keePassGroupEntries(group keePassGroup, tags []string, recycleBinUUID string) []data.Entry {
+	entries := make([]data.Entry, 0, len(group.Entries))
+
+	for _, e := range group.Entries {
+		entries = append(entries, keePassEntryOf(e, tags))
+	}
+
+	for _, subGroup := range group.Groups {
+		if recycleBinUUID != "" && subGroup.UUID == recycleBinUUID {
+			continue
+		}
+
+		subGroupTags := append(append([]string{}, tags...), subGroup.Name)
+
+		entries = append(entries, keePassGroupEntries(subGroup, subGroupTags, recycleBinUUID)...)
+	}
+
+	return entries
+}
@@ -0,0 +1,312 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+// onePasswordDataFile - file of a 1PUX archive containing accounts, vaults and items (other files are attachments).
+const onePasswordDataFile = "export.data"
+
+// onePasswordArchivedTag - tag of archived items.
+const onePasswordArchivedTag = "Archived"
+
+var errNot1PUX = errors.New("not a 1Password 1PUX file")
+
+// nolint: tagliatelle
+type onePasswordFile struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+// onePasswordItem - password history of items is ignored.
+// nolint: tagliatelle
+type onePasswordItem struct {
+	State   string `json:"state"`
+	Details struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Name        string `json:"name"`
+			FieldType   string `json:"fieldType"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                     `json:"title"`
+				Value map[string]json.RawMessage `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+	Overview struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+		Tags []string `json:"tags"`
+	} `json:"overview"`
+}
+
+// onePasswordPasswordFieldType - type of login fields of web forms that are passwords (e.g. a PIN besides the
+// password), imported as sensitive custom fields.
+const onePasswordPasswordFieldType = "P"
+
+// importedAsPassword - value of the extra sensitive field imported as password.
+const importedAsPassword = "(imported as password)"
+
+// onePasswordSensitiveTypes - types of values of section fields that are not folded into the description, but
+// imported as sensitive custom fields.
+var onePasswordSensitiveTypes = map[string]bool{
+	"concealed":        true,
+	"totp":             true,
+	"creditCardNumber": true,
+	"sshKey":           true,
+}
+
+// parse1PUX - read entries of a 1Password export (1PUX archive). Vaults and tags (one per level of nested tags) are
+// imported as tags, and archived items are tagged "Archived"; URLs other than the main one, other login fields
+// and fields of sections are folded into the description, except sensitive values (password login fields,
+// concealed fields, one-time password secrets, card numbers, etc.) that are imported as sensitive custom fields (or
+// as the one-time password key); the first of them is the password of items without one (e.g. credit cards).
+// Attachments are not imported.
+func parse1PUX(r io.Reader) ([]data.Entry, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNot1PUX, err)
+	}
+
+	dataFile, err := archive.Open(onePasswordDataFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errNot1PUX, err)
+	}
+	defer dataFile.Close()
+
+	var file onePasswordFile
+
+	if err := json.NewDecoder(dataFile).Decode(&file); err != nil {
+		return nil, fmt.Errorf("%w: %v", errNot1PUX, err)
+	}
+
+	var entries []data.Entry
+
+	for _, account := range file.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				entries = append(entries, onePasswordEntry(vault.Attrs.Name, item))
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// nolint: funlen, gocognit
+func onePasswordEntry(vault string, item onePasswordItem) data.Entry {
+	entry := data.Entry{
+		UniqueID: item.Overview.Title,
+		Site:     item.Overview.URL,
+		Password: item.Details.Password,
+	}
+
+	tags := []string{vault}
+	for _, tag := range item.Overview.Tags {
+		tags = append(tags, folderTags(tag)...)
+	}
+
+	if item.State == "archived" {
+		tags = append(tags, onePasswordArchivedTag)
+	}
+
+	entry.Tags = uniqueTags(tags)
+
+	var extra []string
+
+	for _, url := range item.Overview.URLs {
+		if url.URL == entry.Site {
+			continue
+		}
+
+		if entry.Site == "" {
+			entry.Site = url.URL
+		} else {
+			extra = append(extra, field("URL", url.URL)...)
+		}
+	}
+
+	for _, loginField := range item.Details.LoginFields {
+		switch {
+		case loginField.Designation == "username":
+			entry.ID = loginField.Value
+		case loginField.Designation == "password":
+			entry.Password = loginField.Value
+		case loginField.FieldType == onePasswordPasswordFieldType:
+			entry.AddSensitiveField(loginField.Name, loginField.Value)
+		default:
+			extra = append(extra, field(loginField.Name, loginField.Value)...)
+		}
+	}
+
+	for _, section := range item.Details.Sections {
+		for _, sectionField := range section.Fields {
+			// a value has a single type, but types are sorted so entries are the same on every import anyway
+			valueTypes := make([]string, 0, len(sectionField.Value))
+			for valueType := range sectionField.Value {
+				valueTypes = append(valueTypes, valueType)
+			}
+
+			sort.Strings(valueTypes)
+
+			for _, valueType := range valueTypes {
+				value := sectionField.Value[valueType]
+
+				if onePasswordSensitiveTypes[valueType] {
+					secret := onePasswordSecret(valueType, value)
+
+					switch {
+					case valueType == "totp":
+						entry.AddOTP(sectionField.Title, secret)
+					// items without password (e.g. credit cards): their first secret is imported as password
+					case entry.Password == "" && secret != "":
+						entry.Password = secret
+						extra = append(extra, field(sectionField.Title, importedAsPassword)...)
+					default:
+						entry.AddSensitiveField(sectionField.Title, secret)
+					}
+				} else {
+					extra = append(extra, field(sectionField.Title, onePasswordValue(valueType, value))...)
+				}
+			}
+		}
+	}
+
+	entry.Description = fold(item.Details.NotesPlain, extra)
+
+	return entry
+}
+
+// onePasswordSecret - value of a sensitive section field: the private key of SSH keys, the value as is for other
+// types (or its JSON if it is not a string, so it is not lost).
+func onePasswordSecret(valueType string, value json.RawMessage) string {
+	var secret string
+
+	if valueType == "sshKey" {
+		var sshKey struct {
+			PrivateKey string `json:"privateKey"`
+		}
+
+		if json.Unmarshal(value, &sshKey) == nil && sshKey.PrivateKey != "" {
+			return sshKey.PrivateKey
+		}
+	}
+
+	if json.Unmarshal(value, &secret) == nil {
+		return secret
+	}
+
+	if string(value) == "null" {
+		return ""
+	}
+
+	return string(value)
+}
+
+// onePasswordValue - printable value of a section field, or nothing if its type is not supported (e.g. files).
+// nolint: gomnd
+func onePasswordValue(valueType string, value json.RawMessage) string {
+	switch valueType {
+	case "email":
+		var email struct {
+			Address string `json:"email_address"`
+		}
+
+		if json.Unmarshal(value, &email) == nil {
+			return email.Address
+		}
+	case "address":
+		var address struct {
+			Street  string `json:"street"`
+			City    string `json:"city"`
+			Country string `json:"country"`
+			Zip     string `json:"zip"`
+			State   string `json:"state"`
+		}
+
+		if json.Unmarshal(value, &address) == nil {
+			var parts []string
+
+			for _, part := range []string{address.Street, address.Zip, address.City, address.State, address.Country} {
+				if part != "" {
+					parts = append(parts, part)
+				}
+			}
+
+			return strings.Join(parts, ", ")
+		}
+	case "date":
+		var timestamp int64
+
+		if json.Unmarshal(value, &timestamp) == nil && timestamp != 0 {
+			return time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+		}
+	case "monthYear":
+		var monthYear int
+
+		if json.Unmarshal(value, &monthYear) == nil && monthYear != 0 {
+			return fmt.Sprintf("%02d/%d", monthYear%100, monthYear/100)
+		}
+	default:
+		var s string
+
+		if json.Unmarshal(value, &s) == nil {
+			return s
+		}
+
+		var number json.Number
+
+		if json.Unmarshal(value, &number) == nil {
+			return number.String()
+		}
+	}
+
+	return ""
+}
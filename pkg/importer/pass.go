@@ -0,0 +1,167 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+// passExtension - extension of encrypted files of a password store.
+const passExtension = ".gpg"
+
+var (
+	errNotPasswordStore = errors.New("not a password store directory")
+	errDecryptionFailed = errors.New("cannot decrypt")
+	errPassNotAFile     = errors.New("a password store is a directory, not a file")
+)
+
+// Decrypter - return the content of an encrypted file of a password store.
+type Decrypter func(file string) ([]byte, error)
+
+// GPG - decrypt files with gpg, as pass does. gpg may ask the passphrase of the key (through its agent).
+func GPG(file string) ([]byte, error) {
+	gpg := exec.Command("gpg", "--quiet", "--decrypt", file)
+	gpg.Stderr = os.Stderr
+
+	return gpg.Output()
+}
+
+// ParsePass - read entries of a password store (the directory tree of pass, usually ~/.password-store), decrypting
+// files with decrypt. Directories of a file are its tags and its name (without extension) its unique ID, or its
+// path in the store if other files have the same name (see `passUniqueIDs`). The first
+// line of a file is the password, then `login:` or `user:` lines are the ID and `url:` lines the site; other
+// lines are the description, except otpauth:// URIs that are the one-time password key. Hidden files and
+// directories (e.g. .git) are ignored.
+func ParsePass(dir string, decrypt Decrypter) ([]data.Entry, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%w: %s", errNotPasswordStore, dir)
+	}
+
+	var (
+		entries []data.Entry
+		paths   []string
+	)
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if d.IsDir() || !strings.HasSuffix(d.Name(), passExtension) {
+			return nil
+		}
+
+		content, err := decrypt(path)
+		if err != nil {
+			return fmt.Errorf("%w %s: %v", errDecryptionFailed, path, err)
+		}
+
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, passEntry(relativePath, content))
+		paths = append(paths, relativePath)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	passUniqueIDs(entries, paths)
+
+	return entries, nil
+}
+
+// passUniqueIDs - unique IDs of entries read from files of paths (relative to the store) whose names are not
+// unique in the store (e.g. work/github and personal/github): their path (without extension) instead of their name,
+// so none of them collides with another.
+func passUniqueIDs(entries []data.Entry, paths []string) {
+	counts := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		counts[entry.UniqueID]++
+	}
+
+	for idx := range entries {
+		if counts[entries[idx].UniqueID] > 1 {
+			entries[idx].UniqueID = strings.TrimSuffix(filepath.ToSlash(paths[idx]), passExtension)
+		}
+	}
+}
+
+func passEntry(relativePath string, content []byte) data.Entry {
+	entry := data.Entry{UniqueID: strings.TrimSuffix(filepath.Base(relativePath), passExtension)}
+
+	if dir := filepath.Dir(relativePath); dir != "." {
+		entry.Tags = folderTags(filepath.ToSlash(dir))
+	}
+
+	var description []string
+
+	for lineNumber, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+
+		if lineNumber == 0 {
+			entry.Password = line
+
+			continue
+		}
+
+		key, value, found := strings.Cut(line, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch {
+		case found && (key == "login" || key == "user") && entry.ID == "":
+			entry.ID = value
+		case found && key == "url" && entry.Site == "":
+			entry.Site = value
+		case strings.HasPrefix(line, "otpauth://"):
+			entry.AddOTP("otpauth", line)
+		default:
+			description = append(description, line)
+		}
+	}
+
+	entry.Description = fold(strings.Join(description, "\n"), nil)
+
+	return entry
+}
@@ -23,9 +23,9 @@ import (
 
 	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
 	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/constants"
 	"github.com/norbjd/pasuman/pkg/data"
-	"github.com/norbjd/pasuman/pkg/encrypt"
 	"github.com/stretchr/testify/require"
 )
 
@@ -56,6 +56,12 @@ func TestList(t *testing.T) {
 	got, err := List(constants.RootCmdDefaultProfile)
 	require.NoError(t, err)
 
+	var d data.Data
+	require.NoError(t, d.FromFile(config.PasumanDataFile))
+
+	keys, err := d.Unlock(pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+
 	gotUniqueID1 := got[0]
 	require.Equal(t, "id1", gotUniqueID1.UniqueID)
 	require.Equal(t, "A desc", gotUniqueID1.Description)
@@ -63,14 +69,10 @@ func TestList(t *testing.T) {
 	require.Equal(t, "https://mysupersite.pasuman", gotUniqueID1.Site)
 
 	require.NotEqual(t, "myId", gotUniqueID1.ID)
-	gotUniqueID1ID, err := encrypt.Decrypt(pasumantest.TestMasterPassword, gotUniqueID1.ID)
-	require.NoError(t, err)
-	require.Equal(t, "myId", gotUniqueID1ID)
-
 	require.NotEqual(t, "p4$$w0rd!", gotUniqueID1.Password)
-	gotUniqueID1Password, err := encrypt.Decrypt(pasumantest.TestMasterPassword, gotUniqueID1.Password)
-	require.NoError(t, err)
-	require.Equal(t, "p4$$w0rd!", gotUniqueID1Password)
+	require.NoError(t, gotUniqueID1.Decrypt(keys))
+	require.Equal(t, "myId", gotUniqueID1.ID)
+	require.Equal(t, "p4$$w0rd!", gotUniqueID1.Password)
 
 	gotUniqueID2 := got[1]
 	require.Equal(t, "id2", gotUniqueID2.UniqueID)
@@ -79,14 +81,10 @@ func TestList(t *testing.T) {
 	require.Equal(t, "https://anothersite.pasuman", gotUniqueID2.Site)
 
 	require.NotEqual(t, "otherId", gotUniqueID2.ID)
-	gotUniqueID2ID, err := encrypt.Decrypt(pasumantest.TestMasterPassword, gotUniqueID2.ID)
-	require.NoError(t, err)
-	require.Equal(t, "otherId", gotUniqueID2ID)
-
 	require.NotEqual(t, "t0ps3cr3t!", gotUniqueID2.Password)
-	gotUniqueID2Password, err := encrypt.Decrypt(pasumantest.TestMasterPassword, gotUniqueID2.Password)
-	require.NoError(t, err)
-	require.Equal(t, "t0ps3cr3t!", gotUniqueID2Password)
+	require.NoError(t, gotUniqueID2.Decrypt(keys))
+	require.Equal(t, "otherId", gotUniqueID2.ID)
+	require.Equal(t, "t0ps3cr3t!", gotUniqueID2.Password)
 }
 
 func TestListEmpty(t *testing.T) {
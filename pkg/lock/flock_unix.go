@@ -0,0 +1,64 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+//go:build !windows
+
+package lock
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func flock(file *os.File, mode Mode) error {
+	how := syscall.LOCK_SH
+
+	if mode == Exclusive {
+		how = syscall.LOCK_EX
+	}
+
+	err := syscall.Flock(int(file.Fd()), how|syscall.LOCK_NB)
+
+	switch {
+	case errors.Is(err, syscall.EWOULDBLOCK):
+		return errWouldBlock
+	case errors.Is(err, syscall.ENOTSUP), errors.Is(err, syscall.EOPNOTSUPP), errors.Is(err, syscall.ENOLCK):
+		return errUnsupported
+	}
+
+	return err
+}
+
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// removeLockFile - remove the lock file at path, then close it: the lock is held until the file is removed,
+// so that nobody can acquire it in between.
+func removeLockFile(file *os.File, path string) error {
+	errRemove := os.Remove(path)
+	errClose := file.Close()
+
+	if errRemove != nil && !errors.Is(errRemove, os.ErrNotExist) {
+		return errRemove
+	}
+
+	return errClose
+}
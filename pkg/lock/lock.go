@@ -0,0 +1,331 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	fileMode = os.FileMode(0o600)
+
+	retryInterval = 100 * time.Millisecond
+)
+
+var (
+	ErrLocked = errors.New("file is locked")
+
+	errWouldBlock      = errors.New("lock is held by another process")
+	errUnsupported     = errors.New("file locking is not supported")
+	errLockFileRemoved = errors.New("lock file has been removed")
+)
+
+// Mode - lock mode: any number of processes can hold a shared lock at the same time,
+// while an exclusive lock can be held by only one process (and no shared lock can be held meanwhile).
+type Mode int
+
+const (
+	Shared Mode = iota
+	Exclusive
+)
+
+// Owner - process holding an exclusive lock.
+type Owner struct {
+	PID       int       `json:"pid"`
+	Hostname  string    `json:"hostname"`
+	StartTime time.Time `json:"start_time"`
+}
+
+func (o *Owner) String() string {
+	return fmt.Sprintf("PID %d on %s, since %s", o.PID, o.Hostname, o.StartTime.Format(time.RFC3339))
+}
+
+// Stale - true if the owner process does not exist anymore.
+// Only processes running on the same host can be checked, others are never considered stale.
+func (o *Owner) Stale() bool {
+	hostname, err := os.Hostname()
+	if err != nil || hostname != o.Hostname {
+		return false
+	}
+
+	return !processExists(o.PID)
+}
+
+// LockedError - returned when a lock is held by another process.
+// Owner is nil if the owner is unknown, which is always the case for shared locks.
+type LockedError struct {
+	Owner *Owner
+}
+
+func (e *LockedError) Error() string {
+	if e.Owner == nil {
+		return "lock held by another pasuman process"
+	}
+
+	return fmt.Sprintf("lock held by %s", e.Owner)
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// Lock - an advisory lock (flock) on a file.
+// An exclusive lock holds information about its owner.
+type Lock struct {
+	file *os.File
+	path string
+	mode Mode
+
+	// StaleOwner - owner of a previous lock that has not been released properly
+	// (CTRL+C, panic, etc.), if any.
+	StaleOwner *Owner
+}
+
+// Acquire - acquire the lock on path with the given mode. If the lock is held by another process
+// in a conflicting mode, retry until it is released or until wait is elapsed, and then fail with ErrLocked.
+func Acquire(path string, mode Mode, wait time.Duration) (*Lock, error) {
+	deadline := time.Now().Add(wait)
+
+	for {
+		lock, err := tryAcquire(path, mode)
+
+		switch {
+		case err == nil:
+			return lock, nil
+		case errors.Is(err, errLockFileRemoved):
+			continue
+		case !errors.Is(err, ErrLocked) || time.Now().After(deadline):
+			return nil, err
+		}
+
+		time.Sleep(retryInterval)
+	}
+}
+
+// nolint: cyclop
+func tryAcquire(path string, mode Mode) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &Lock{file: file, path: path, mode: mode}
+
+	err = flock(file, mode)
+
+	switch {
+	case errors.Is(err, errWouldBlock):
+		// best effort: the owner may not be readable while it holds the lock (locks are mandatory on Windows)
+		owner, _ := readOwner(file)
+		_ = file.Close()
+
+		return nil, &LockedError{Owner: owner}
+	case errors.Is(err, errUnsupported):
+		// best effort on filesystems not supporting flock: rely on the owner only
+		owner, err := readOwner(file)
+		if err != nil {
+			_ = file.Close()
+
+			return nil, err
+		}
+
+		if owner != nil && !owner.Stale() {
+			_ = file.Close()
+
+			return nil, &LockedError{Owner: owner}
+		}
+	case err != nil:
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	// the previous owner may have released the lock (and removed the file) between
+	// our open and our flock: in that case, we hold a lock on a file nobody else will look at
+	if same, err := isSameFile(path, file); err != nil || !same {
+		_ = file.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errLockFileRemoved
+	}
+
+	// read once we hold the lock, so it is not the owner of a lock released in between:
+	// if there is an owner, it did not release the lock properly
+	previousOwner, err := readOwner(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	lock.StaleOwner = previousOwner
+
+	if mode == Shared {
+		// the owner of a shared lock is not recorded, as there can be many
+		if err := clearOwner(file); err != nil {
+			_ = file.Close()
+
+			return nil, err
+		}
+
+		return lock, nil
+	}
+
+	if err := writeOwner(file); err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// Release - release the lock. For an exclusive lock, the lock file is removed (see `removeLockFile`);
+// processes waiting for the lock will notice it and retry (see `tryAcquire`). The lock file is kept for a shared
+// lock, as other processes may still hold a shared lock on it.
+func (l *Lock) Release() error {
+	if l.mode == Shared {
+		return l.file.Close()
+	}
+
+	return removeLockFile(l.file, l.path)
+}
+
+// Remove - forcibly remove the lock file at path, and return the lock owner (nil if unknown).
+// It fails with ErrLocked if the lock is currently held by a running process.
+func Remove(path string) (*Owner, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, fileMode)
+	if err != nil {
+		return nil, err
+	}
+
+	errFlock := flock(file, Exclusive)
+
+	switch {
+	case errors.Is(errFlock, errWouldBlock):
+		owner, _ := readOwner(file)
+		_ = file.Close()
+
+		return owner, &LockedError{Owner: owner}
+	case errFlock != nil && !errors.Is(errFlock, errUnsupported):
+		_ = file.Close()
+
+		return nil, errFlock
+	}
+
+	owner, err := readOwner(file)
+	if err != nil {
+		_ = file.Close()
+
+		return nil, err
+	}
+
+	// without flock, the lock is held as long as its owner is running
+	if errFlock != nil && owner != nil && !owner.Stale() {
+		_ = file.Close()
+
+		return owner, &LockedError{Owner: owner}
+	}
+
+	return owner, removeLockFile(file, path)
+}
+
+func readOwner(file *os.File) (*Owner, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner Owner
+
+	// lock files created by previous pasuman versions are empty: owner is unknown
+	if len(content) == 0 || json.Unmarshal(content, &owner) != nil {
+		return nil, nil // nolint: nilnil
+	}
+
+	return &owner, nil
+}
+
+func writeOwner(file *os.File) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(Owner{
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		StartTime: time.Now().Truncate(time.Second),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+
+	if _, err := file.WriteAt(content, 0); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+func clearOwner(file *os.File) error {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if fileInfo.Size() == 0 {
+		return nil
+	}
+
+	return file.Truncate(0)
+}
+
+func isSameFile(path string, file *os.File) (bool, error) {
+	pathInfo, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	return os.SameFile(pathInfo, fileInfo), nil
+}
@@ -0,0 +1,180 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package lock
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	t.Parallel()
+
+	lockFile := filepath.Join(t.TempDir(), "default.json.lock")
+
+	lock, err := Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+	require.Nil(t, lock.StaleOwner)
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	var lockedErr *LockedError
+
+	_, err = Acquire(lockFile, Exclusive, 0)
+	require.ErrorIs(t, err, ErrLocked)
+	require.ErrorAs(t, err, &lockedErr)
+	require.Equal(t, os.Getpid(), lockedErr.Owner.PID)
+	require.Equal(t, hostname, lockedErr.Owner.Hostname)
+
+	require.NoError(t, lock.Release())
+
+	_, err = os.Stat(lockFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	lock, err = Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
+
+func TestAcquireWait(t *testing.T) {
+	t.Parallel()
+
+	lockFile := filepath.Join(t.TempDir(), "default.json.lock")
+
+	lock, err := Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = Acquire(lockFile, Exclusive, 300*time.Millisecond)
+	require.ErrorIs(t, err, ErrLocked)
+	require.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+
+		_ = lock.Release()
+	}()
+
+	lock, err = Acquire(lockFile, Exclusive, 5*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
+
+func TestAcquireStale(t *testing.T) {
+	t.Parallel()
+
+	lockFile := filepath.Join(t.TempDir(), "default.json.lock")
+
+	hostname, err := os.Hostname()
+	require.NoError(t, err)
+
+	// lock file left behind by a process killed brutally
+	staleOwner := Owner{PID: 1 << 22, Hostname: hostname, StartTime: time.Now().Add(-time.Hour).Truncate(time.Second)}
+	require.True(t, staleOwner.Stale())
+
+	content, err := json.Marshal(staleOwner)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(lockFile, content, 0o600))
+
+	lock, err := Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+	require.NotNil(t, lock.StaleOwner)
+	require.True(t, staleOwner.StartTime.Equal(lock.StaleOwner.StartTime))
+	require.Equal(t, staleOwner.PID, lock.StaleOwner.PID)
+	require.NoError(t, lock.Release())
+
+	// empty lock file created by previous pasuman versions
+	require.NoError(t, os.WriteFile(lockFile, nil, 0o600))
+
+	lock, err = Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+	require.Nil(t, lock.StaleOwner)
+	require.NoError(t, lock.Release())
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	lockFile := filepath.Join(t.TempDir(), "default.json.lock")
+
+	_, err := Remove(lockFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	lock, err := Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+
+	owner, err := Remove(lockFile)
+	require.ErrorIs(t, err, ErrLocked)
+	require.Equal(t, os.Getpid(), owner.PID)
+
+	require.NoError(t, lock.Release())
+
+	require.NoError(t, os.WriteFile(lockFile, nil, 0o600))
+
+	owner, err = Remove(lockFile)
+	require.NoError(t, err)
+	require.Nil(t, owner)
+
+	_, err = os.Stat(lockFile)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestAcquireShared(t *testing.T) {
+	t.Parallel()
+
+	lockFile := filepath.Join(t.TempDir(), "default.json.lock")
+
+	reader1, err := Acquire(lockFile, Shared, 0)
+	require.NoError(t, err)
+
+	reader2, err := Acquire(lockFile, Shared, 0)
+	require.NoError(t, err)
+
+	_, err = Acquire(lockFile, Exclusive, 0)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, reader1.Release())
+
+	_, err = Acquire(lockFile, Exclusive, 0)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, reader2.Release())
+
+	writer, err := Acquire(lockFile, Exclusive, 0)
+	require.NoError(t, err)
+	require.Nil(t, writer.StaleOwner)
+
+	_, err = Acquire(lockFile, Shared, 0)
+	require.ErrorIs(t, err, ErrLocked)
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+
+		_ = writer.Release()
+	}()
+
+	reader1, err = Acquire(lockFile, Shared, 5*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, reader1.Release())
+}
@@ -18,12 +18,11 @@
 package masterpassword
 
 import (
-	"fmt"
+	"errors"
 
 	"github.com/alexedwards/argon2id"
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/data"
-	"github.com/norbjd/pasuman/pkg/encrypt"
 	"github.com/norbjd/pasuman/pkg/util"
 )
 
@@ -39,7 +38,8 @@ var argon2idParams = argon2id.Params{
 func IsSet() (bool, error) {
 	var d data.Data
 
-	if err := d.FromFile(config.PasumanDataFile); err != nil {
+	// entries are not needed
+	if err := d.FromFile(config.PasumanDataFile); err != nil && !errors.Is(err, data.ErrSealed) {
 		return false, err
 	}
 
@@ -51,13 +51,25 @@ func IsSet() (bool, error) {
 }
 
 func IsCorrect(masterPassword string) (bool, error) {
-	var data data.Data
+	var d data.Data
 
-	if err := data.FromFile(config.PasumanDataFile); err != nil {
+	// entries are not needed
+	if err := d.FromFile(config.PasumanDataFile); err != nil && !errors.Is(err, data.ErrSealed) {
 		return false, err
 	}
 
-	match, _, err := argon2id.CheckHash(masterPassword, data.MasterPassword)
+	// unwrapping the data key checks the master password too, and is needed anyway to decrypt entries:
+	// the key is derived only once (see `data.Unlock`)
+	if d.DataKey != "" {
+		_, err := d.Unlock(masterPassword)
+		if errors.Is(err, util.ErrMasterPasswordIncorrect) {
+			return false, nil
+		}
+
+		return err == nil, err
+	}
+
+	match, _, err := argon2id.CheckHash(masterPassword, d.MasterPassword)
 	if err != nil {
 		return false, err
 	}
@@ -65,9 +77,8 @@ func IsCorrect(masterPassword string) (bool, error) {
 	return match, nil
 }
 
-// SetMasterPassword - change master password and re-encrypt all encrypted values
-// with the new master password.
-// nolint: funlen
+// SetMasterPassword - change master password and re-wrap the data key with the new master password.
+// Entries, encrypted with the data key, are not re-encrypted.
 func SetMasterPassword(oldMasterPassword, newMasterPassword string) error {
 	if newMasterPassword == "" {
 		return util.ErrMasterPasswordMustNotBeEmpty
@@ -86,47 +97,37 @@ func SetMasterPassword(oldMasterPassword, newMasterPassword string) error {
 		}
 	}
 
-	var data data.Data
-
-	if err := data.FromFile(config.PasumanDataFile); err != nil {
-		return err
-	}
-
-	if data.MasterPassword, err = argon2id.CreateHash(newMasterPassword, &argon2idParams); err != nil {
-		return err
-	}
+	var d data.Data
 
 	if oldMasterPassword != "" {
-		fmt.Println("Re-encrypting all entries with new master password, please wait...")
-
-		for idx := range data.Entries {
-			encryptedEntryID := data.Entries[idx].ID
-
-			entryID, err := encrypt.Decrypt(oldMasterPassword, encryptedEntryID)
-			if err != nil {
-				return err
-			}
-
-			if data.Entries[idx].ID, err = encrypt.Encrypt(newMasterPassword, entryID); err != nil {
-				return err
-			}
+		keys, err := d.Open(config.PasumanDataFile, oldMasterPassword)
+		if err != nil {
+			return err
+		}
 
-			encryptedEntryPassword := data.Entries[idx].Password
+		// entries encrypted by older versions of pasuman are encrypted with the master password
+		if err := d.UpgradeEncryption(keys); err != nil {
+			return err
+		}
 
-			entryPassword, err := encrypt.Decrypt(oldMasterPassword, encryptedEntryPassword)
-			if err != nil {
-				return err
-			}
+		if _, err := d.Rewrap(keys, newMasterPassword); err != nil {
+			return err
+		}
+	} else {
+		if err := d.FromFile(config.PasumanDataFile); err != nil {
+			return err
+		}
 
-			if data.Entries[idx].Password, err = encrypt.Encrypt(newMasterPassword, entryPassword); err != nil {
-				return err
-			}
+		if _, err := d.Unlock(newMasterPassword); err != nil {
+			return err
 		}
+	}
 
-		fmt.Println("Done!")
+	if d.MasterPassword, err = argon2id.CreateHash(newMasterPassword, &argon2idParams); err != nil {
+		return err
 	}
 
-	if err = data.ToFile(config.PasumanDataFile); err != nil {
+	if err = d.ToFile(config.PasumanDataFile); err != nil {
 		return err
 	}
 
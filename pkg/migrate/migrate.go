@@ -0,0 +1,85 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migrate
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+// Result - migrations needed (or applied) to bring the profile file to the latest version.
+type Result struct {
+	FromVersion int
+	ToVersion   int
+	Migrations  []data.Migration
+}
+
+// Migrate - upgrade the profile file to the latest version. When dryRun is true, nothing is written
+// and masterPassword is not needed. The previous version of the file is kept as backup.
+func Migrate(masterPassword string, dryRun bool) (Result, error) {
+	byteContents, err := os.ReadFile(config.PasumanDataFile)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(byteContents, &raw); err != nil {
+		return Result{}, err
+	}
+
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+
+	fromVersion, err := data.Version(raw)
+	if err != nil {
+		return Result{}, err
+	}
+
+	migrations, err := data.Migrate(raw)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{
+		FromVersion: fromVersion,
+		ToVersion:   data.CurrentVersion,
+		Migrations:  migrations,
+	}
+
+	if dryRun || len(migrations) == 0 {
+		return result, nil
+	}
+
+	var d data.Data
+
+	// entries are re-encrypted, and the file is authenticated, with the data key
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return Result{}, err
+	}
+
+	return result, d.ToFile(config.PasumanDataFile)
+}
@@ -0,0 +1,75 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package migrate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	v0, err := os.ReadFile(filepath.Join("..", "data", "testdata", "v0.json"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(config.PasumanDataFile, v0, 0o600))
+
+	// dry run does not write anything
+	result, err := Migrate("", true)
+	require.NoError(t, err)
+	require.Equal(t, 0, result.FromVersion)
+	require.Equal(t, data.CurrentVersion, result.ToVersion)
+	require.Len(t, result.Migrations, data.CurrentVersion)
+
+	contents, err := os.ReadFile(config.PasumanDataFile)
+	require.NoError(t, err)
+	require.Equal(t, v0, contents)
+
+	result, err = Migrate(pasumantest.TestMasterPassword, false)
+	require.NoError(t, err)
+	require.Len(t, result.Migrations, data.CurrentVersion)
+
+	contents, err = os.ReadFile(config.PasumanDataFile)
+	require.NoError(t, err)
+
+	var raw map[string]interface{}
+	require.NoError(t, json.Unmarshal(contents, &raw))
+
+	version, err := data.Version(raw)
+	require.NoError(t, err)
+	require.Equal(t, data.CurrentVersion, version)
+
+	backup, err := os.ReadFile(config.PasumanDataFile + data.BackupSuffix)
+	require.NoError(t, err)
+	require.Equal(t, v0, backup)
+
+	// already migrated
+	result, err = Migrate(pasumantest.TestMasterPassword, false)
+	require.NoError(t, err)
+	require.Equal(t, data.CurrentVersion, result.FromVersion)
+	require.Empty(t, result.Migrations)
+}
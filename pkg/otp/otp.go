@@ -0,0 +1,218 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Algorithm - HMAC hash algorithm used to compute codes.
+type Algorithm string
+
+const (
+	SHA1   Algorithm = "SHA1"
+	SHA256 Algorithm = "SHA256"
+	SHA512 Algorithm = "SHA512"
+)
+
+const (
+	scheme = "otpauth"
+	totp   = "totp"
+
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+
+	minDigits = 6
+	maxDigits = 8
+
+	// counterSize - size of the counter, in bytes (RFC 4226)
+	counterSize = 8
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid one-time password key: must be an otpauth:// URI or a base32 secret")
+
+	errUnsupportedType      = errors.New("unsupported one-time password type: only totp is supported")
+	errUnsupportedAlgorithm = errors.New("unsupported algorithm: must be SHA1, SHA256 or SHA512")
+	errInvalidDigits        = errors.New("digits must be between 6 and 8")
+	errInvalidPeriod        = errors.New("period must be a positive number of seconds")
+	errMissingSecret        = errors.New("secret is missing")
+)
+
+// Key - key of time-based one-time passwords (RFC 6238).
+type Key struct {
+	Secret    []byte
+	Algorithm Algorithm
+	Digits    int
+	Period    time.Duration
+	// Label - label of otpauth:// URIs, usually "issuer:account" (informative only)
+	Label string
+}
+
+// Parse - read a key from an otpauth://totp/ URI (with secret, and optional algorithm, digits and period
+// parameters), or from a base32 secret (spaces and padding are optional, case does not matter), using
+// default parameters (SHA1, 6 digits, 30 seconds).
+func Parse(s string) (Key, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(strings.ToLower(s), scheme+":") {
+		return parseURI(s)
+	}
+
+	secret, err := decodeSecret(s)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{Secret: secret, Algorithm: SHA1, Digits: DefaultDigits, Period: DefaultPeriod}, nil
+}
+
+func parseURI(s string) (Key, error) {
+	uri, err := url.Parse(s)
+	if err != nil {
+		return Key{}, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	if !strings.EqualFold(uri.Host, totp) {
+		return Key{}, fmt.Errorf("%w: %s", errUnsupportedType, uri.Host)
+	}
+
+	query := uri.Query()
+
+	if query.Get("secret") == "" {
+		return Key{}, errMissingSecret
+	}
+
+	secret, err := decodeSecret(query.Get("secret"))
+	if err != nil {
+		return Key{}, err
+	}
+
+	key := Key{
+		Secret:    secret,
+		Algorithm: SHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+		Label:     strings.TrimPrefix(uri.Path, "/"),
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = Algorithm(strings.ToUpper(algorithm))
+
+		if key.hash() == nil {
+			return Key{}, fmt.Errorf("%w: %s", errUnsupportedAlgorithm, algorithm)
+		}
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil || key.Digits < minDigits || key.Digits > maxDigits {
+			return Key{}, fmt.Errorf("%w: %s", errInvalidDigits, digits)
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		seconds, err := strconv.Atoi(period)
+		if err != nil || seconds <= 0 {
+			return Key{}, fmt.Errorf("%w: %s", errInvalidPeriod, period)
+		}
+
+		key.Period = time.Duration(seconds) * time.Second
+	}
+
+	return key, nil
+}
+
+func decodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.Join(strings.Fields(s), ""))
+	s = strings.TrimRight(s, "=")
+
+	secret, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	if err != nil || len(secret) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return secret, nil
+}
+
+// hash - hash function of the algorithm (HMAC-SHA1 is the default of RFC 6238, and is still secure
+// as a MAC), or nil if it is not supported.
+func (k Key) hash() func() hash.Hash {
+	switch k.Algorithm {
+	case SHA1:
+		return sha1.New
+	case SHA256:
+		return sha256.New
+	case SHA512:
+		return sha512.New
+	default:
+		return nil
+	}
+}
+
+// Code - one-time password valid at t.
+func (k Key) Code(t time.Time) (string, error) {
+	h := k.hash()
+	if h == nil {
+		return "", fmt.Errorf("%w: %s", errUnsupportedAlgorithm, k.Algorithm)
+	}
+
+	if k.Digits < minDigits || k.Digits > maxDigits {
+		return "", fmt.Errorf("%w: %d", errInvalidDigits, k.Digits)
+	}
+
+	if k.Period < time.Second {
+		return "", fmt.Errorf("%w: %s", errInvalidPeriod, k.Period)
+	}
+
+	counter := make([]byte, counterSize)
+	binary.BigEndian.PutUint64(counter, uint64(t.Unix()/int64(k.Period/time.Second)))
+
+	mac := hmac.New(h, k.Secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation (RFC 4226, section 5.3)
+	offset := sum[len(sum)-1] & 0x0f                                // nolint: gomnd
+	truncated := binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff // nolint: gomnd
+
+	modulo := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", k.Digits, truncated%modulo), nil
+}
+
+// Remaining - how long the one-time password valid at t is still valid.
+func (k Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period / time.Second)
+
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
@@ -0,0 +1,158 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package otp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCodeRFC6238 - test vectors of RFC 6238 (appendix B).
+func TestCodeRFC6238(t *testing.T) {
+	t.Parallel()
+
+	secrets := map[Algorithm]string{
+		SHA1:   "12345678901234567890",
+		SHA256: "12345678901234567890123456789012",
+		SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	tests := []struct {
+		time  int64
+		codes map[Algorithm]string
+	}{
+		{time: 59, codes: map[Algorithm]string{SHA1: "94287082", SHA256: "46119246", SHA512: "90693936"}},
+		{time: 1111111109, codes: map[Algorithm]string{SHA1: "07081804", SHA256: "68084774", SHA512: "25091201"}},
+		{time: 1111111111, codes: map[Algorithm]string{SHA1: "14050471", SHA256: "67062674", SHA512: "99943326"}},
+		{time: 1234567890, codes: map[Algorithm]string{SHA1: "89005924", SHA256: "91819424", SHA512: "93441116"}},
+		{time: 2000000000, codes: map[Algorithm]string{SHA1: "69279037", SHA256: "90698825", SHA512: "38618901"}},
+		{time: 20000000000, codes: map[Algorithm]string{SHA1: "65353130", SHA256: "77737706", SHA512: "47863826"}},
+	}
+
+	for _, tt := range tests {
+		for algorithm, want := range tt.codes {
+			key := Key{Secret: []byte(secrets[algorithm]), Algorithm: algorithm, Digits: 8, Period: DefaultPeriod}
+
+			got, err := key.Code(time.Unix(tt.time, 0))
+			require.NoError(t, err)
+			require.Equal(t, want, got, tt.time, algorithm)
+
+			// codes with fewer digits are the last digits of the same number
+			key.Digits = 6
+			got, err = key.Code(time.Unix(tt.time, 0))
+			require.NoError(t, err)
+			require.Equal(t, want[2:], got, tt.time, algorithm)
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	t.Parallel()
+
+	key := Key{Period: DefaultPeriod}
+	require.Equal(t, 30*time.Second, key.Remaining(time.Unix(60, 0)))
+	require.Equal(t, 1*time.Second, key.Remaining(time.Unix(59, 0)))
+
+	key.Period = time.Minute
+	require.Equal(t, 20*time.Second, key.Remaining(time.Unix(100, 0)))
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	// base32 of "12345678901234567890"
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		s       string
+		want    Key
+		wantErr error
+	}{
+		{
+			s:    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+			want: Key{Secret: secret, Algorithm: SHA1, Digits: 6, Period: 30 * time.Second},
+		},
+		{
+			s:    " gezd gnbv gy3t qojq gezd gnbv gy3t qojq\n",
+			want: Key{Secret: secret, Algorithm: SHA1, Digits: 6, Period: 30 * time.Second},
+		},
+		{
+			s:    "otpauth://totp/Example:alice@example.com?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=Example",
+			want: Key{Secret: secret, Algorithm: SHA1, Digits: 6, Period: 30 * time.Second, Label: "Example:alice@example.com"},
+		},
+		{
+			s:    "otpauth://totp/Example?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&algorithm=sha512&digits=8&period=60",
+			want: Key{Secret: secret, Algorithm: SHA512, Digits: 8, Period: time.Minute, Label: "Example"},
+		},
+		{s: "", wantErr: ErrInvalidKey},
+		{s: "not base32!", wantErr: ErrInvalidKey},
+		{s: "otpauth://hotp/Example?secret=GEZDGNBV&counter=1", wantErr: errUnsupportedType},
+		{s: "otpauth://totp/Example", wantErr: errMissingSecret},
+		{s: "otpauth://totp/Example?secret=GEZDGNBV&algorithm=MD5", wantErr: errUnsupportedAlgorithm},
+		{s: "otpauth://totp/Example?secret=GEZDGNBV&digits=10", wantErr: errInvalidDigits},
+		{s: "otpauth://totp/Example?secret=GEZDGNBV&period=0", wantErr: errInvalidPeriod},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.s)
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.s)
+		} else {
+			require.NoError(t, err, tt.s)
+			require.Equal(t, tt.want, got, tt.s)
+		}
+	}
+}
+
+func TestReadQR(t *testing.T) {
+	t.Parallel()
+
+	uri := "otpauth://totp/Example?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	errDecoder := errors.New("decoder failed")
+
+	tests := []struct {
+		decoded string
+		err     error
+		want    string
+		wantErr error
+	}{
+		{decoded: uri + "\n", want: uri},
+		{decoded: "https://example.com\r\n" + uri + "\r\n", want: uri},
+		{decoded: "GEZDGNBVGY3TQOJQ\n", want: "GEZDGNBVGY3TQOJQ"},
+		{decoded: "https://example.com\nhttps://example.org\n", wantErr: errNoQRCode},
+		{decoded: "otpauth://totp/Example\n", wantErr: errMissingSecret},
+		{err: errDecoder, wantErr: errDecoder},
+	}
+
+	for _, tt := range tests {
+		got, err := ReadQR("qr.png", func(file string) (string, error) {
+			require.Equal(t, "qr.png", file)
+
+			return tt.decoded, tt.err
+		})
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.decoded)
+		} else {
+			require.NoError(t, err, tt.decoded)
+			require.Equal(t, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package otp
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+var errNoQRCode = errors.New("no one-time password QR code found in")
+
+// QRDecoder - return the content of QR codes found in an image file, one per line.
+type QRDecoder func(file string) (string, error)
+
+// ZBarImg - decode QR codes with zbarimg (from zbar tools), that must be installed.
+func ZBarImg(file string) (string, error) {
+	zbarimg := exec.Command("zbarimg", "--quiet", "--raw", "-Sdisable", "-Sqrcode.enable", file)
+	zbarimg.Stderr = os.Stderr
+
+	out, err := zbarimg.Output()
+	if err != nil {
+		return "", fmt.Errorf("cannot decode QR code of %s with zbarimg: %w", file, err)
+	}
+
+	return string(out), nil
+}
+
+// ReadQR - key of the QR code in an image file (usually, an otpauth:// URI shown when enabling two-factor
+// authentication), decoded with decode. If the image contains several QR codes, the first otpauth:// URI is read.
+func ReadQR(file string, decode QRDecoder) (string, error) {
+	content, err := decode(file)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.FieldsFunc(content, func(r rune) bool { return r == '\n' || r == '\r' })
+
+	for _, line := range lines {
+		if strings.HasPrefix(strings.ToLower(line), scheme+":") {
+			_, err := Parse(line)
+
+			return line, err
+		}
+	}
+
+	if len(lines) == 1 {
+		_, err := Parse(lines[0])
+
+		return lines[0], err
+	}
+
+	return "", fmt.Errorf("%w %s", errNoQRCode, file)
+}
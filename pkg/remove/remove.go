@@ -19,6 +19,7 @@ package remove
 
 import (
 	"errors"
+	"time"
 
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/data"
@@ -26,28 +27,27 @@ import (
 
 var ErrNotFound = errors.New("entry not found")
 
-func Remove(uniqueID string) error {
+// Remove - move the entry uniqueID to the trash, where it is kept until it is restored, or purged (see
+// `config.Config.TrashRetention`).
+func Remove(masterPassword string, uniqueID string) error {
 	var d data.Data
 
-	if err := d.FromFile(config.PasumanDataFile); err != nil {
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
 		return err
 	}
 
-	index := -1
+	now := time.Now()
 
-	for idx := range d.Entries {
-		if d.Entries[idx].UniqueID == uniqueID {
-			index = idx
-
-			break
-		}
-	}
-
-	if index == -1 {
+	if !d.MoveToTrash(uniqueID, now) {
 		return ErrNotFound
 	}
 
-	d.Entries = append(d.Entries[:index], d.Entries[index+1:]...)
+	d.PurgeTrash(config.GetConfig().TrashRetention(), now)
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return err
+	}
 
 	return d.ToFile(config.PasumanDataFile)
 }
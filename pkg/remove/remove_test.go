@@ -79,7 +79,7 @@ func TestRemove(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		err := Remove(tt.uniqueID)
+		err := Remove(pasumantest.TestMasterPassword, tt.uniqueID)
 
 		require.ErrorIs(t, tt.wantErr, err)
 	}
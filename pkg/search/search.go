@@ -82,7 +82,34 @@ func Search(profile string, term string, caseSensitive bool) ([]data.Entry, erro
 
 			continue
 		}
+
+		if fieldsContain(entry.Fields, term, caseSensitive) {
+			searchResults = append(searchResults, entry)
+
+			continue
+		}
 	}
 
 	return searchResults, nil
 }
+
+// fieldsContain - true if the name or the value of a custom field contains term. Values of sensitive
+// fields are encrypted: only their name is searched.
+func fieldsContain(fields []data.Field, term string, caseSensitive bool) bool {
+	for _, field := range fields {
+		name, value := field.Name, field.Value
+		if field.Sensitive {
+			value = ""
+		}
+
+		if !caseSensitive {
+			name, value = strings.ToLower(name), strings.ToLower(value)
+		}
+
+		if strings.Contains(name, term) || strings.Contains(value, term) {
+			return true
+		}
+	}
+
+	return false
+}
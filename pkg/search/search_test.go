@@ -50,6 +50,10 @@ func TestSearch(t *testing.T) {
 		Site:        "https://anothersite.pasuman",
 		ID:          "otherId",
 		Password:    "t0ps3cr3t!",
+		Fields: []data.Field{
+			{Name: "Account number", Value: "FR76-1234"},
+			{Name: "PIN", Value: "4321", Sensitive: true},
+		},
 	}
 	_, err = add.Add(pasumantest.TestMasterPassword, entry2)
 	require.NoError(t, err)
@@ -120,6 +124,31 @@ func TestSearch(t *testing.T) {
 			want:    nil,
 			wantErr: nil,
 		},
+		{
+			args: args{
+				term:          "fr76",
+				caseSensitive: false,
+			},
+			want:    []data.Entry{entry2},
+			wantErr: nil,
+		},
+		{
+			args: args{
+				term:          "PIN",
+				caseSensitive: true,
+			},
+			want:    []data.Entry{entry2},
+			wantErr: nil,
+		},
+		{
+			// values of sensitive fields are not searched
+			args: args{
+				term:          "4321",
+				caseSensitive: false,
+			},
+			want:    nil,
+			wantErr: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,15 +156,13 @@ func TestSearch(t *testing.T) {
 
 		require.ErrorIs(t, tt.wantErr, err)
 
-		// do not compare ID and Password
+		// do not compare sensitive data
 		for i := range entries {
-			entries[i].ID = ""
-			entries[i].Password = ""
+			entries[i].ClearSensitive()
 		}
 
 		for i := range tt.want {
-			tt.want[i].ID = ""
-			tt.want[i].Password = ""
+			tt.want[i].ClearSensitive()
 		}
 
 		require.ElementsMatch(t, tt.want, entries)
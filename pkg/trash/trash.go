@@ -0,0 +1,114 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package trash
+
+import (
+	"errors"
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+var ErrAlreadyExists = errors.New("an entry with this unique id already exists")
+
+// List - entries in the trash, in the order they have been removed, without their sensitive data.
+func List() ([]data.TrashedEntry, error) {
+	var d data.Data
+
+	if err := d.FromFile(config.PasumanDataFile); err != nil {
+		return nil, err
+	}
+
+	d.PurgeTrash(config.GetConfig().TrashRetention(), time.Now())
+
+	trash := make([]data.TrashedEntry, len(d.Trash))
+
+	for idx, trashed := range d.Trash {
+		trashed.Entry.ClearSensitive()
+		trash[idx] = trashed
+	}
+
+	return trash, nil
+}
+
+// Restore - move the entry uniqueID back from the trash, renamed to newUniqueID if not empty (so it does not
+// collide with an entry added since). Returns the unique ID of the entry restored.
+func Restore(masterPassword string, uniqueID string, newUniqueID string) (string, error) {
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return "", err
+	}
+
+	d.PurgeTrash(config.GetConfig().TrashRetention(), time.Now())
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return "", err
+	}
+
+	trashed, err := d.TakeFromTrash(uniqueID)
+	if err != nil {
+		return "", err
+	}
+
+	entry := trashed.Entry
+
+	if newUniqueID != "" && newUniqueID != entry.UniqueID {
+		// values are re-encrypted, as they are bound to the unique ID
+		if err := entry.Decrypt(keys); err != nil {
+			return "", err
+		}
+
+		entry.UniqueID = newUniqueID
+
+		if err := entry.Encrypt(keys); err != nil {
+			return "", err
+		}
+	}
+
+	for _, e := range d.Entries {
+		if e.UniqueID == entry.UniqueID {
+			return "", ErrAlreadyExists
+		}
+	}
+
+	d.Entries = append(d.Entries, entry)
+
+	return entry.UniqueID, d.ToFile(config.PasumanDataFile)
+}
+
+// Empty - remove all entries of the trash for good. Returns the number of entries removed.
+func Empty(masterPassword string) (int, error) {
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	count := len(d.Trash)
+	d.Trash = nil
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return 0, err
+	}
+
+	return count, d.ToFile(config.PasumanDataFile)
+}
@@ -0,0 +1,92 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package trash
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/remove"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrash(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{
+		UniqueID: "id1", Description: "A desc", ID: "myId", Password: "p4$$w0rd!",
+		Attachments: []data.Attachment{{Name: "codes.txt", Content: []byte("123456")}},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, remove.Remove(pasumantest.TestMasterPassword, "id1"))
+
+	_, err = get.NotSensitive("id1")
+	require.ErrorIs(t, err, get.ErrNotFound)
+
+	trashed, err := List()
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	require.Equal(t, "A desc", trashed[0].Entry.Description)
+	require.Empty(t, trashed[0].Entry.Password)
+	require.NotEmpty(t, trashed[0].DeletedAt)
+
+	_, err = Restore(pasumantest.TestMasterPassword, "id2", "")
+	require.ErrorIs(t, err, data.ErrNotInTrash)
+
+	// an entry with the same unique ID has been added since
+	_, err = add.Add(pasumantest.TestMasterPassword, data.Entry{UniqueID: "id1", Password: "n€wp4$$w0rd!"})
+	require.NoError(t, err)
+
+	_, err = Restore(pasumantest.TestMasterPassword, "id1", "")
+	require.ErrorIs(t, err, ErrAlreadyExists)
+
+	uniqueID, err := Restore(pasumantest.TestMasterPassword, "id1", "oldId1")
+	require.NoError(t, err)
+	require.Equal(t, "oldId1", uniqueID)
+
+	// values are re-encrypted with the new unique ID, and attachments are kept
+	restored, err := get.Sensitive(pasumantest.TestMasterPassword, "oldId1")
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", restored.Password)
+
+	content, err := get.Attachment(pasumantest.TestMasterPassword, "oldId1", "codes.txt")
+	require.NoError(t, err)
+	require.Equal(t, []byte("123456"), content)
+
+	trashed, err = List()
+	require.NoError(t, err)
+	require.Empty(t, trashed)
+
+	require.NoError(t, remove.Remove(pasumantest.TestMasterPassword, "id1"))
+	require.NoError(t, remove.Remove(pasumantest.TestMasterPassword, "oldId1"))
+
+	count, err := Empty(pasumantest.TestMasterPassword)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+
+	trashed, err = List()
+	require.NoError(t, err)
+	require.Empty(t, trashed)
+}
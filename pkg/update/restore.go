@@ -0,0 +1,89 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package update
+
+import (
+	"time"
+
+	"github.com/norbjd/pasuman/pkg/config"
+	"github.com/norbjd/pasuman/pkg/data"
+)
+
+// Restore - replace the entry uniqueID by its previous version, keeping its unique ID. The replaced entry
+// is kept in the history, so a restore can be undone too. Changed fields are returned.
+func Restore(masterPassword string, uniqueID string, version int) ([]string, error) {
+	var d data.Data
+
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+
+	for idx := range d.Entries {
+		if d.Entries[idx].UniqueID == uniqueID {
+			index = idx
+
+			break
+		}
+	}
+
+	if index == -1 {
+		return nil, ErrNotFound
+	}
+
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return nil, err
+	}
+
+	current := d.Entries[index]
+
+	revision, ok := current.Revision(version)
+	if !ok {
+		return nil, data.ErrVersionNotFound
+	}
+
+	decrypted := current
+	if err := decrypted.Decrypt(keys); err != nil {
+		return nil, err
+	}
+
+	restored := revision.Entry
+	if err := restored.Decrypt(keys); err != nil {
+		return nil, err
+	}
+
+	restored.UniqueID = current.UniqueID
+
+	changed := data.Changes(decrypted, restored)
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	// values are re-encrypted, as they are bound to the unique ID
+	if err := restored.Encrypt(keys); err != nil {
+		return nil, err
+	}
+
+	restored.Record(current, changed, time.Now(), retention())
+
+	d.Entries[index] = restored
+
+	return changed, d.ToFile(config.PasumanDataFile)
+}
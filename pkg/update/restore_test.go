@@ -0,0 +1,84 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package update
+
+import (
+	"os"
+	"testing"
+
+	"github.com/norbjd/pasuman/internal/pkg/pasumantest"
+	"github.com/norbjd/pasuman/pkg/add"
+	"github.com/norbjd/pasuman/pkg/constants"
+	"github.com/norbjd/pasuman/pkg/data"
+	"github.com/norbjd/pasuman/pkg/get"
+	"github.com/norbjd/pasuman/pkg/masterpassword"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestore(t *testing.T) {
+	tempDir := pasumantest.Init(t, constants.RootCmdDefaultProfile)
+	defer os.RemoveAll(tempDir)
+
+	_, err := add.Add(pasumantest.TestMasterPassword, data.Entry{
+		UniqueID: "id1", Site: "https://mysupersite.pasuman", ID: "myId", Password: "p4$$w0rd!",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, Update(pasumantest.TestMasterPassword, "id1",
+		data.Entry{UniqueID: "newId1", Password: "n€wp4$$w0rd!"}, Removed{}))
+
+	// the master password changes, previous versions are still readable
+	require.NoError(t, masterpassword.SetMasterPassword(pasumantest.TestMasterPassword, "n€wpass"))
+
+	entry, err := get.NotSensitive("newId1")
+	require.NoError(t, err)
+	require.Equal(t, 2, entry.Version())
+	require.Len(t, entry.History, 1)
+	require.Equal(t, []string{"unique_id", "password"}, entry.History[0].Changed)
+	require.Empty(t, entry.History[0].Entry.Password)
+
+	_, err = Restore("n€wpass", "newId1", 2)
+	require.ErrorIs(t, err, data.ErrVersionNotFound)
+
+	_, err = Restore("n€wpass", "id1", 1)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	changed, err := Restore("n€wpass", "newId1", 1)
+	require.NoError(t, err)
+	require.Equal(t, []string{"password"}, changed)
+
+	// the unique ID is kept
+	restored, err := get.Sensitive("n€wpass", "newId1")
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", restored.Password)
+	require.Equal(t, "myId", restored.ID)
+	require.Equal(t, 3, restored.Version())
+
+	// the restore can be undone
+	changed, err = Restore("n€wpass", "newId1", 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{"password"}, changed)
+
+	restored, err = get.Sensitive("n€wpass", "newId1")
+	require.NoError(t, err)
+	require.Equal(t, "n€wp4$$w0rd!", restored.Password)
+
+	changed, err = Restore("n€wpass", "newId1", 2)
+	require.NoError(t, err)
+	require.Nil(t, changed)
+}
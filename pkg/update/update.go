@@ -19,18 +19,31 @@ package update
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/norbjd/pasuman/pkg/config"
 	"github.com/norbjd/pasuman/pkg/data"
-	"github.com/norbjd/pasuman/pkg/encrypt"
 )
 
 var ErrNotFound = errors.New("entry not found")
 
-func Update(masterPassword string, uniqueID string, e data.Entry) error {
+// Removed - names of custom fields and attachments to remove from an entry, and whether to unbind it from its
+// password policy.
+type Removed struct {
+	Fields      []string
+	Attachments []string
+	Policy      bool
+}
+
+// Update - update entry uniqueID with non-empty fields of e. Custom fields and attachments removed are removed,
+// then custom fields and attachments of e are added to the entry, or replace the ones with the same name
+// (sensitive custom fields stay sensitive). The previous version of the entry is kept in its history.
+func Update(masterPassword string, uniqueID string, e data.Entry, removed Removed) error {
 	var d data.Data
 
-	if err := d.FromFile(config.PasumanDataFile); err != nil {
+	keys, err := d.Open(config.PasumanDataFile, masterPassword)
+	if err != nil {
 		return err
 	}
 
@@ -48,35 +61,117 @@ func Update(masterPassword string, uniqueID string, e data.Entry) error {
 		return ErrNotFound
 	}
 
+	if err := d.UpgradeEncryption(keys); err != nil {
+		return err
+	}
+
+	// sensitive fields are bound to the unique ID: they must be re-encrypted if it changes
+	previous := d.Entries[index]
+	entry := previous
+
+	if err := entry.Decrypt(keys); err != nil {
+		return err
+	}
+
+	decrypted := entry
+
 	if e.UniqueID != "" {
-		d.Entries[index].UniqueID = e.UniqueID
+		entry.UniqueID = e.UniqueID
 	}
 
 	if e.Description != "" {
-		d.Entries[index].Description = e.Description
+		entry.Description = e.Description
 	}
 
 	if len(e.Tags) > 0 {
-		d.Entries[index].Tags = e.Tags
+		entry.Tags = e.Tags
 	}
 
 	if e.Site != "" {
-		d.Entries[index].Site = e.Site
+		entry.Site = e.Site
 	}
 
-	var err error
-
 	if e.ID != "" {
-		if d.Entries[index].ID, err = encrypt.Encrypt(masterPassword, e.ID); err != nil {
-			return err
-		}
+		entry.ID = e.ID
 	}
 
 	if e.Password != "" {
-		if d.Entries[index].Password, err = encrypt.Encrypt(masterPassword, e.Password); err != nil {
-			return err
+		entry.Password = e.Password
+	}
+
+	if e.OTP != "" {
+		entry.OTP = e.OTP
+	}
+
+	if e.Type != "" {
+		entry.Type = e.Type
+	}
+
+	if e.Note != "" {
+		entry.Note = e.Note
+	}
+
+	if removed.Policy {
+		entry.Policy = ""
+	}
+
+	if e.Policy != "" {
+		entry.Policy = e.Policy
+	}
+
+	for _, name := range removed.Fields {
+		if !entry.RemoveField(name) {
+			return fmt.Errorf("%w: %s", data.ErrFieldNotFound, name)
 		}
 	}
 
+	for _, field := range e.Fields {
+		// a sensitive field stays sensitive: remove it first to store it in plaintext
+		if existing, ok := entry.Field(field.Name); ok && existing.Sensitive {
+			field.Sensitive = true
+		}
+
+		entry.SetField(field)
+	}
+
+	for _, name := range removed.Attachments {
+		if !entry.RemoveAttachment(name) {
+			return fmt.Errorf("%w: %s", data.ErrAttachmentNotFound, name)
+		}
+	}
+
+	for _, attachment := range e.Attachments {
+		entry.SetAttachment(attachment)
+	}
+
+	changed := data.Changes(decrypted, entry)
+
+	if err := entry.Encrypt(keys); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	retention := retention()
+
+	if len(changed) > 0 {
+		entry.Record(previous, changed, now, retention)
+	}
+
+	d.Entries[index] = entry
+
+	// so old versions are dropped even if their entry does not change anymore
+	for idx := range d.Entries {
+		d.Entries[idx].PruneHistory(retention, now)
+	}
+
+	d.PurgeTrash(config.GetConfig().TrashRetention(), now)
+
 	return d.ToFile(config.PasumanDataFile)
 }
+
+// retention - previous versions of entries kept, as configured.
+func retention() data.Retention {
+	conf := config.GetConfig()
+
+	return data.Retention{Versions: conf.HistoryVersions(), MaxAge: conf.HistoryMaxAge()}
+}
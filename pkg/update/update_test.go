@@ -40,6 +40,10 @@ func TestUpdate(t *testing.T) {
 		Site:        "https://mysupersite.pasuman",
 		ID:          "myId",
 		Password:    "p4$$w0rd!",
+		Fields: []data.Field{
+			{Name: "PIN", Value: "1234", Sensitive: true},
+			{Name: "Account number", Value: "FR76-1234"},
+		},
 	}
 	_, err := add.Add(pasumantest.TestMasterPassword, entry)
 	require.NoError(t, err)
@@ -47,6 +51,8 @@ func TestUpdate(t *testing.T) {
 	tests := []struct {
 		uniqueID string
 		entry    data.Entry
+		removed  Removed
+		want     data.Entry
 		wantErr  error
 	}{
 		{
@@ -58,8 +64,70 @@ func TestUpdate(t *testing.T) {
 				Site:        "https://newsite.pasuman",
 				ID:          "newId",
 				Password:    "n€wp4$$w0rd!",
+				Policy:      "bank",
+				Fields: []data.Field{
+					{Name: "Recovery codes", Value: "abcd efgh", Sensitive: true},
+					{Name: "PIN", Value: "5678"},
+				},
+			},
+			removed: Removed{Fields: []string{"Account number"}},
+			want: data.Entry{
+				UniqueID:    "newId1",
+				Description: "New desc",
+				Tags:        []string{"tag2", "tag3"},
+				Site:        "https://newsite.pasuman",
+				ID:          "newId",
+				Password:    "n€wp4$$w0rd!",
+				Policy:      "bank",
+				Fields: []data.Field{
+					{Name: "PIN", Value: "5678", Sensitive: true},
+					{Name: "Recovery codes", Value: "abcd efgh", Sensitive: true},
+				},
 			},
 		},
+		{
+			uniqueID: "newId1",
+			entry:    data.Entry{Policy: "wifi"},
+			want: data.Entry{
+				UniqueID:    "newId1",
+				Description: "New desc",
+				Tags:        []string{"tag2", "tag3"},
+				Site:        "https://newsite.pasuman",
+				ID:          "newId",
+				Password:    "n€wp4$$w0rd!",
+				Policy:      "wifi",
+				Fields: []data.Field{
+					{Name: "PIN", Value: "5678", Sensitive: true},
+					{Name: "Recovery codes", Value: "abcd efgh", Sensitive: true},
+				},
+			},
+		},
+		{
+			uniqueID: "newId1",
+			removed:  Removed{Policy: true},
+			want: data.Entry{
+				UniqueID:    "newId1",
+				Description: "New desc",
+				Tags:        []string{"tag2", "tag3"},
+				Site:        "https://newsite.pasuman",
+				ID:          "newId",
+				Password:    "n€wp4$$w0rd!",
+				Fields: []data.Field{
+					{Name: "PIN", Value: "5678", Sensitive: true},
+					{Name: "Recovery codes", Value: "abcd efgh", Sensitive: true},
+				},
+			},
+		},
+		{
+			uniqueID: "newId1",
+			removed:  Removed{Fields: []string{"Account number"}},
+			wantErr:  data.ErrFieldNotFound,
+		},
+		{
+			uniqueID: "newId1",
+			removed:  Removed{Attachments: []string{"id_ed25519"}},
+			wantErr:  data.ErrAttachmentNotFound,
+		},
 		{
 			uniqueID: "id3",
 			wantErr:  ErrNotFound,
@@ -67,15 +135,18 @@ func TestUpdate(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		err := Update(pasumantest.TestMasterPassword, tt.uniqueID, tt.entry)
+		err := Update(pasumantest.TestMasterPassword, tt.uniqueID, tt.entry, tt.removed)
 
 		require.ErrorIs(t, err, tt.wantErr)
 
 		if tt.wantErr == nil {
-			updated, err := get.Sensitive(pasumantest.TestMasterPassword, tt.entry.UniqueID)
+			updated, err := get.Sensitive(pasumantest.TestMasterPassword, tt.want.UniqueID)
 			require.NoError(t, err)
 
-			require.Equal(t, tt.entry, updated)
+			// previous versions are tested by TestHistory
+			updated.History = nil
+
+			require.Equal(t, tt.want, updated)
 		}
 	}
 }
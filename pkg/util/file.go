@@ -0,0 +1,83 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic - write content to a temporary file created in the same directory as path,
+// sync it to disk, then rename it over path and sync the directory.
+// This way, path always contains either its previous content or the new one,
+// even if the process crashes or the disk is full or removed in the middle of the write.
+func WriteFileAtomic(path string, content []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmpFile, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	tmpFileName := tmpFile.Name()
+
+	defer func() {
+		// no-op when the rename succeeded
+		_ = os.Remove(tmpFileName)
+	}()
+
+	if err := writeAndSync(tmpFile, content, perm); err != nil {
+		_ = tmpFile.Close()
+
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpFileName, path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func writeAndSync(f *os.File, content []byte, perm os.FileMode) error {
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// syncDir - sync a directory so that a rename done inside it is persisted on disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -25,17 +25,45 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"golang.org/x/term"
 )
 
-var errInvalidTerminal = errors.New("stdin and stdout should be terminal")
+var errInvalidTerminal = errors.New("stdout should be a terminal when stdin is a terminal")
 
-// ReadLine - read from stdin and handles backspace.
+// stdin - buffered, so consecutive lines piped to stdin are read by consecutive prompts.
+var stdin = bufio.NewReader(os.Stdin)
+
+// SetStdin - read lines and passwords from r, instead of stdin, when stdin is not a terminal.
+func SetStdin(r io.Reader) {
+	stdin = bufio.NewReader(r)
+}
+
+// readPipedLine - read a line from stdin when it is not a terminal (e.g. piped from another command).
+func readPipedLine() (string, error) {
+	return readLine(stdin)
+}
+
+// readLine - read a line, without the line ending. At the end of r, an empty line is returned.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ReadLine - read from stdin and handles backspace. If stdin is not a terminal, a line is read as is.
 // Inspired from https://gist.github.com/artyom/a59e2707976124f387f5
 func ReadLine() (string, error) {
-	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return readPipedLine()
+	}
+
+	if !term.IsTerminal(int(os.Stdout.Fd())) {
 		return "", errInvalidTerminal
 	}
 
@@ -68,18 +96,20 @@ func ReadLine() (string, error) {
 }
 
 // ReadPassword - read password from stdin and handle CTRL+C to restore.
-// Inspired from https://groups.google.com/g/golang-nuts/c/DCl8xUJMJJ0.
+// If stdin is not a terminal, a line is read as is (see `PasswordSource` to read the master password
+// from elsewhere). Inspired from https://groups.google.com/g/golang-nuts/c/DCl8xUJMJJ0.
 func ReadPassword() (string, error) {
 	// nolint: unconvert
 	// int conversion is necessary to build for windows
 	fd := int(syscall.Stdin)
 
 	if !term.IsTerminal(fd) {
-		// if not a terminal, use environment variable
-		// warning: it should not be used outside of tests
+		// warning: this environment variable should not be used outside of tests
 		if password := os.Getenv("PASUMAN_MASTER_PASSWORD"); password != "" {
 			return password, nil
 		}
+
+		return readPipedLine()
 	}
 
 	oldState, err := term.GetState(fd)
@@ -118,3 +148,10 @@ func CopyToClipboard(s string) {
 	fmt.Fprintf(out, "\033]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(s)))
 	out.Flush()
 }
+
+// IsTerminal - whether w writes to a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+
+	return ok && term.IsTerminal(int(f.Fd()))
+}
@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -51,3 +52,36 @@ func TestCopyToClipboard(t *testing.T) {
 
 	require.Equal(t, "\033]52;c;"+toCopyBase64+"\a", string(fakeStdoutContent))
 }
+
+func TestReadLinePiped(t *testing.T) {
+	realStdin := os.Stdin
+	defer func() { os.Stdin = realStdin }()
+
+	// stdin is not a terminal
+	fakeStdin, _, err := os.Pipe()
+	require.NoError(t, err)
+
+	os.Stdin = fakeStdin
+
+	SetStdin(strings.NewReader("first\r\n\n  second  \nlast"))
+	defer SetStdin(realStdin)
+
+	for _, want := range []string{"first", "", "  second  ", "last", ""} {
+		got, err := ReadLine()
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, IsTerminal(&strings.Builder{}))
+
+	_, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	defer w.Close()
+
+	require.False(t, IsTerminal(w))
+}
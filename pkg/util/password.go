@@ -0,0 +1,114 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+var (
+	errInsecurePasswordFile = errors.New("password file must only be readable and writable by its owner " +
+		"(e.g. chmod 600)")
+	errPasswordCommandFailed = errors.New("password command failed")
+)
+
+// PasswordSource - where to read the master password from, instead of prompting for it:
+// a file descriptor (FD, ignored if negative), a file (File), or the output of a shell command (Command).
+// Only the first line is used.
+type PasswordSource struct {
+	FD      int
+	File    string
+	Command string
+}
+
+// IsSet - whether the master password must be read from this source.
+func (s PasswordSource) IsSet() bool {
+	return s.FD >= 0 || s.File != "" || s.Command != ""
+}
+
+// Read - read the master password from the source.
+func (s PasswordSource) Read() (string, error) {
+	switch {
+	case s.FD == 0:
+		// stdin may also be used by other prompts
+		return readPipedLine()
+	case s.FD > 0:
+		f := os.NewFile(uintptr(s.FD), "password-fd")
+		if f == nil {
+			return "", os.ErrInvalid
+		}
+		defer f.Close()
+
+		return readFirstLine(f)
+	case s.File != "":
+		return readPasswordFile(s.File)
+	case s.Command != "":
+		return runPasswordCommand(s.Command)
+	default:
+		return ReadPassword()
+	}
+}
+
+func readPasswordFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	// permissions are not meaningful on Windows
+	if !info.Mode().IsRegular() || (runtime.GOOS != "windows" && info.Mode().Perm()&0o077 != 0) {
+		return "", fmt.Errorf("%w: %s is %s", errInsecurePasswordFile, file, info.Mode())
+	}
+
+	return readFirstLine(f)
+}
+
+// runPasswordCommand - run command with the shell, for example to get the master password from a keyring
+// (`secret-tool lookup pasuman master-password`) or a pinentry wrapper. It can interact with the user
+// on the terminal: only its standard output is read.
+func runPasswordCommand(command string) (string, error) {
+	var stdout bytes.Buffer
+
+	shell := exec.Command("sh", "-c", command)
+	shell.Stdin = os.Stdin
+	shell.Stdout = &stdout
+	shell.Stderr = os.Stderr
+
+	if err := shell.Run(); err != nil {
+		return "", fmt.Errorf("%w: %v", errPasswordCommandFailed, err)
+	}
+
+	return readFirstLine(&stdout)
+}
+
+func readFirstLine(r io.Reader) (string, error) {
+	return readLine(bufio.NewReader(r))
+}
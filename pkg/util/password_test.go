@@ -0,0 +1,110 @@
+// This file is part of pasuman (https://github.com/norbjd/pasuman).
+//
+// pasuman is a command-line password manager.
+// Copyright (C) 2022 norbjd
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, version 3 of the License.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordSource(t *testing.T) {
+	dir := t.TempDir()
+
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("p4$$w0rd!\nignored\n"), 0o600))
+
+	insecurePasswordFile := filepath.Join(dir, "insecure-password")
+	require.NoError(t, os.WriteFile(insecurePasswordFile, []byte("p4$$w0rd!"), 0o640))
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+
+	_, err = w.WriteString("p4$$w0rd!\r\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	tests := []struct {
+		source  PasswordSource
+		want    string
+		wantErr error
+	}{
+		{
+			source: PasswordSource{FD: int(r.Fd())},
+			want:   "p4$$w0rd!",
+		},
+		{
+			source: PasswordSource{FD: -1, File: passwordFile},
+			want:   "p4$$w0rd!",
+		},
+		{
+			source:  PasswordSource{FD: -1, File: insecurePasswordFile},
+			wantErr: errInsecurePasswordFile,
+		},
+		{
+			source:  PasswordSource{FD: -1, File: dir},
+			wantErr: errInsecurePasswordFile,
+		},
+		{
+			source:  PasswordSource{FD: -1, File: filepath.Join(dir, "does-not-exist")},
+			wantErr: os.ErrNotExist,
+		},
+		{
+			source: PasswordSource{FD: -1, Command: "printf 'p4$$w0rd!\\nignored\\n'"},
+			want:   "p4$$w0rd!",
+		},
+		{
+			source:  PasswordSource{FD: -1, Command: "exit 1"},
+			wantErr: errPasswordCommandFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		require.True(t, tt.source.IsSet())
+
+		got, err := tt.source.Read()
+		if tt.wantErr != nil {
+			require.ErrorIs(t, err, tt.wantErr, tt.source)
+		} else {
+			require.NoError(t, err, tt.source)
+			require.Equal(t, tt.want, got, tt.source)
+		}
+	}
+
+	require.False(t, PasswordSource{FD: -1}.IsSet())
+
+	// already closed by `Read`
+	require.Error(t, r.Close())
+}
+
+func TestPasswordSourceStdin(t *testing.T) {
+	SetStdin(strings.NewReader("p4$$w0rd!\nmy description\n"))
+	defer SetStdin(os.Stdin)
+
+	got, err := PasswordSource{FD: 0}.Read()
+	require.NoError(t, err)
+	require.Equal(t, "p4$$w0rd!", got)
+
+	// next lines are still available for prompts
+	line, err := readPipedLine()
+	require.NoError(t, err)
+	require.Equal(t, "my description", line)
+}